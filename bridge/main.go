@@ -0,0 +1,263 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/cjlapao/servicebuscli-go/templating"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+var logger = log.Get()
+
+// Defaults of the bridge options
+const (
+	DefaultRetries = 3
+	DefaultBackoff = time.Second
+	DefaultTimeout = 10 * time.Second
+)
+
+// Body mappings of the requests
+const (
+	// BodyData posts the body of the message as it is
+	BodyData = "data"
+	// BodyEnvelope posts the message properties and the body as a json object
+	BodyEnvelope = "envelope"
+)
+
+// lockMargin is how long before the lock of a message expires the retries stop, so it can still be settled
+const lockMargin = 5 * time.Second
+
+// Bridge Posts the messages of a queue or subscription to an http endpoint, messages are completed when the
+// endpoint answers with a 2xx status, failed requests are retried with an exponential backoff and the
+// message is abandoned, or dead lettered, once the retries run out
+type Bridge struct {
+	URL        string
+	Headers    map[string]string
+	Body       string
+	Retries    int
+	Backoff    time.Duration
+	Timeout    time.Duration
+	DeadLetter bool
+	Transform  *transform.Pipeline
+
+	cli      *sbcli.ServiceBusCli
+	from     *sbcli.Endpoint
+	template *template.Template
+	client   *http.Client
+
+	delivered int64
+	failed    int64
+}
+
+// NewBridge Creates a bridge from a queue or subscription to an url
+func NewBridge(cli *sbcli.ServiceBusCli, from *sbcli.Endpoint, url string) (*Bridge, error) {
+	if !from.CanReceive() {
+		return nil, errors.New("can not receive messages from " + from.String() + ", topics need a subscription")
+	}
+	if url == "" {
+		return nil, errors.New("the bridge needs an url to post the messages to")
+	}
+
+	return &Bridge{
+		URL:     url,
+		Headers: make(map[string]string),
+		Body:    BodyData,
+		Retries: DefaultRetries,
+		Backoff: DefaultBackoff,
+		Timeout: DefaultTimeout,
+		cli:     cli,
+		from:    from,
+		client:  &http.Client{},
+	}, nil
+}
+
+// SetTemplate Maps the messages with a go template instead of the body mapping, the template gets the
+// envelope of the message, for example {"order": {{json .Body}}, "type": "{{.Label}}"}
+func (b *Bridge) SetTemplate(text string) error {
+	parsed, err := template.New("body").Funcs(templating.FormatFuncs()).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	b.template = parsed
+	return nil
+}
+
+// Run Posts the messages until the context is cancelled
+func (b *Bridge) Run(ctx context.Context) error {
+	switch b.Body {
+	case BodyData, BodyEnvelope:
+	default:
+		return fmt.Errorf("invalid body mapping %v, it can be %v or %v", b.Body, BodyData, BodyEnvelope)
+	}
+	if b.Retries < 0 {
+		b.Retries = 0
+	}
+	if b.Backoff <= 0 {
+		b.Backoff = DefaultBackoff
+	}
+	if b.Timeout <= 0 {
+		b.Timeout = DefaultTimeout
+	}
+
+	return b.cli.StreamEndpointMessages(ctx, b.from, b.handle)
+}
+
+// Delivered Gets the number of messages posted and completed
+func (b *Bridge) Delivered() int64 {
+	return atomic.LoadInt64(&b.delivered)
+}
+
+// Failed Gets the number of messages that could not be posted
+func (b *Bridge) Failed() int64 {
+	return atomic.LoadInt64(&b.failed)
+}
+
+// handle posts a message and settles it, errors are only logged as returning them stops the listener
+func (b *Bridge) handle(ctx context.Context, msg *servicebus.Message) error {
+	status, err := b.deliver(ctx, msg)
+
+	settleCtx, cancel := context.WithTimeout(context.Background(), sbcli.SettleTimeout)
+	defer cancel()
+
+	var settleErr error
+	switch {
+	case err == nil:
+		atomic.AddInt64(&b.delivered, 1)
+		logger.LogHighlight("Delivered message %v to %v with status %v", log.Info, msg.ID, b.URL, strconv.Itoa(status))
+		settleErr = msg.Complete(settleCtx)
+	case ctx.Err() != nil:
+		// the bridge is stopping so the message goes back to the entity for the next time
+		settleErr = msg.Abandon(settleCtx)
+	case b.DeadLetter:
+		atomic.AddInt64(&b.failed, 1)
+		logger.LogHighlight("Could not deliver message %v to %v, dead lettering it, %v", log.Error, msg.ID, b.URL, err.Error())
+		settleErr = msg.DeadLetter(settleCtx, err)
+	default:
+		atomic.AddInt64(&b.failed, 1)
+		logger.LogHighlight("Could not deliver message %v to %v, abandoning it, %v", log.Error, msg.ID, b.URL, err.Error())
+		settleErr = msg.Abandon(settleCtx)
+	}
+
+	if settleErr != nil {
+		logger.LogHighlight("Could not settle message %v, %v", log.Error, msg.ID, settleErr.Error())
+	}
+	return nil
+}
+
+// deliver posts the message retrying the failures that can succeed later, network errors, timeouts, 429 and
+// 5xx statuses, other statuses fail straight away. The retries stop early when the next attempt could end after
+// the lock of the message expires, as the message could not be settled anymore
+func (b *Bridge) deliver(ctx context.Context, msg *servicebus.Message) (int, error) {
+	msg, err := b.Transform.Apply(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	body, contentType, err := b.body(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	backoff := b.Backoff
+	for attempt := 0; ; attempt++ {
+		status, err := b.post(ctx, msg, body, contentType)
+		if err == nil {
+			return status, nil
+		}
+		if attempt >= b.Retries || !retryable(status) {
+			return status, err
+		}
+		if msg.SystemProperties != nil && msg.SystemProperties.LockedUntil != nil {
+			if time.Now().Add(backoff + b.Timeout + lockMargin).After(*msg.SystemProperties.LockedUntil) {
+				return status, fmt.Errorf("%w, the lock of the message expires before it can be retried", err)
+			}
+		}
+
+		logger.LogHighlight("Retrying message %v in %v, %v", log.Warning, msg.ID, backoff.String(), err.Error())
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (b *Bridge) post(ctx context.Context, msg *servicebus.Message, body []byte, contentType string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("X-Message-Id", msg.ID)
+	if msg.Label != "" {
+		request.Header.Set("X-Message-Label", msg.Label)
+	}
+	if msg.CorrelationID != "" {
+		request.Header.Set("X-Correlation-Id", msg.CorrelationID)
+	}
+	for key, value := range b.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("the endpoint answered with status %v", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// body maps the message to the body of the request and its content type
+func (b *Bridge) body(msg *servicebus.Message) ([]byte, string, error) {
+	if b.template == nil && b.Body == BodyData {
+		contentType := msg.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		return msg.Data, contentType, nil
+	}
+
+	envelope := entities.MessageEnvelope{}
+	envelope.FromServiceBus(msg)
+	if b.template == nil {
+		content, err := json.Marshal(envelope)
+		return content, "application/json", err
+	}
+
+	var buffer bytes.Buffer
+	if err := b.template.Execute(&buffer, envelope); err != nil {
+		return nil, "", err
+	}
+	return buffer.Bytes(), "application/json", nil
+}
+
+// retryable returns true if a request that failed with the status can succeed later, zero is a request
+// that did not get an answer
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
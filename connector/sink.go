@@ -0,0 +1,282 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+var logger = log.Get()
+
+// Formats of the files written by the sink and read by the source
+const (
+	// FormatFiles writes every message envelope to its own json file
+	FormatFiles = "files"
+	// FormatNDJSON writes the message envelopes as lines of rolling segments
+	FormatNDJSON = "ndjson"
+)
+
+// Defaults of the segment rotation
+const (
+	DefaultMaxSegmentSize = 64 * 1024 * 1024
+	DefaultMaxSegmentAge  = time.Hour
+)
+
+// partSuffix is added to the files that are still being written, they are renamed once they are complete so
+// whoever reads the folder never sees half a file
+const partSuffix = ".part"
+
+// Sink Writes the messages of a queue or subscription to a folder, a message is only completed once it is
+// written to the disk
+type Sink struct {
+	Dir            string
+	Format         string
+	MaxSegmentSize int64
+	MaxSegmentAge  time.Duration
+
+	cli  *sbcli.ServiceBusCli
+	from *sbcli.Endpoint
+
+	// segment is the ndjson file being written, it is rotated by the handler and by the age ticker
+	mutex          sync.Mutex
+	segment        *os.File
+	segmentSize    int64
+	segmentStarted time.Time
+
+	written int64
+	failed  int64
+}
+
+// NewSink Creates a sink from a queue or subscription to a folder
+func NewSink(cli *sbcli.ServiceBusCli, from *sbcli.Endpoint, dir string) (*Sink, error) {
+	if !from.CanReceive() {
+		return nil, fmt.Errorf("can not receive messages from %v, topics need a subscription", from.String())
+	}
+	if dir == "" {
+		return nil, errors.New("the sink needs a folder to write the messages to")
+	}
+
+	return &Sink{
+		Dir:            dir,
+		Format:         FormatFiles,
+		MaxSegmentSize: DefaultMaxSegmentSize,
+		MaxSegmentAge:  DefaultMaxSegmentAge,
+		cli:            cli,
+		from:           from,
+	}, nil
+}
+
+// Run Writes the messages until the context is cancelled, the segment being written is closed before returning
+func (s *Sink) Run(ctx context.Context) error {
+	switch s.Format {
+	case FormatFiles, FormatNDJSON:
+	default:
+		return fmt.Errorf("invalid format %v, it can be %v or %v", s.Format, FormatFiles, FormatNDJSON)
+	}
+	if s.MaxSegmentSize <= 0 {
+		s.MaxSegmentSize = DefaultMaxSegmentSize
+	}
+	if s.MaxSegmentAge <= 0 {
+		s.MaxSegmentAge = DefaultMaxSegmentAge
+	}
+	if err := os.MkdirAll(s.Dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	if s.Format == FormatNDJSON {
+		done := make(chan bool)
+		defer close(done)
+		go s.rotateByAge(done)
+		defer func() {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			if err := s.closeSegment(); err != nil {
+				logger.Error(err.Error())
+			}
+		}()
+	}
+
+	return s.cli.StreamEndpointMessages(ctx, s.from, s.handle)
+}
+
+// Written Gets the number of messages written
+func (s *Sink) Written() int64 {
+	return atomic.LoadInt64(&s.written)
+}
+
+// Failed Gets the number of messages that could not be written
+func (s *Sink) Failed() int64 {
+	return atomic.LoadInt64(&s.failed)
+}
+
+// handle writes a message and settles it, errors are only logged as returning them stops the listener
+func (s *Sink) handle(ctx context.Context, msg *servicebus.Message) error {
+	envelope := entities.MessageEnvelope{}
+	envelope.FromServiceBus(msg)
+
+	var err error
+	if s.Format == FormatNDJSON {
+		err = s.appendToSegment(envelope)
+	} else {
+		err = s.writeFile(envelope)
+	}
+
+	settleCtx, cancel := context.WithTimeout(context.Background(), sbcli.SettleTimeout)
+	defer cancel()
+	if err != nil {
+		atomic.AddInt64(&s.failed, 1)
+		logger.LogHighlight("Could not write message %v, abandoning it, %v", log.Error, msg.ID, err.Error())
+		err = msg.Abandon(settleCtx)
+	} else {
+		atomic.AddInt64(&s.written, 1)
+		err = msg.Complete(settleCtx)
+	}
+
+	if err != nil {
+		logger.LogHighlight("Could not settle message %v, %v", log.Error, msg.ID, err.Error())
+	}
+	return nil
+}
+
+// writeFile writes the envelope to its own file named after the time and the id of the message
+func (s *Sink) writeFile(envelope entities.MessageEnvelope) error {
+	content, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(s.Dir, timestamp(time.Now())+"-"+safeName(envelope.ID)+".json")
+	if err := writeSynced(name+partSuffix, content); err != nil {
+		return err
+	}
+	return os.Rename(name+partSuffix, name)
+}
+
+// appendToSegment adds the envelope as a line of the current segment, starting a new one when it would get
+// bigger than the maximum size
+func (s *Sink) appendToSegment(envelope entities.MessageEnvelope) error {
+	content, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.segment != nil && s.segmentSize > 0 && s.segmentSize+int64(len(content)) > s.MaxSegmentSize {
+		if err := s.closeSegment(); err != nil {
+			return err
+		}
+	}
+	if s.segment == nil {
+		name := filepath.Join(s.Dir, "messages-"+timestamp(time.Now())+".ndjson"+partSuffix)
+		s.segment, err = os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		if err != nil {
+			s.segment = nil
+			return err
+		}
+		s.segmentSize = 0
+		s.segmentStarted = time.Now()
+	}
+
+	if _, err := s.segment.Write(content); err != nil {
+		return err
+	}
+	s.segmentSize += int64(len(content))
+	return s.segment.Sync()
+}
+
+// rotateByAge closes the segment once it is older than the maximum age, even if no messages are arriving
+func (s *Sink) rotateByAge(done chan bool) {
+	interval := s.MaxSegmentAge / 10
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			if s.segment != nil && time.Since(s.segmentStarted) >= s.MaxSegmentAge {
+				if err := s.closeSegment(); err != nil {
+					logger.Error(err.Error())
+				}
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// closeSegment closes the segment and removes its part suffix, the mutex needs to be held
+func (s *Sink) closeSegment() error {
+	if s.segment == nil {
+		return nil
+	}
+
+	name := s.segment.Name()
+	err := s.segment.Close()
+	s.segment = nil
+	if err != nil {
+		return err
+	}
+
+	logger.LogHighlight("Closed segment %v with %v bytes", log.Info, strings.TrimSuffix(filepath.Base(name), partSuffix), fmt.Sprint(s.segmentSize))
+	return os.Rename(name, strings.TrimSuffix(name, partSuffix))
+}
+
+// writeSynced writes a file and flushes it to the disk
+func writeSynced(name string, content []byte) error {
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// timestamp formats a time so the files sort in the order they were written
+func timestamp(value time.Time) string {
+	return value.UTC().Format("20060102T150405.000000000Z")
+}
+
+// safeName replaces the characters that can not be part of a file name
+func safeName(value string) string {
+	if value == "" {
+		return "message"
+	}
+
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, value)
+}
@@ -0,0 +1,282 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+// Formats of the files read by the source, besides the formats written by the sink
+const (
+	// FormatBody sends the content of every file as the body of a message
+	FormatBody = "body"
+)
+
+// DefaultPollInterval is how often the source looks for new files
+const DefaultPollInterval = 5 * time.Second
+
+// settleAge is how long a file needs to be unchanged before it is sent, so files still being copied are not
+// picked up
+const settleAge = time.Second
+
+// Source Watches a folder sending its files as messages to a queue or topic, sent files are moved to the done
+// folder and the ones that could not be sent to the failed folder
+type Source struct {
+	Dir          string
+	DoneDir      string
+	FailedDir    string
+	Format       string
+	Label        string
+	ContentType  string
+	PollInterval time.Duration
+	Transform    *transform.Pipeline
+
+	cli *sbcli.ServiceBusCli
+	to  *sbcli.Endpoint
+
+	sent   int64
+	failed int64
+}
+
+// NewSource Creates a source from a folder to a queue or topic
+func NewSource(cli *sbcli.ServiceBusCli, dir string, to *sbcli.Endpoint) (*Source, error) {
+	if !to.CanSend() {
+		return nil, fmt.Errorf("can not send messages to %v, send them to its topic", to.String())
+	}
+	if dir == "" {
+		return nil, errors.New("the source needs a folder to read the files from")
+	}
+
+	return &Source{
+		Dir:          dir,
+		DoneDir:      filepath.Join(dir, "done"),
+		FailedDir:    filepath.Join(dir, "failed"),
+		Format:       FormatBody,
+		PollInterval: DefaultPollInterval,
+		cli:          cli,
+		to:           to,
+	}, nil
+}
+
+// Run Sends the files of the folder until the context is cancelled
+func (s *Source) Run(ctx context.Context) error {
+	switch s.Format {
+	case FormatBody, FormatFiles, FormatNDJSON:
+	default:
+		return fmt.Errorf("invalid format %v, it can be %v, %v or %v", s.Format, FormatBody, FormatFiles, FormatNDJSON)
+	}
+	if s.PollInterval <= 0 {
+		s.PollInterval = DefaultPollInterval
+	}
+	for _, dir := range []string{s.DoneDir, s.FailedDir} {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+
+	sender, err := s.cli.GetEndpointSender(s.to)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), sbcli.SettleTimeout)
+		defer cancel()
+		sender.Close(closeCtx)
+	}()
+
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Poll(ctx, sender); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sent Gets the number of files sent
+func (s *Source) Sent() int64 {
+	return atomic.LoadInt64(&s.sent)
+}
+
+// Failed Gets the number of files that could not be sent
+func (s *Source) Failed() int64 {
+	return atomic.LoadInt64(&s.failed)
+}
+
+// Poll Sends the files in the folder from the oldest, only an error reading the folder stops the source
+func (s *Source) Poll(ctx context.Context, sender sbcli.MessageSender) error {
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return nil
+		}
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, partSuffix) || time.Since(file.ModTime()) < settleAge {
+			continue
+		}
+
+		path := filepath.Join(s.Dir, name)
+		count, err := s.sendFile(ctx, sender, path)
+		if err != nil {
+			atomic.AddInt64(&s.failed, 1)
+			logger.LogHighlight("Could not send file %v, moving it to %v, %v", log.Error, name, s.FailedDir, err.Error())
+			err = moveFile(path, s.FailedDir)
+		} else {
+			atomic.AddInt64(&s.sent, 1)
+			logger.LogHighlight("Sent file %v as %v messages to %v", log.Info, name, fmt.Sprint(count), s.to.String())
+			err = moveFile(path, s.DoneDir)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendFile sends the messages of a file in batches, returning how many were sent
+func (s *Source) sendFile(ctx context.Context, sender sbcli.MessageSender, path string) (int, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	messages, err := s.messages(path, content)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		return 0, errors.New("the file has no messages")
+	}
+
+	return len(messages), sbcli.SendMessageBatch(ctx, sender, messages...)
+}
+
+// messages builds the messages of a file, an envelope written by the sink keeps its properties and the
+// properties of the source are only used when it does not have them
+func (s *Source) messages(path string, content []byte) ([]*servicebus.Message, error) {
+	envelopes := make([]entities.MessageEnvelope, 0)
+	switch s.Format {
+	case FormatBody:
+		envelopes = append(envelopes, entities.MessageEnvelope{
+			Body: string(content),
+		})
+	case FormatFiles:
+		envelope, err := decodeEnvelope(content)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			envelope, err := decodeEnvelope(scanner.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("line %v is not a message envelope, %v", line, err.Error())
+			}
+			envelopes = append(envelopes, envelope)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := make([]*servicebus.Message, 0)
+	for i := range envelopes {
+		envelope := &envelopes[i]
+		if envelope.Label == "" {
+			envelope.Label = s.Label
+		}
+		if envelope.Label == "" {
+			envelope.Label = filepath.Base(path)
+		}
+		if envelope.ContentType == "" {
+			envelope.ContentType = s.ContentType
+		}
+		if _, isString := envelope.Body.(string); envelope.ContentType == "" && !isString {
+			envelope.ContentType = "application/json"
+		}
+		if envelope.ContentType == "" {
+			envelope.ContentType = contentType(path)
+		}
+
+		msg, err := envelope.ToServiceBus()
+		if err != nil {
+			return nil, err
+		}
+		msg, err = s.Transform.Apply(msg)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// decodeEnvelope decodes an envelope keeping the numbers of the body and the user properties as they are
+func decodeEnvelope(content []byte) (entities.MessageEnvelope, error) {
+	envelope := entities.MessageEnvelope{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	err := decoder.Decode(&envelope)
+
+	return envelope, err
+}
+
+// contentType guesses the content type of a file from its extension
+func contentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "application/json"
+	case ".xml":
+		return "application/xml"
+	case ".txt", ".csv":
+		return "text/plain"
+	}
+
+	return "application/octet-stream"
+}
+
+// moveFile moves a file to a folder, adding the time to its name if there is already a file with it
+func moveFile(path string, dir string) error {
+	target := filepath.Join(dir, filepath.Base(path))
+	if _, err := os.Stat(target); err == nil {
+		target = filepath.Join(dir, timestamp(time.Now())+"-"+filepath.Base(path))
+	}
+
+	return os.Rename(path, target)
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/filter"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+// RunExpectation Waits for a message matching the expression in a queue or subscription, replying with the message
+// once it arrives or with matched false when it did not arrive in time
+func (c *Controller) RunExpectation(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Empty Body", "The body of the request is null or empty"))
+		return
+	}
+
+	expectation := entities.ExpectationRequest{}
+	if err := json.Unmarshal(reqBody, &expectation); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Failed Body Deserialization", "There was an error deserializing the body of the request"))
+		return
+	}
+
+	if err := expectation.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Expectation", err.Error()))
+		return
+	}
+
+	where, err := filter.Parse(expectation.Where)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Where Expression", err.Error()))
+		return
+	}
+
+	endpoint := &servicebus.Endpoint{Queue: expectation.Queue, Topic: expectation.Topic, Subscription: expectation.Subscription}
+	if endpoint.Queue != "" {
+		if queue, err := sbcli.GetQueueDetails(endpoint.Queue); queue == nil {
+			writeEntityNotFound(w, "Queue Not Found", "Queue with name "+endpoint.Queue+" was not found in "+sbcli.Namespace.Name, err)
+			return
+		}
+	} else if subscription, err := sbcli.GetSubscription(endpoint.Topic, endpoint.Subscription); subscription == nil {
+		writeEntityNotFound(w, "Subscription not found", "The Subscription "+endpoint.Subscription+" was not found on "+endpoint.Topic+" topic in the service bus "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	start := time.Now()
+	msg, err := sbcli.ExpectMessage(r.Context(), endpoint, servicebus.ExpectOptions{
+		Where:      where,
+		Within:     expectation.Within(),
+		Consume:    expectation.Consume,
+		DeadLetter: expectation.DeadLetter,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Expectation Failed", err.Error()))
+		return
+	}
+
+	response := entities.ExpectationResponse{
+		Matched:        msg != nil,
+		ElapsedInMilli: time.Since(start).Milliseconds(),
+	}
+	if msg != nil {
+		envelope := entities.MessageEnvelope{}
+		envelope.FromServiceBus(msg)
+		response.Message = &envelope
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
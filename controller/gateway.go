@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/gateway"
+	"github.com/gorilla/mux"
+)
+
+// registerGatewayRoutes Adds the routes of the gateway to the router, they are added after the api routes so a
+// route that would hide one of them is skipped
+func registerGatewayRoutes(router *mux.Router, config *gateway.Config) {
+	for i := range config.Routes {
+		route := &config.Routes[i]
+		for _, method := range route.Methods {
+			var match mux.RouteMatch
+			if request, err := http.NewRequest(method, route.Path, nil); err == nil && router.Match(request, &match) && match.MatchErr == nil {
+				logger.Error("Gateway route [" + method + "] " + route.Path + " is already an api route, it will not be added")
+				continue
+			}
+
+			router.HandleFunc(route.Path, gatewayHandler(route)).Methods(method)
+			logger.Info("Gateway route [" + method + "] " + route.Path + " sends to " + route.Target())
+		}
+	}
+}
+
+// gatewayHandler Sends every request of the route as a message to its queue or topic
+func gatewayHandler(route *gateway.Route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		message, err := route.NewMessage(r, mux.Vars(r))
+		if errors.Is(err, gateway.ErrBodyTooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusRequestEntityTooLarge, "Body Too Large", "The body of the request is bigger than the maximum of the route"))
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Request", "There was an error reading the request, "+err.Error()))
+			return
+		}
+
+		if route.Queue != "" {
+			err = sbcli.SendQueueServiceBusMessage(route.Queue, message)
+		} else {
+			err = sbcli.SendTopicServiceBusMessage(route.Topic, message)
+		}
+		if writeSchemaError(w, err) {
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadGateway, "Error Sending Message", "There was an error sending the message to "+route.Target()+", "+err.Error()))
+			return
+		}
+
+		w.Header().Set(route.RequestIDHeader, message.CorrelationID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(entities.NewApiSuccessResponse(http.StatusAccepted, "Message was sent successfully to "+route.Target(), map[string]interface{}{
+			"messageId":     message.ID,
+			"correlationId": message.CorrelationID,
+		}))
+	}
+}
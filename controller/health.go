@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+)
+
+// readinessTimeout is how long the readiness check waits for the service bus
+const readinessTimeout = 5 * time.Second
+
+// GetHealth Returns ok while the api is running, used as the liveness probe
+func (c *Controller) GetHealth(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entities.NewApiSuccessResponse(http.StatusOK, "ok", nil))
+}
+
+// GetReadiness Returns ok if the service bus namespace can be reached, used as the readiness probe
+func (c *Controller) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	if err := sbcli.Ping(ctx); err != nil {
+		logger.Error("Readiness check failed, " + err.Error())
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusServiceUnavailable, "Not Ready", "Could not reach the service bus namespace, "+err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entities.NewApiSuccessResponse(http.StatusOK, "Service bus "+sbcli.Namespace.Name+" is reachable", nil))
+}
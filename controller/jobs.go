@@ -0,0 +1,271 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/jobs"
+	cli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/cjlapao/servicebuscli-go/transform"
+	"github.com/gorilla/mux"
+)
+
+// Job types
+const (
+	JobTypeSendBulkTemplate    = "SendBulkTemplate"
+	JobTypePurge               = "Purge"
+	JobTypePurgeDeadLetters    = "PurgeDeadLetters"
+	JobTypeResubmitDeadLetters = "ResubmitDeadLetters"
+)
+
+var jobManager = jobs.NewManager()
+
+// GetJobs Gets all the jobs started by the api
+func (c *Controller) GetJobs(w http.ResponseWriter, r *http.Request) {
+	response := make([]entities.JobResponse, 0)
+	for _, job := range jobManager.List() {
+		jobResponse := entities.JobResponse{}
+		jobResponse.FromJob(job)
+		response = append(response, jobResponse)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetJob Gets the progress of a job
+func (c *Controller) GetJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["jobId"]
+
+	job, exists := jobManager.Get(jobID)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusNotFound, "Job Not Found", "Job with id "+jobID+" was not found"))
+		return
+	}
+
+	response := entities.JobResponse{}
+	response.FromJob(job)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CancelJob Cancels a running job
+func (c *Controller) CancelJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID := vars["jobId"]
+
+	job, exists := jobManager.Get(jobID)
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusNotFound, "Job Not Found", "Job with id "+jobID+" was not found"))
+		return
+	}
+
+	if job.Progress().Status.IsFinished() {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusConflict, "Job Finished", "Job with id "+jobID+" has already finished"))
+		return
+	}
+
+	job.Cancel()
+	logger.Info("Job " + jobID + " was cancelled")
+
+	response := entities.JobResponse{}
+	response.FromJob(job)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// PurgeQueue Starts a job removing all the messages of a queue or its dead letter queue
+func (c *Controller) PurgeQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queueName := vars["queueName"]
+	deadLetter := r.URL.Query().Get("deadletter") == "true"
+
+	queue, err := sbcli.GetQueueDetails(queueName)
+	if queue == nil {
+		writeEntityNotFound(w, "Queue Not Found", "Queue with name "+queueName+" was not found in "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	jobType := JobTypePurge
+	if deadLetter {
+		jobType = JobTypePurgeDeadLetters
+	}
+	total := messageCount(queue.CountDetails, deadLetter)
+
+	startJob(w, jobType, "queues/"+queueName, total, func(ctx context.Context, job *jobs.Job) error {
+		return sbcli.PurgeQueue(ctx, queueName, deadLetter, job.Add)
+	})
+}
+
+// ResubmitQueueDeadLetters Starts a job sending the dead letter messages of a queue back to it
+func (c *Controller) ResubmitQueueDeadLetters(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queueName := vars["queueName"]
+	options, errorResponse := getResubmitOptions(r)
+	if errorResponse != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	queue, err := sbcli.GetQueueDetails(queueName)
+	if queue == nil {
+		writeEntityNotFound(w, "Queue Not Found", "Queue with name "+queueName+" was not found in "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	total := messageCount(queue.CountDetails, true)
+	if options.Max > 0 && options.Max < total {
+		total = options.Max
+	}
+
+	startJob(w, JobTypeResubmitDeadLetters, "queues/"+queueName, total, func(ctx context.Context, job *jobs.Job) error {
+		return sbcli.ResubmitQueueDeadLetters(ctx, queueName, options, job.Add)
+	})
+}
+
+// PurgeSubscription Starts a job removing all the messages of a topic subscription or its dead letter queue
+func (c *Controller) PurgeSubscription(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	topicName := vars["topicName"]
+	subscriptionName := vars["subscriptionName"]
+	deadLetter := r.URL.Query().Get("deadletter") == "true"
+
+	subscription, err := sbcli.GetSubscription(topicName, subscriptionName)
+	if subscription == nil {
+		writeEntityNotFound(w, "Subscription not found", "The Subscription "+subscriptionName+" was not found on "+topicName+" topic in the service bus "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	jobType := JobTypePurge
+	if deadLetter {
+		jobType = JobTypePurgeDeadLetters
+	}
+	total := messageCount(subscription.CountDetails, deadLetter)
+
+	startJob(w, jobType, "topics/"+topicName+"/"+subscriptionName, total, func(ctx context.Context, job *jobs.Job) error {
+		return sbcli.PurgeSubscription(ctx, topicName, subscriptionName, deadLetter, job.Add)
+	})
+}
+
+// ResubmitSubscriptionDeadLetters Starts a job sending the dead letter messages of a topic subscription back to the topic,
+// every subscription with matching rules receives them so the fan out needs to be allowed
+func (c *Controller) ResubmitSubscriptionDeadLetters(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	topicName := vars["topicName"]
+	subscriptionName := vars["subscriptionName"]
+	options, errorResponse := getResubmitOptions(r)
+	if errorResponse != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	if !options.FanOut {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Fan Out Not Allowed", "The dead letters are resubmitted to topic "+topicName+" and every subscription with matching rules receives them, set fanout to true to resubmit them"))
+		return
+	}
+
+	subscription, err := sbcli.GetSubscription(topicName, subscriptionName)
+	if subscription == nil {
+		writeEntityNotFound(w, "Subscription not found", "The Subscription "+subscriptionName+" was not found on "+topicName+" topic in the service bus "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	total := messageCount(subscription.CountDetails, true)
+	if options.Max > 0 && options.Max < total {
+		total = options.Max
+	}
+
+	startJob(w, JobTypeResubmitDeadLetters, "topics/"+topicName+"/"+subscriptionName, total, func(ctx context.Context, job *jobs.Job) error {
+		return sbcli.ResubmitSubscriptionDeadLetters(ctx, topicName, subscriptionName, options, job.Add)
+	})
+}
+
+// getResubmitOptions reads the qty and fanout query attributes of a resubmit request and the transform pipeline of
+// its optional body
+func getResubmitOptions(r *http.Request) (cli.ResubmitOptions, *entities.ApiErrorResponse) {
+	queryValues := r.URL.Query()
+	qty, _ := strconv.Atoi(queryValues.Get("qty"))
+	options := cli.ResubmitOptions{
+		Max:    qty,
+		FanOut: queryValues.Get("fanout") == "true",
+	}
+
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(bytes.TrimSpace(reqBody)) == 0 {
+		return options, nil
+	}
+
+	request := entities.ResubmitRequest{}
+	if err := json.Unmarshal(reqBody, &request); err != nil {
+		return options, entities.NewApiErrorResponse(http.StatusBadRequest, "Failed Body Deserialization", "There was an error deserializing the body of the request, "+err.Error())
+	}
+	if request.Transform != nil {
+		// json is valid yaml so the pipeline is read like the files of the --transform flag
+		content, err := json.Marshal(request.Transform)
+		if err == nil {
+			options.Transform, err = transform.Parse(content)
+		}
+		if err != nil {
+			return options, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Transform", err.Error())
+		}
+	}
+	return options, nil
+}
+
+// startJob starts a job and replies with 202 and the job location, it returns false if the job could not be started
+func startJob(w http.ResponseWriter, jobType string, target string, total int, work jobs.RunFunc) bool {
+	job, err := jobManager.Start(jobType, target, total, work)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusInternalServerError, "Error Starting Job", err.Error()))
+		return false
+	}
+
+	logger.Info("Started " + jobType + " job " + job.ID + " for " + target)
+	response := entities.JobResponse{}
+	response.FromJob(job)
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+	return true
+}
+
+// writeEntityNotFound replies with a 404 logging the error returned by the service bus if any
+func writeEntityNotFound(w http.ResponseWriter, title string, message string, err error) {
+	w.WriteHeader(http.StatusNotFound)
+	errorResponse := entities.NewApiErrorResponse(http.StatusNotFound, title, message)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+	json.NewEncoder(w).Encode(errorResponse)
+}
+
+// messageCount gets the number of active or dead letter messages, zero if the count is not available
+func messageCount(details *servicebus.CountDetails, deadLetter bool) int {
+	if details == nil {
+		return 0
+	}
+
+	count := details.ActiveMessageCount
+	if deadLetter {
+		count = details.DeadLetterMessageCount
+	}
+	if count == nil {
+		return 0
+	}
+
+	return int(*count)
+}
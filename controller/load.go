@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/jobs"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/cjlapao/servicebuscli-go/templating"
+	"github.com/gorilla/mux"
+)
+
+// JobTypeLoad Job type of the load tests
+const JobTypeLoad = "Load"
+
+// LoadQueue Starts a job sending load to a queue, the job result has the throughput and latency report
+func (c *Controller) LoadQueue(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queueName := vars["queueName"]
+
+	queue, err := sbcli.GetQueueDetails(queueName)
+	if queue == nil {
+		writeEntityNotFound(w, "Queue Not Found", "Queue with name "+queueName+" was not found in "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	startLoadJob(w, r, "queues/"+queueName, queueName, "", func() (servicebus.MessageSender, error) {
+		return sbcli.GetQueueSender(queueName)
+	})
+}
+
+// LoadTopic Starts a job sending load to a topic, the job result has the throughput and latency report
+func (c *Controller) LoadTopic(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	topicName := vars["topicName"]
+
+	topic := sbcli.GetTopicDetails(topicName)
+	if topic == nil {
+		writeEntityNotFound(w, "Topic Not Found", "Topic with name "+topicName+" was not found in "+sbcli.Namespace.Name, nil)
+		return
+	}
+
+	startLoadJob(w, r, "topics/"+topicName, "", topicName, func() (servicebus.MessageSender, error) {
+		return sbcli.GetTopicSender(topicName)
+	})
+}
+
+// startLoadJob reads the load request from the body and starts it as a job, templates rendering messages that do
+// not match the schema of the queue or topic are rejected
+func startLoadJob(w http.ResponseWriter, r *http.Request, target string, queueName string, topicName string, newSender servicebus.SenderFactory) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Empty Body", "The body of the request is null or empty"))
+		return
+	}
+
+	load := entities.LoadRequest{}
+	if err := json.Unmarshal(reqBody, &load); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Failed Body Deserialization", "There was an error deserializing the body of the request"))
+		return
+	}
+
+	if err := load.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Load", err.Error()))
+		return
+	}
+
+	if _, err := templating.New(load.Template, load.Rows); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Template", err.Error()))
+		return
+	}
+
+	if err := sbcli.CheckTemplateSchema(queueName, topicName, load.Template, load.Rows); writeSchemaError(w, err) {
+		return
+	}
+
+	startJob(w, JobTypeLoad, target, load.ExpectedMessages(), func(ctx context.Context, job *jobs.Job) error {
+		report, err := sbcli.RunLoad(ctx, newSender, load, job.Add)
+		if report != nil {
+			job.SetResult(report)
+		}
+		return err
+	})
+}
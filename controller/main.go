@@ -8,9 +8,11 @@ import (
 
 	"net/http"
 
+	"github.com/cjlapao/common-go/helper"
 	cjlog "github.com/cjlapao/common-go/log"
 	"github.com/cjlapao/common-go/version"
 	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/gateway"
 	"github.com/cjlapao/servicebuscli-go/servicebus"
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/parser"
@@ -44,6 +46,14 @@ func handleRequests() {
 	router.Use(commonMiddleware)
 	router.HandleFunc("/", homePage)
 	_ = NewAPIController(router)
+	if gatewayFile := helper.GetFlagValue("gateway", os.Getenv("SERVICEBUS_GATEWAY_CONFIG")); gatewayFile != "" {
+		config, err := gateway.LoadConfig(gatewayFile)
+		if err != nil {
+			logger.Error("Could not load the gateway routes from " + gatewayFile + ", " + err.Error())
+			os.Exit(1)
+		}
+		registerGatewayRoutes(router, config)
+	}
 	logger.Success("API Server ready on port " + port + ".")
 	log.Fatal(http.ListenAndServe(":"+port, router))
 }
@@ -78,10 +88,21 @@ func commonMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// connectionlessRoutes are the routes that work without a connection to the service bus
+var connectionlessRoutes = map[string]bool{
+	"/openapi.json": true,
+	"/metrics":      true,
+	"/healthz":      true,
+	"/readyz":       true,
+}
+
 func ServiceBusConnectionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logger.Info("[%v] %v route requested by %v.", r.Method, r.URL.Path, r.RemoteAddr)
-		if connStr == "" {
+		// the kubernetes probes would flood the log
+		if r.URL.Path != "/healthz" && r.URL.Path != "/readyz" {
+			logger.Info("[%v] %v route requested by %v.", r.Method, r.URL.Path, r.RemoteAddr)
+		}
+		if connStr == "" && !connectionlessRoutes[r.URL.Path] {
 			w.WriteHeader(http.StatusBadRequest)
 			w.Write([]byte("No Azure Service Bus Connection String defined"))
 			return
@@ -97,8 +118,12 @@ func NewAPIController(router *mux.Router) Controller {
 		Router: router,
 	}
 
+	controller.Router.Use(MetricsMiddleware)
 	controller.Router.Use(ServiceBusConnectionMiddleware)
 	controller.Router.Use(commonMiddleware)
+	controller.Router.Use(RequestValidationMiddleware)
+	// Documentation Controllers
+	controller.Router.HandleFunc("/openapi.json", controller.GetOpenAPIDocument).Methods("GET")
 	// Topics Controllers
 	controller.Router.HandleFunc("/config", controller.SetConnectionString).Methods("POST")
 	controller.Router.HandleFunc("/topics", controller.GetTopics).Methods("GET")
@@ -109,6 +134,8 @@ func NewAPIController(router *mux.Router) Controller {
 	controller.Router.HandleFunc("/topics/{topicName}/send", controller.SendTopicMessage).Methods("PUT")
 	controller.Router.HandleFunc("/topics/{topicName}/sendbulk", controller.SendBulkTopicMessage).Methods("PUT")
 	controller.Router.HandleFunc("/topics/{topicName}/sendbulktemplate", controller.SendBulkTemplateTopicMessage).Methods("PUT")
+	controller.Router.HandleFunc("/topics/{topicName}/sendbulktemplate", controller.SendBulkTemplateTopicMessage).Methods("POST")
+	controller.Router.HandleFunc("/topics/{topicName}/load", controller.LoadTopic).Methods("POST")
 	// Subscriptions Controllers
 	controller.Router.HandleFunc("/topics/{topicName}/subscriptions", controller.GetTopicSubscriptions).Methods("GET")
 	controller.Router.HandleFunc("/topics/{topicName}/subscriptions", controller.UpsertTopicSubscription).Methods("POST")
@@ -116,7 +143,12 @@ func NewAPIController(router *mux.Router) Controller {
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}", controller.GetTopicSubscription).Methods("GET")
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}", controller.DeleteTopicSubscription).Methods("DELETE")
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/deadletters", controller.GetSubscriptionDeadLetterMessages).Methods("GET")
+	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/deadletters/resubmit", controller.ResubmitSubscriptionDeadLetters).Methods("POST")
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/messages", controller.GetSubscriptionMessages).Methods("GET")
+	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/messages/{action}", controller.SubscriptionMessagesOperation).Methods("POST")
+	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/messages/{sequenceNumber}/{action}", controller.SubscriptionMessageOperation).Methods("POST")
+	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/purge", controller.PurgeSubscription).Methods("POST")
+	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/stream", controller.StreamSubscriptionMessages).Methods("GET")
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/rules", controller.GetSubscriptionRules).Methods("GET")
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/rules", controller.CreateSubscriptionRule).Methods("POST")
 	controller.Router.HandleFunc("/topics/{topicName}/{subscriptionName}/rules/{ruleName}", controller.GetSubscriptionRule).Methods("GET")
@@ -130,8 +162,30 @@ func NewAPIController(router *mux.Router) Controller {
 	controller.Router.HandleFunc("/queues/{queueName}/send", controller.SendQueueMessage).Methods("PUT")
 	controller.Router.HandleFunc("/queues/{queueName}/sendbulk", controller.SendBulkQueueMessage).Methods("PUT")
 	controller.Router.HandleFunc("/queues/{queueName}/sendbulktemplate", controller.SendBulkTemplateQueueMessage).Methods("PUT")
+	controller.Router.HandleFunc("/queues/{queueName}/sendbulktemplate", controller.SendBulkTemplateQueueMessage).Methods("POST")
+	controller.Router.HandleFunc("/queues/{queueName}/load", controller.LoadQueue).Methods("POST")
 	controller.Router.HandleFunc("/queues/{queueName}/deadletters", controller.GetQueueDeadLetterMessages).Methods("GET")
+	controller.Router.HandleFunc("/queues/{queueName}/deadletters/resubmit", controller.ResubmitQueueDeadLetters).Methods("POST")
 	controller.Router.HandleFunc("/queues/{queueName}/messages", controller.GetQueueMessages).Methods("GET")
+	controller.Router.HandleFunc("/queues/{queueName}/messages/{action}", controller.QueueMessagesOperation).Methods("POST")
+	controller.Router.HandleFunc("/queues/{queueName}/messages/{sequenceNumber}/{action}", controller.QueueMessageOperation).Methods("POST")
+	controller.Router.HandleFunc("/queues/{queueName}/purge", controller.PurgeQueue).Methods("POST")
+	controller.Router.HandleFunc("/queues/{queueName}/stream", controller.StreamQueueMessages).Methods("GET")
+	// Metrics and Health Controllers
+	controller.Router.HandleFunc("/metrics", controller.GetMetrics).Methods("GET")
+	controller.Router.HandleFunc("/healthz", controller.GetHealth).Methods("GET")
+	controller.Router.HandleFunc("/readyz", controller.GetReadiness).Methods("GET")
+	// Probes Controllers
+	controller.Router.HandleFunc("/probes", controller.GetProbes).Methods("GET")
+	controller.Router.HandleFunc("/probes", controller.StartProbe).Methods("POST")
+	// Expectations Controllers
+	controller.Router.HandleFunc("/expectations", controller.RunExpectation).Methods("POST")
+	// Jobs Controllers
+	controller.Router.HandleFunc("/jobs", controller.GetJobs).Methods("GET")
+	controller.Router.HandleFunc("/jobs/{jobId}", controller.GetJob).Methods("GET")
+	controller.Router.HandleFunc("/jobs/{jobId}", controller.CancelJob).Methods("DELETE")
+
+	apiDocument = newAPIDocument(controller.Router)
 
 	return controller
 }
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/gorilla/mux"
+)
+
+// QueueMessageOperation Completes, dead letters, moves, defers or receives a message of a queue by its sequence number
+func (c *Controller) QueueMessageOperation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queueName := vars["queueName"]
+
+	operation, errorResponse := getMessageOperation(r, true)
+	if errorResponse != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	runQueueMessageOperation(w, r, queueName, operation)
+}
+
+// QueueMessagesOperation Completes, dead letters, moves, defers or receives the messages of a queue picked by their
+// sequence numbers or ids in the body
+func (c *Controller) QueueMessagesOperation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queueName := vars["queueName"]
+
+	operation, errorResponse := getMessageOperation(r, false)
+	if errorResponse != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	runQueueMessageOperation(w, r, queueName, operation)
+}
+
+// SubscriptionMessageOperation Completes, dead letters, moves, defers or receives a message of a topic subscription by
+// its sequence number
+func (c *Controller) SubscriptionMessageOperation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	topicName := vars["topicName"]
+	subscriptionName := vars["subscriptionName"]
+
+	operation, errorResponse := getMessageOperation(r, true)
+	if errorResponse != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	runSubscriptionMessageOperation(w, r, topicName, subscriptionName, operation)
+}
+
+// SubscriptionMessagesOperation Completes, dead letters, moves, defers or receives the messages of a topic
+// subscription picked by their sequence numbers or ids in the body
+func (c *Controller) SubscriptionMessagesOperation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	topicName := vars["topicName"]
+	subscriptionName := vars["subscriptionName"]
+
+	operation, errorResponse := getMessageOperation(r, false)
+	if errorResponse != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	runSubscriptionMessageOperation(w, r, topicName, subscriptionName, operation)
+}
+
+func runQueueMessageOperation(w http.ResponseWriter, r *http.Request, queueName string, operation *servicebus.MessageOperation) {
+	queue, err := sbcli.GetQueueDetails(queueName)
+	if queue == nil {
+		writeEntityNotFound(w, "Queue Not Found", "Queue with name "+queueName+" was not found in "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	results, err := sbcli.QueueMessagesOperation(r.Context(), queueName, *operation)
+	writeMessageOperationResults(w, results, err)
+}
+
+func runSubscriptionMessageOperation(w http.ResponseWriter, r *http.Request, topicName string, subscriptionName string, operation *servicebus.MessageOperation) {
+	subscription, err := sbcli.GetSubscription(topicName, subscriptionName)
+	if subscription == nil {
+		writeEntityNotFound(w, "Subscription not found", "The Subscription "+subscriptionName+" was not found on "+topicName+" topic in the service bus "+sbcli.Namespace.Name, err)
+		return
+	}
+
+	results, err := sbcli.SubscriptionMessagesOperation(r.Context(), topicName, subscriptionName, *operation)
+	writeMessageOperationResults(w, results, err)
+}
+
+// getMessageOperation reads the operation of a request, single message routes have the sequence number in the path
+// and the options in the query, the other routes have them in the body
+func getMessageOperation(r *http.Request, single bool) (*servicebus.MessageOperation, *entities.ApiErrorResponse) {
+	vars := mux.Vars(r)
+	queryValues := r.URL.Query()
+	request := entities.MessageOperationRequest{
+		Reason:      queryValues.Get("reason"),
+		Description: queryValues.Get("description"),
+		To:          queryValues.Get("to"),
+		KeepID:      queryValues.Get("keepId") == "true",
+		LockAhead:   queryValues.Get("lockAhead") == "true",
+	}
+
+	if single {
+		sequenceNumber, err := strconv.ParseInt(vars["sequenceNumber"], 10, 64)
+		if err != nil {
+			return nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Sequence Number", "The sequence number "+vars["sequenceNumber"]+" is not a number")
+		}
+		request.SequenceNumbers = []int64{sequenceNumber}
+	} else {
+		reqBody, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(reqBody, &request)
+		}
+		if err != nil {
+			return nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Failed Body Deserialization", "There was an error deserializing the body of the request, "+err.Error())
+		}
+	}
+
+	operation := servicebus.MessageOperation{
+		Action:          vars["action"],
+		SequenceNumbers: request.SequenceNumbers,
+		MessageIDs:      request.MessageIDs,
+		DeadLetter:      queryValues.Get("deadletter") == "true",
+		Reason:          request.Reason,
+		Description:     request.Description,
+		KeepID:          request.KeepID,
+		LockAhead:       request.LockAhead,
+	}
+	if request.To != "" {
+		endpoint, err := servicebus.ParseEndpoint(request.To)
+		if err != nil {
+			return nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Destination", err.Error())
+		}
+		operation.To = endpoint
+	}
+
+	if err := operation.Validate(); err != nil {
+		return nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Message Operation", err.Error())
+	}
+	return &operation, nil
+}
+
+// writeMessageOperationResults replies with the result of every picked message, messages that failed or were not
+// found are reported in their result
+func writeMessageOperationResults(w http.ResponseWriter, results []entities.MessageOperationResult, err error) {
+	if results == nil && err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Message Operation Failed", err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/metrics"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/gorilla/mux"
+)
+
+var metricsRegistry = metrics.NewRegistry()
+var exporterOnce sync.Once
+
+var httpRequests = metricsRegistry.NewCounterVec("servicebus_cli_http_requests_total", "Number of requests handled by the api by route and status code", "method", "route", "status")
+var httpRequestDuration = metricsRegistry.NewHistogramVec("servicebus_cli_http_request_duration_seconds", "Time the api took to handle the requests by route, streams count until they are closed", nil, "method", "route")
+var httpRequestsInFlight = metricsRegistry.NewGaugeVec("servicebus_cli_http_requests_in_flight", "Number of requests the api is handling right now")
+
+func init() {
+	servicebus.Instrument(metricsRegistry)
+}
+
+// GetMetrics Returns the prometheus metrics, the exporter only starts polling the service bus on the first scrape
+// so the api does not call the management endpoints if nobody is collecting the metrics
+func (c *Controller) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	exporterOnce.Do(func() {
+		exporter := servicebus.NewExporter(metricsRegistry, func() *servicebus.ServiceBusCli {
+			return sbcli
+		})
+		if interval, err := time.ParseDuration(os.Getenv("SERVICEBUS_METRICS_INTERVAL")); err == nil && interval > 0 {
+			exporter.Interval = interval
+		}
+
+		logger.Info("Starting the metrics exporter polling every " + exporter.Interval.String())
+		// the first scrape waits for the first poll so it does not return empty gauges
+		exporter.Poll()
+		go func() {
+			time.Sleep(exporter.Interval)
+			exporter.Run(context.Background())
+		}()
+	})
+
+	metricsRegistry.Handler().ServeHTTP(w, r)
+}
+
+// MetricsMiddleware Records the count, duration and status code of the requests by the route template, so requests
+// to different entities are counted in the same route
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+			if path, err := currentRoute.GetPathTemplate(); err == nil {
+				route = path
+			}
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		httpRequestsInFlight.Inc()
+		defer func() {
+			httpRequestsInFlight.Dec()
+			httpRequestDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+			httpRequests.Inc(r.Method, route, strconv.Itoa(recorder.status))
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// statusRecorder keeps the status code written to the response, it implements the flusher and the hijacker
+// of the wrapped writer as the streams need them for server sent events and websockets
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(data []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(data)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		s.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	// the connection is upgraded to a websocket so the status is never written through the writer
+	s.status = http.StatusSwitchingProtocols
+	s.wroteHeader = true
+	return hijacker.Hijack()
+}
@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/openapi"
+	"github.com/gorilla/mux"
+)
+
+var apiDocument *openapi.Document
+
+// apiOperation describes a route registered in the router for the openapi document
+type apiOperation struct {
+	id          string
+	tag         string
+	summary     string
+	query       []openapi.Parameter
+	request     interface{}
+	status      int
+	response    interface{}
+	contentType string
+	// optional accepts requests without a body
+	optional bool
+}
+
+var peekParameter = openapi.Parameter{
+	Name:        "peek",
+	In:          "query",
+	Description: "If true the messages are not removed from the entity",
+	Schema:      &openapi.Schema{Type: "boolean"},
+}
+
+var qtyParameter = openapi.Parameter{
+	Name:        "qty",
+	In:          "query",
+	Description: "Number of messages to receive, defaults to all the messages and peeks have a maximum of 100",
+	Schema:      &openapi.Schema{Type: "integer"},
+}
+
+var filterParameter = openapi.Parameter{
+	Name:        "filter",
+	In:          "query",
+	Description: "Filter expression, only messages matching it are pushed to the client, it needs peek to be true",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+var whereParameter = openapi.Parameter{
+	Name:        "where",
+	In:          "query",
+	Description: "Filter expression, if set the whole backlog is peeked and only the matching messages are returned with qty limiting the number of matches",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+var prefetchParameter = openapi.Parameter{
+	Name:        "prefetch",
+	In:          "query",
+	Description: "Number of messages the receiver asks for at once, defaults to qty so the whole batch is received together and can not be bigger than it",
+	Schema:      &openapi.Schema{Type: "integer"},
+}
+
+var concurrencyParameter = openapi.Parameter{
+	Name:        "concurrency",
+	In:          "query",
+	Description: "Number of received messages completed at the same time, defaults to 16",
+	Schema:      &openapi.Schema{Type: "integer"},
+}
+
+var receiveModeParameter = openapi.Parameter{
+	Name:        "receiveMode",
+	In:          "query",
+	Description: "peeklock or receiveanddelete, messages received past qty are abandoned in peeklock and returned too in receiveanddelete so they are not lost",
+	Schema:      &openapi.Schema{Type: "string", Enum: []interface{}{"peeklock", "receiveanddelete"}},
+}
+
+var deadLetterParameter = openapi.Parameter{
+	Name:        "deadletter",
+	In:          "query",
+	Description: "If true the dead letter queue is purged instead of the active messages",
+	Schema:      &openapi.Schema{Type: "boolean"},
+}
+
+var messageDeadLetterParameter = openapi.Parameter{
+	Name:        "deadletter",
+	In:          "query",
+	Description: "If true the messages are picked from the dead letter queue instead of the active messages",
+	Schema:      &openapi.Schema{Type: "boolean"},
+}
+
+var reasonParameter = openapi.Parameter{
+	Name:        "reason",
+	In:          "query",
+	Description: "Dead letter reason set on the message by the deadletter action",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+var descriptionParameter = openapi.Parameter{
+	Name:        "description",
+	In:          "query",
+	Description: "Dead letter error description set on the message by the deadletter action",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+var toParameter = openapi.Parameter{
+	Name:        "to",
+	In:          "query",
+	Description: "Queue or topic the move action sends the message to, as queue:name or topic:name",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+var keepIDParameter = openapi.Parameter{
+	Name:        "keepId",
+	In:          "query",
+	Description: "Sends the moved message with its id instead of a new one",
+	Schema:      &openapi.Schema{Type: "boolean"},
+}
+
+var lockAheadParameter = openapi.Parameter{
+	Name:        "lockAhead",
+	In:          "query",
+	Description: "Locks and abandons the active messages in front of the message to reach it, which increases their delivery count, without it a message with others in front fails",
+	Schema:      &openapi.Schema{Type: "boolean"},
+}
+
+var fanOutParameter = openapi.Parameter{
+	Name:        "fanout",
+	In:          "query",
+	Description: "Needs to be true to resubmit, the messages are sent to the topic and every subscription with matching rules receives them",
+	Schema:      &openapi.Schema{Type: "boolean"},
+}
+
+var resubmitQtyParameter = openapi.Parameter{
+	Name:        "qty",
+	In:          "query",
+	Description: "Maximum number of messages to resubmit, all of them if not set",
+	Schema:      &openapi.Schema{Type: "integer"},
+}
+
+// apiOperations documents every route, keyed by the method and the route path template
+var apiOperations = map[string]apiOperation{
+	"GET /":             {id: "getHome", tag: "Documentation", summary: "Returns the README documentation as html", status: http.StatusOK, contentType: "text/html"},
+	"GET /openapi.json": {id: "getOpenAPIDocument", tag: "Documentation", summary: "Returns this OpenAPI document", status: http.StatusOK, response: map[string]interface{}{}},
+	"POST /config":      {id: "setConnectionString", tag: "Config", summary: "Sets the service bus connection string used by the api", request: entities.ConfigRequest{}, status: http.StatusAccepted},
+	// Topics
+	"GET /topics":                               {id: "getTopics", tag: "Topics", summary: "Returns all the topics in the namespace", status: http.StatusOK, response: []entities.TopicResponseEntity{}},
+	"POST /topics":                              {id: "createTopic", tag: "Topics", summary: "Creates a topic in the namespace", request: entities.TopicRequestEntity{}, status: http.StatusCreated, response: entities.TopicResponseEntity{}},
+	"PUT /topics":                               {id: "putTopic", tag: "Topics", summary: "Creates a topic in the namespace", request: entities.TopicRequestEntity{}, status: http.StatusCreated, response: entities.TopicResponseEntity{}},
+	"GET /topics/{topicName}":                   {id: "getTopic", tag: "Topics", summary: "Returns a topic", status: http.StatusOK, response: entities.TopicResponseEntity{}},
+	"DELETE /topics/{topicName}":                {id: "deleteTopic", tag: "Topics", summary: "Deletes a topic", status: http.StatusNoContent},
+	"PUT /topics/{topicName}/send":              {id: "sendTopicMessage", tag: "Topics", summary: "Sends a message to a topic", request: entities.MessageRequest{}, status: http.StatusAccepted, response: entities.ApiSuccessResponse{}},
+	"PUT /topics/{topicName}/sendbulk":          {id: "sendBulkTopicMessage", tag: "Topics", summary: "Sends a list of messages to a topic", request: entities.BulkMessageRequest{}, status: http.StatusAccepted, response: entities.ApiSuccessResponse{}},
+	"PUT /topics/{topicName}/sendbulktemplate":  {id: "sendBulkTemplateTopicMessage", tag: "Topics", summary: "Sends copies of a message template to a topic in batches", request: entities.BulkTemplateMessageRequest{}, status: http.StatusAccepted, response: entities.ApiSuccessResponse{}},
+	"POST /topics/{topicName}/sendbulktemplate": {id: "startSendBulkTemplateTopicMessageJob", tag: "Topics", summary: "Starts a job sending copies of a message template to a topic in batches", request: entities.BulkTemplateMessageRequest{}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"POST /topics/{topicName}/load":             {id: "startLoadTopicJob", tag: "Topics", summary: "Starts a job sending load to a topic, the job result has the throughput and latency report", request: entities.LoadRequest{}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	// Subscriptions
+	"GET /topics/{topicName}/subscriptions":                                          {id: "getTopicSubscriptions", tag: "Subscriptions", summary: "Returns all the subscriptions of a topic", status: http.StatusOK, response: []entities.SubscriptionResponse{}},
+	"POST /topics/{topicName}/subscriptions":                                         {id: "createTopicSubscription", tag: "Subscriptions", summary: "Creates a subscription in a topic", request: entities.SubscriptionRequest{}, status: http.StatusCreated, response: entities.SubscriptionResponse{}},
+	"PUT /topics/{topicName}/subscriptions":                                          {id: "upsertTopicSubscription", tag: "Subscriptions", summary: "Creates or updates a subscription in a topic", request: entities.SubscriptionRequest{}, status: http.StatusCreated, response: entities.SubscriptionResponse{}},
+	"GET /topics/{topicName}/{subscriptionName}":                                     {id: "getTopicSubscription", tag: "Subscriptions", summary: "Returns a topic subscription", status: http.StatusOK, response: entities.SubscriptionResponse{}},
+	"DELETE /topics/{topicName}/{subscriptionName}":                                  {id: "deleteTopicSubscription", tag: "Subscriptions", summary: "Deletes a topic subscription", status: http.StatusAccepted},
+	"GET /topics/{topicName}/{subscriptionName}/deadletters":                         {id: "getSubscriptionDeadLetterMessages", tag: "Subscriptions", summary: "Returns the dead letter messages of a topic subscription", query: []openapi.Parameter{qtyParameter, peekParameter, whereParameter, prefetchParameter, concurrencyParameter, receiveModeParameter}, status: http.StatusOK, response: []entities.MessageResponse{}},
+	"POST /topics/{topicName}/{subscriptionName}/deadletters/resubmit":               {id: "startResubmitSubscriptionDeadLettersJob", tag: "Subscriptions", summary: "Starts a job sending the dead letter messages of a topic subscription back to the topic", query: []openapi.Parameter{resubmitQtyParameter, fanOutParameter}, request: entities.ResubmitRequest{}, optional: true, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"GET /topics/{topicName}/{subscriptionName}/messages":                            {id: "getSubscriptionMessages", tag: "Subscriptions", summary: "Returns the active messages of a topic subscription", query: []openapi.Parameter{qtyParameter, peekParameter, whereParameter, prefetchParameter, concurrencyParameter, receiveModeParameter}, status: http.StatusOK, response: []entities.MessageResponse{}},
+	"POST /topics/{topicName}/{subscriptionName}/messages/{action}":                  {id: "subscriptionMessagesOperation", tag: "Subscriptions", summary: "Completes, dead letters, moves, defers or receives the messages of a topic subscription picked by their sequence numbers or ids", query: []openapi.Parameter{messageDeadLetterParameter}, request: entities.MessageOperationRequest{}, status: http.StatusOK, response: []entities.MessageOperationResult{}},
+	"POST /topics/{topicName}/{subscriptionName}/messages/{sequenceNumber}/{action}": {id: "subscriptionMessageOperation", tag: "Subscriptions", summary: "Completes, dead letters, moves, defers or receives a message of a topic subscription by its sequence number", query: []openapi.Parameter{messageDeadLetterParameter, reasonParameter, descriptionParameter, toParameter, keepIDParameter, lockAheadParameter}, status: http.StatusOK, response: []entities.MessageOperationResult{}},
+	"POST /topics/{topicName}/{subscriptionName}/purge":                              {id: "startPurgeSubscriptionJob", tag: "Subscriptions", summary: "Starts a job removing all the messages of a topic subscription", query: []openapi.Parameter{deadLetterParameter}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"GET /topics/{topicName}/{subscriptionName}/stream":                              {id: "streamSubscriptionMessages", tag: "Subscriptions", summary: "Streams the messages of a topic subscription as server sent events or websocket frames", query: []openapi.Parameter{peekParameter, filterParameter}, status: http.StatusOK, response: entities.MessageResponse{}, contentType: "text/event-stream"},
+	"GET /topics/{topicName}/{subscriptionName}/rules":                               {id: "getSubscriptionRules", tag: "Subscriptions", summary: "Returns the rules of a topic subscription", status: http.StatusOK, response: []entities.RuleResponse{}},
+	"POST /topics/{topicName}/{subscriptionName}/rules":                              {id: "createSubscriptionRule", tag: "Subscriptions", summary: "Creates a rule in a topic subscription", request: entities.RuleRequest{}, status: http.StatusOK, response: entities.RuleResponse{}},
+	"GET /topics/{topicName}/{subscriptionName}/rules/{ruleName}":                    {id: "getSubscriptionRule", tag: "Subscriptions", summary: "Returns a topic subscription rule", status: http.StatusOK, response: entities.RuleResponse{}},
+	"DELETE /topics/{topicName}/{subscriptionName}/rules/{ruleName}":                 {id: "deleteSubscriptionRule", tag: "Subscriptions", summary: "Deletes a topic subscription rule", status: http.StatusAccepted},
+	// Queues
+	"GET /queues":                                                 {id: "getQueues", tag: "Queues", summary: "Returns all the queues in the namespace", status: http.StatusOK, response: []entities.QueueResponse{}},
+	"POST /queues":                                                {id: "createQueue", tag: "Queues", summary: "Creates a queue in the namespace", request: entities.QueueRequest{}, status: http.StatusCreated, response: entities.QueueResponse{}},
+	"PUT /queues":                                                 {id: "upsertQueue", tag: "Queues", summary: "Creates or updates a queue in the namespace", request: entities.QueueRequest{}, status: http.StatusCreated, response: entities.QueueResponse{}},
+	"GET /queues/{queueName}":                                     {id: "getQueue", tag: "Queues", summary: "Returns a queue", status: http.StatusOK, response: entities.QueueResponse{}},
+	"DELETE /queues/{queueName}":                                  {id: "deleteQueue", tag: "Queues", summary: "Deletes a queue", status: http.StatusAccepted},
+	"PUT /queues/{queueName}/send":                                {id: "sendQueueMessage", tag: "Queues", summary: "Sends a message to a queue", request: entities.MessageRequest{}, status: http.StatusAccepted, response: entities.ApiSuccessResponse{}},
+	"PUT /queues/{queueName}/sendbulk":                            {id: "sendBulkQueueMessage", tag: "Queues", summary: "Sends a list of messages to a queue", request: entities.BulkMessageRequest{}, status: http.StatusAccepted, response: entities.ApiSuccessResponse{}},
+	"PUT /queues/{queueName}/sendbulktemplate":                    {id: "sendBulkTemplateQueueMessage", tag: "Queues", summary: "Sends copies of a message template to a queue in batches", request: entities.BulkTemplateMessageRequest{}, status: http.StatusAccepted, response: entities.ApiSuccessResponse{}},
+	"POST /queues/{queueName}/sendbulktemplate":                   {id: "startSendBulkTemplateQueueMessageJob", tag: "Queues", summary: "Starts a job sending copies of a message template to a queue in batches", request: entities.BulkTemplateMessageRequest{}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"POST /queues/{queueName}/load":                               {id: "startLoadQueueJob", tag: "Queues", summary: "Starts a job sending load to a queue, the job result has the throughput and latency report", request: entities.LoadRequest{}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"GET /queues/{queueName}/deadletters":                         {id: "getQueueDeadLetterMessages", tag: "Queues", summary: "Returns the dead letter messages of a queue", query: []openapi.Parameter{qtyParameter, peekParameter, whereParameter, prefetchParameter, concurrencyParameter, receiveModeParameter}, status: http.StatusOK, response: []entities.MessageResponse{}},
+	"POST /queues/{queueName}/deadletters/resubmit":               {id: "startResubmitQueueDeadLettersJob", tag: "Queues", summary: "Starts a job sending the dead letter messages of a queue back to it", query: []openapi.Parameter{resubmitQtyParameter}, request: entities.ResubmitRequest{}, optional: true, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"GET /queues/{queueName}/messages":                            {id: "getQueueMessages", tag: "Queues", summary: "Returns the active messages of a queue", query: []openapi.Parameter{qtyParameter, peekParameter, whereParameter, prefetchParameter, concurrencyParameter, receiveModeParameter}, status: http.StatusOK, response: []entities.MessageResponse{}},
+	"POST /queues/{queueName}/messages/{action}":                  {id: "queueMessagesOperation", tag: "Queues", summary: "Completes, dead letters, moves, defers or receives the messages of a queue picked by their sequence numbers or ids", query: []openapi.Parameter{messageDeadLetterParameter}, request: entities.MessageOperationRequest{}, status: http.StatusOK, response: []entities.MessageOperationResult{}},
+	"POST /queues/{queueName}/messages/{sequenceNumber}/{action}": {id: "queueMessageOperation", tag: "Queues", summary: "Completes, dead letters, moves, defers or receives a message of a queue by its sequence number", query: []openapi.Parameter{messageDeadLetterParameter, reasonParameter, descriptionParameter, toParameter, keepIDParameter, lockAheadParameter}, status: http.StatusOK, response: []entities.MessageOperationResult{}},
+	"POST /queues/{queueName}/purge":                              {id: "startPurgeQueueJob", tag: "Queues", summary: "Starts a job removing all the messages of a queue", query: []openapi.Parameter{deadLetterParameter}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	"GET /queues/{queueName}/stream":                              {id: "streamQueueMessages", tag: "Queues", summary: "Streams the messages of a queue as server sent events or websocket frames", query: []openapi.Parameter{peekParameter, filterParameter}, status: http.StatusOK, response: entities.MessageResponse{}, contentType: "text/event-stream"},
+	// Metrics and Health
+	"GET /metrics": {id: "getMetrics", tag: "Metrics", summary: "Returns the message counts of every entity and the api metrics in the prometheus text format", status: http.StatusOK, contentType: "text/plain"},
+	"GET /healthz": {id: "getHealth", tag: "Metrics", summary: "Returns ok while the api is running, used as the liveness probe", status: http.StatusOK, response: entities.ApiSuccessResponse{}},
+	"GET /readyz":  {id: "getReadiness", tag: "Metrics", summary: "Returns ok if the service bus namespace can be reached, used as the readiness probe", status: http.StatusOK, response: entities.ApiSuccessResponse{}},
+	// Probes
+	"GET /probes":  {id: "getProbes", tag: "Probes", summary: "Returns the probes started by the api with their latest report", status: http.StatusOK, response: []entities.JobResponse{}},
+	"POST /probes": {id: "startProbe", tag: "Probes", summary: "Starts a job sending probe messages and measuring their end to end delivery latency", request: entities.ProbeRequest{}, status: http.StatusAccepted, response: entities.JobResponse{}},
+	// Expectations
+	"POST /expectations": {id: "runExpectation", tag: "Expectations", summary: "Waits for a message matching a filter expression in a queue or subscription and returns it", request: entities.ExpectationRequest{}, status: http.StatusOK, response: entities.ExpectationResponse{}},
+	// Jobs
+	"GET /jobs":            {id: "getJobs", tag: "Jobs", summary: "Returns all the jobs started by the api", status: http.StatusOK, response: []entities.JobResponse{}},
+	"GET /jobs/{jobId}":    {id: "getJob", tag: "Jobs", summary: "Returns the progress of a job", status: http.StatusOK, response: entities.JobResponse{}},
+	"DELETE /jobs/{jobId}": {id: "cancelJob", tag: "Jobs", summary: "Cancels a running job", status: http.StatusAccepted, response: entities.JobResponse{}},
+}
+
+// newAPIDocument Generates the openapi document from the routes registered in the router,
+// routes missing from apiOperations are still added so the document always matches the router
+func newAPIDocument(router *mux.Router) *openapi.Document {
+	document := openapi.NewDocument("Service Bus Client API", "REST api to manage and test Azure Service Bus topics, subscriptions and queues", ver.String())
+	document.SetSchema(entities.ForwardDestination(0), &openapi.Schema{
+		Type: "string",
+		Enum: []interface{}{"Topic", "Queue", "topic", "queue"},
+	})
+	errorSchema := document.SchemaOf(entities.ApiErrorResponse{})
+
+	_ = router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+
+		for _, method := range methods {
+			definition, documented := apiOperations[method+" "+path]
+			if !documented {
+				logger.Warn("Route [" + method + "] " + path + " is not documented in the openapi document")
+				definition = apiOperation{
+					id:     strings.ToLower(method) + strings.NewReplacer("/", "_", "{", "", "}", "").Replace(path),
+					status: http.StatusOK,
+				}
+			}
+
+			operation := openapi.Operation{
+				OperationID: definition.id,
+				Summary:     definition.summary,
+				Parameters:  definition.query,
+				Responses:   make(map[string]*openapi.Response),
+			}
+			if definition.tag != "" {
+				operation.Tags = []string{definition.tag}
+				document.AddTag(definition.tag, "")
+			}
+
+			if definition.request != nil {
+				operation.RequestBody = &openapi.RequestBody{
+					Required: !definition.optional,
+					Content: map[string]openapi.MediaType{
+						"application/json": {Schema: document.SchemaOf(definition.request)},
+					},
+				}
+			}
+
+			response := openapi.Response{
+				Description: http.StatusText(definition.status),
+			}
+			contentType := definition.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			if definition.response != nil || contentType != "application/json" {
+				response.Content = map[string]openapi.MediaType{
+					contentType: {Schema: document.SchemaOf(definition.response)},
+				}
+			}
+			operation.Responses[strconv.Itoa(definition.status)] = &response
+
+			if path != "/" && path != "/openapi.json" {
+				operation.Responses[strconv.Itoa(http.StatusBadRequest)] = newErrorResponse(http.StatusBadRequest, errorSchema)
+				if len(openapi.PathParameters(path)) > 0 {
+					operation.Responses[strconv.Itoa(http.StatusNotFound)] = newErrorResponse(http.StatusNotFound, errorSchema)
+				}
+			}
+
+			document.AddOperation(method, path, &operation)
+		}
+
+		return nil
+	})
+
+	// entities that are not used by any route yet are still part of the api contract
+	document.SchemaOf(entities.BulkMessageResponse{})
+	document.SchemaOf(entities.LoadReport{})
+	document.SchemaOf(entities.ProbeReport{})
+
+	return document
+}
+
+func newErrorResponse(status int, schema *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: http.StatusText(status),
+		Content: map[string]openapi.MediaType{
+			"application/json": {Schema: schema},
+		},
+	}
+}
+
+// GetOpenAPIDocument Returns the openapi document describing the api
+func (c *Controller) GetOpenAPIDocument(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(apiDocument)
+}
+
+// RequestValidationMiddleware Validates the body of the requests against the schema of the route in the openapi document
+func RequestValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil || apiDocument == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		operation := apiDocument.Operation(r.Method, path)
+		schema := operation.RequestSchema()
+		if schema == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reqBody, err := ioutil.ReadAll(r.Body)
+		if err == nil && len(bytes.TrimSpace(reqBody)) == 0 && !operation.RequestBody.Required {
+			r.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if err != nil || len(bytes.TrimSpace(reqBody)) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Empty Body", "The body of the request is null or empty"))
+			return
+		}
+
+		var body interface{}
+		if err := json.Unmarshal(reqBody, &body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Failed Body Deserialization", "There was an error deserializing the body of the request, "+err.Error()))
+			return
+		}
+
+		validationErrors := apiDocument.Validate(schema, body)
+		if len(validationErrors) > 0 {
+			errorResponse := entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Body", "The body of the request is not valid, check the details for the fields with errors")
+			errorResponse.Details = validationDetails(validationErrors)
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errorResponse)
+			return
+		}
+
+		// the handlers read the body again so we need to give them a fresh reader
+		r.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/jobs"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+// JobTypeProbe Job type of the end to end latency probes
+const JobTypeProbe = "Probe"
+
+// GetProbes Gets the probes started by the api with their latest report
+func (c *Controller) GetProbes(w http.ResponseWriter, r *http.Request) {
+	response := make([]entities.JobResponse, 0)
+	for _, job := range jobManager.List() {
+		if job.Type != JobTypeProbe {
+			continue
+		}
+		jobResponse := entities.JobResponse{}
+		jobResponse.FromJob(job)
+		response = append(response, jobResponse)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartProbe Starts a job sending probe messages and receiving them back, the job result is updated with
+// the latency report every time a message is sent and the probe runs until it is cancelled if it has no total
+func (c *Controller) StartProbe(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Empty Body", "The body of the request is null or empty"))
+		return
+	}
+
+	probe := entities.ProbeRequest{}
+	if err := json.Unmarshal(reqBody, &probe); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Failed Body Deserialization", "There was an error deserializing the body of the request"))
+		return
+	}
+
+	if err := probe.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Probe", err.Error()))
+		return
+	}
+	if servicebus.ProbeSourceInUse(probe) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusConflict, "Probe Already Running", "A probe is already receiving from "+probe.Source()+", probes on the same entity receive each other's messages"))
+		return
+	}
+
+	startJob(w, JobTypeProbe, probe.Target()+" -> "+probe.Source(), probe.TotalMessages, func(ctx context.Context, job *jobs.Job) error {
+		processed := 0
+		report, err := sbcli.RunProbe(ctx, probe, func(report entities.ProbeReport) {
+			job.SetResult(report)
+			// late messages are counted as received and lost before, so we only report what is new
+			if report.Received+report.Lost > processed {
+				job.Add(report.Received+report.Lost-processed, 0)
+				processed = report.Received + report.Lost
+			}
+		})
+		if report != nil {
+			job.SetResult(*report)
+		}
+		return err
+	})
+}
@@ -1,16 +1,18 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
 
+	servicebus "github.com/Azure/azure-service-bus-go"
 	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/jobs"
+	"github.com/cjlapao/servicebuscli-go/templating"
 	"github.com/gorilla/mux"
 )
 
@@ -270,6 +272,9 @@ func (c *Controller) SendQueueMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = sbcli.SendQueueServiceBusMessage(queueName, sbMessage)
+	if writeSchemaError(w, err) {
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		errorResponse.Code = http.StatusBadRequest
@@ -328,6 +333,9 @@ func (c *Controller) SendBulkQueueMessage(w http.ResponseWriter, r *http.Request
 	}
 
 	err = sbcli.SendBulkQueueMessage(queueName, bulk.Messages...)
+	if writeSchemaError(w, err) {
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		errorResponse.Code = http.StatusBadRequest
@@ -351,7 +359,6 @@ func (c *Controller) SendBulkTemplateQueueMessage(w http.ResponseWriter, r *http
 	queueName := vars["queueName"]
 	reqBody, err := ioutil.ReadAll(r.Body)
 	errorResponse := entities.ApiErrorResponse{}
-	maxSizeOfPayload := 262144
 
 	if queueName == "" {
 		w.WriteHeader(http.StatusBadRequest)
@@ -385,92 +392,77 @@ func (c *Controller) SendBulkTemplateQueueMessage(w http.ResponseWriter, r *http
 		return
 	}
 
-	if bulk.BatchOf > bulk.TotalMessages {
+	if bulk.TotalMessages < 1 {
+		bulk.TotalMessages = 1
+	}
+	if bulk.BatchOf < 1 || bulk.BatchOf > bulk.TotalMessages {
 		bulk.BatchOf = 1
 	}
 
-	totalMessageSent := 0
-
-	messageTemplate, err := json.Marshal(bulk.Template)
-
-	if err != nil {
+	if _, err := templating.New(bulk.Template, bulk.Rows); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		errorResponse.Code = http.StatusBadRequest
-		errorResponse.Error = err.Error()
-		errorResponse.Message = "There was an error checking the templated message payload size"
+		errorResponse.Error = "Invalid Template"
+		errorResponse.Message = err.Error()
 		json.NewEncoder(w).Encode(errorResponse)
 		return
 	}
 
-	messageTemplateSize := len(messageTemplate)
-	totalMessagePayloadSize := messageTemplateSize * bulk.TotalMessages
-	minimumBatchSize := totalMessagePayloadSize / maxSizeOfPayload
-	if minimumBatchSize > bulk.BatchOf {
-		bulk.BatchOf = minimumBatchSize + 5
-		logger.Info("The total payload was too big for the given batch size, increasing it to the minimum of " + fmt.Sprint(bulk.BatchOf))
+	if err := sbcli.CheckTemplateSchema(queueName, "", bulk.Template, bulk.Rows); writeSchemaError(w, err) {
+		return
 	}
 
-	batchSize := bulk.TotalMessages / bulk.BatchOf
-	messages := make([]entities.MessageRequest, 0)
-	for i := 0; i < batchSize; i++ {
-		messages = append(messages, bulk.Template)
+	sender, err := sbcli.GetQueueSender(queueName)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		errorResponse.Code = http.StatusNotFound
+		errorResponse.Error = "Queue Not Found"
+		errorResponse.Message = err.Error()
+		json.NewEncoder(w).Encode(errorResponse)
+		return
 	}
 
-	if bulk.WaitBetweenBatchesInMilli > 0 {
-		for i := 0; i < bulk.BatchOf; i++ {
-			if i > 0 && bulk.WaitBetweenBatchesInMilli > 0 {
-				logger.Info("Waiting " + fmt.Sprint(bulk.WaitBetweenBatchesInMilli) + "ms for next batch")
-				time.Sleep(time.Duration(bulk.WaitBetweenBatchesInMilli) * time.Millisecond)
-			}
-
-			err = sbcli.SendBulkQueueMessage(queueName, messages...)
-
-			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				errorResponse.Code = http.StatusBadRequest
-				errorResponse.Error = err.Error()
-				errorResponse.Message = "There was an error sending bulk messages to queue " + queueName
-				json.NewEncoder(w).Encode(errorResponse)
-				return
-			}
-			totalMessageSent += len(messages)
-		}
-	} else {
-		logger.Info("Sending all batches without waiting")
-		var waitFor sync.WaitGroup
-		waitFor.Add(bulk.BatchOf)
-		for i := 0; i < bulk.BatchOf; i++ {
-			go sbcli.SendParallelBulkQueueMessage(&waitFor, queueName, messages...)
-			totalMessageSent += len(messages)
-		}
-
-		waitFor.Wait()
-		logger.Success("Finished sending all the batches to service bus")
+	work := func(ctx context.Context, job *jobs.Job) error {
+		defer sender.Close(context.Background())
+		return sbcli.SendBulkTemplateMessages(ctx, sender, bulk, job.Add)
 	}
 
-	if totalMessageSent < bulk.TotalMessages {
-		missingMessageCount := bulk.TotalMessages - totalMessageSent
-		logger.Info("Sending remaining " + fmt.Sprint(missingMessageCount) + " messages in the payload")
-		messages := make([]entities.MessageRequest, 0)
-		for x := 0; x < missingMessageCount; x++ {
-			messages = append(messages, bulk.Template)
+	// POST runs the send in the background and replies straight away with the job to follow its progress
+	if r.Method == "POST" {
+		// the job closes the sender, it needs to be closed here if the job did not start
+		if !startJob(w, JobTypeSendBulkTemplate, "queues/"+queueName, bulk.TotalMessages, work) {
+			sender.Close(context.Background())
 		}
+		return
+	}
 
-		err = sbcli.SendBulkQueueMessage(queueName, messages...)
+	job, err := jobManager.Start(JobTypeSendBulkTemplate, "queues/"+queueName, bulk.TotalMessages, work)
+	if err != nil {
+		sender.Close(context.Background())
+		w.WriteHeader(http.StatusInternalServerError)
+		errorResponse.Code = http.StatusInternalServerError
+		errorResponse.Error = "Error Starting Job"
+		errorResponse.Message = err.Error()
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+	<-job.Done()
 
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			errorResponse.Code = http.StatusBadRequest
-			errorResponse.Error = err.Error()
-			errorResponse.Message = "There was an error sending bulk messages to queue " + queueName
-			json.NewEncoder(w).Encode(errorResponse)
-			return
-		}
-		bulk.BatchOf += 1
+	progress := job.Progress()
+	if writeSchemaError(w, progress.Error) {
+		return
+	}
+	if progress.Error != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		errorResponse.Code = http.StatusBadRequest
+		errorResponse.Error = progress.Error.Error()
+		errorResponse.Message = "There was an error sending bulk messages to queue " + queueName
+		json.NewEncoder(w).Encode(errorResponse)
+		return
 	}
 
 	response := entities.ApiSuccessResponse{
-		Message: "Sent " + fmt.Sprint(bulk.TotalMessages) + " Messages in " + fmt.Sprint(bulk.BatchOf) + " batches successfully to " + queueName + " queue",
+		Message: "Sent " + fmt.Sprint(progress.Succeeded) + " Messages in " + fmt.Sprint(bulk.BatchOf) + " batches successfully to " + queueName + " queue",
 	}
 
 	w.WriteHeader(http.StatusAccepted)
@@ -510,7 +502,27 @@ func (c *Controller) GetQueueMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := sbcli.GetQueueActiveMessages(queueName, qty, peek)
+	search, searchErr := getSearchOptions(r, qty, false)
+	if searchErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(searchErr)
+		return
+	}
+
+	receive, receiveErr := getReceiveOptions(r)
+	if receiveErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(receiveErr)
+		return
+	}
+
+	var result []servicebus.Message
+	var err error
+	if search != nil {
+		result, err = sbcli.SearchQueueMessages(r.Context(), queueName, *search)
+	} else {
+		result, err = sbcli.GetQueueActiveMessages(queueName, qty, peek, receive)
+	}
 
 	// Body deserialization error
 	if err != nil {
@@ -530,6 +542,7 @@ func (c *Controller) GetQueueMessages(w http.ResponseWriter, r *http.Request) {
 		for _, msg := range result {
 			entityMsg := entities.MessageResponse{}
 			entityMsg.FromServiceBus(&msg)
+			entityMsg.SchemaErrors = schemaDetails(sbcli.CheckQueueMessageSchema(queueName, &msg))
 			response = append(response, entityMsg)
 		}
 		w.WriteHeader(http.StatusOK)
@@ -571,7 +584,27 @@ func (c *Controller) GetQueueDeadLetterMessages(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	result, err := sbcli.GetQueueDeadLetterMessages(queueName, qty, peek)
+	search, searchErr := getSearchOptions(r, qty, true)
+	if searchErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(searchErr)
+		return
+	}
+
+	receive, receiveErr := getReceiveOptions(r)
+	if receiveErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(receiveErr)
+		return
+	}
+
+	var result []servicebus.Message
+	var err error
+	if search != nil {
+		result, err = sbcli.SearchQueueMessages(r.Context(), queueName, *search)
+	} else {
+		result, err = sbcli.GetQueueDeadLetterMessages(queueName, qty, peek, receive)
+	}
 
 	// Body deserialization error
 	if err != nil {
@@ -591,6 +624,7 @@ func (c *Controller) GetQueueDeadLetterMessages(w http.ResponseWriter, r *http.R
 		for _, msg := range result {
 			entityMsg := entities.MessageResponse{}
 			entityMsg.FromServiceBus(&msg)
+			entityMsg.SchemaErrors = schemaDetails(sbcli.CheckQueueMessageSchema(queueName, &msg))
 			response = append(response, entityMsg)
 		}
 		w.WriteHeader(http.StatusOK)
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+// getReceiveOptions Reads the prefetch, concurrency and receiveMode query attributes of a request receiving messages,
+// missing attributes keep the defaults of the receivers
+func getReceiveOptions(r *http.Request) (servicebus.ReceiveOptions, *entities.ApiErrorResponse) {
+	options := servicebus.ReceiveOptions{}
+	queryValues := r.URL.Query()
+
+	var err error
+	if value := queryValues.Get("prefetch"); value != "" {
+		if options.PrefetchCount, err = strconv.Atoi(value); err != nil {
+			return options, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Prefetch", "The prefetch needs to be a number")
+		}
+	}
+	if value := queryValues.Get("concurrency"); value != "" {
+		if options.MaxConcurrency, err = strconv.Atoi(value); err != nil {
+			return options, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Concurrency", "The concurrency needs to be a number")
+		}
+	}
+	if options.ReceiveAndDelete, err = servicebus.ParseReceiveMode(queryValues.Get("receiveMode")); err != nil {
+		return options, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Receive Mode", err.Error())
+	}
+	if err = options.Validate(); err != nil {
+		return options, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Receive Options", err.Error())
+	}
+
+	return options, nil
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/schema"
+)
+
+// writeSchemaError Replies with the validation errors of a message that does not match its schema, it returns
+// false if the error is not a schema error
+func writeSchemaError(w http.ResponseWriter, err error) bool {
+	var schemaError *schema.Error
+	if !errors.As(err, &schemaError) {
+		return false
+	}
+
+	response := entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Message", "The message does not match the schema "+schemaError.File+" of "+schemaError.Target)
+	response.Details = schemaDetails(err)
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+	return true
+}
+
+// schemaDetails Gets the validation errors of a schema error as error details, it returns nil for other errors
+func schemaDetails(err error) []entities.ApiErrorDetail {
+	var schemaError *schema.Error
+	if !errors.As(err, &schemaError) {
+		return nil
+	}
+
+	return validationDetails(schemaError.Errors)
+}
+
+// validationDetails Gets the validation errors of a request or message body as error details
+func validationDetails(validationErrors []schema.ValidationError) []entities.ApiErrorDetail {
+	details := make([]entities.ApiErrorDetail, 0)
+	for _, validationError := range validationErrors {
+		details = append(details, entities.ApiErrorDetail{
+			Field:   validationError.Field,
+			Message: validationError.Message,
+		})
+	}
+	return details
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/filter"
+	"github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+// getSearchOptions Reads the where query attribute of a messages request, requests with an expression peek through
+// the whole backlog for the matching messages instead of receiving the first ones, it returns nil if there is none
+//
+// The qty attribute limits the number of matches and the search never removes the messages
+func getSearchOptions(r *http.Request, qty int, deadLetter bool) (*servicebus.SearchOptions, *entities.ApiErrorResponse) {
+	where, err := filter.Parse(r.URL.Query().Get("where"))
+	if err != nil {
+		return nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Where Expression", err.Error())
+	}
+	if where.IsEmpty() {
+		return nil, nil
+	}
+
+	return &servicebus.SearchOptions{
+		Where:      where,
+		DeadLetter: deadLetter,
+		MaxResults: qty,
+	}, nil
+}
@@ -0,0 +1,377 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/filter"
+	cli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+const streamKeepAliveInterval = 15 * time.Second
+
+var errStreamingNotSupported = errors.New("the response writer does not support streaming")
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// The api does not have any authentication so we allow browser tails served from any origin
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// StreamQueueMessages Streams the messages arriving in a Queue as server sent events or websocket frames
+func (c *Controller) StreamQueueMessages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queueName := vars["queueName"]
+	errorResponse := entities.ApiErrorResponse{}
+
+	// Queue Name cannot be nil
+	if queueName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		errorResponse.Code = http.StatusBadRequest
+		errorResponse.Error = "Queue name is null"
+		errorResponse.Message = "Queue name cannot be null"
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	peek, expression, filterErr := getStreamOptions(r)
+	if filterErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(filterErr)
+		return
+	}
+
+	queue, err := sbcli.GetQueueDetails(queueName)
+	if queue == nil {
+		w.WriteHeader(http.StatusNotFound)
+		errorResponse.Code = http.StatusNotFound
+		errorResponse.Error = "Queue Not Found"
+		errorResponse.Message = "Queue with name " + queueName + " was not found in " + sbcli.Namespace.Name
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			errorResponse.Message = err.Error()
+		}
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	check := func(msg *servicebus.Message) error {
+		return sbcli.CheckQueueMessageSchema(queueName, msg)
+	}
+	streamMessages(w, r, &cli.Endpoint{Queue: queueName}, peek, expression, check)
+}
+
+// StreamSubscriptionMessages Streams the messages arriving in a topic subscription as server sent events or websocket frames
+func (c *Controller) StreamSubscriptionMessages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	topicName := vars["topicName"]
+	subscriptionName := vars["subscriptionName"]
+	errorResponse := entities.ApiErrorResponse{}
+
+	// Topic Name cannot be nil
+	if topicName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		errorResponse.Code = http.StatusBadRequest
+		errorResponse.Error = "Topic name is null"
+		errorResponse.Message = "Topic name cannot be null"
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	// Subscription Name cannot be nil
+	if subscriptionName == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		errorResponse.Code = http.StatusBadRequest
+		errorResponse.Error = "Subscription name is null"
+		errorResponse.Message = "Subscription name cannot be null"
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	peek, expression, filterErr := getStreamOptions(r)
+	if filterErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(filterErr)
+		return
+	}
+
+	_, err := sbcli.GetSubscription(topicName, subscriptionName)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		errorResponse.Code = http.StatusNotFound
+		errorResponse.Error = "Subscription not found"
+		errorResponse.Message = "The Subscription " + subscriptionName + " was not found on " + topicName + " topic in the service bus " + sbcli.Namespace.Name
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
+	check := func(msg *servicebus.Message) error {
+		return sbcli.CheckTopicMessageSchema(topicName, msg)
+	}
+	streamMessages(w, r, &cli.Endpoint{Topic: topicName, Subscription: subscriptionName}, peek, expression, check)
+}
+
+// getStreamOptions reads the peek and filter query attributes of a stream request, a filter needs peek as a
+// receiver can not leave the messages that do not match alone, abandoning them delivers them again straight away
+func getStreamOptions(r *http.Request) (bool, *filter.Expression, *entities.ApiErrorResponse) {
+	queryValues := r.URL.Query()
+	peek := queryValues.Get("peek") == "true"
+
+	expression, err := filter.Parse(queryValues.Get("filter"))
+	if err != nil {
+		return peek, nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Invalid Filter Expression", err.Error())
+	}
+	if !expression.IsEmpty() && !peek {
+		return peek, nil, entities.NewApiErrorResponse(http.StatusBadRequest, "Filter Needs Peek", "filtered streams can only peek the messages, add peek=true to the request")
+	}
+
+	return peek, expression, nil
+}
+
+// messageStreamWriter is the transport used to push messages to the client
+type messageStreamWriter interface {
+	WriteMessage(message entities.MessageResponse) error
+	WriteKeepAlive() error
+	WriteError(errorResponse *entities.ApiErrorResponse) error
+	Disconnected() <-chan struct{}
+	Close() error
+}
+
+type streamEvent struct {
+	message   entities.MessageResponse
+	delivered chan error
+}
+
+// streamMessages pushes every message accepted by the filter to the client until it disconnects,
+// peek streams browse the messages arriving after the client connected so nothing is locked, the other
+// streams receive the messages and only complete them after being written to the client, abandoning
+// the ones that could not be written
+func streamMessages(w http.ResponseWriter, r *http.Request, endpoint *cli.Endpoint, peek bool, expression *filter.Expression, check func(msg *servicebus.Message) error) {
+	var writer messageStreamWriter
+	if websocket.IsWebSocketUpgrade(r) {
+		conn, err := streamUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// the upgrader already replied to the client with the error
+			logger.Error(err.Error())
+			return
+		}
+		writer = newWebSocketStreamWriter(conn)
+	} else {
+		sseWriter, err := newServerSentEventsWriter(w, r)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(entities.NewApiErrorResponse(http.StatusInternalServerError, "Streaming Not Supported", err.Error()))
+			return
+		}
+		writer = sseWriter
+	}
+	defer writer.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	events := make(chan streamEvent)
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		if !expression.Match(msg) {
+			return nil
+		}
+
+		event := streamEvent{
+			delivered: make(chan error, 1),
+		}
+		event.message.FromServiceBus(msg)
+		event.message.SchemaErrors = schemaDetails(check(msg))
+
+		var delivered error
+		select {
+		case events <- event:
+			select {
+			case delivered = <-event.delivered:
+			case <-ctx.Done():
+				delivered = ctx.Err()
+			}
+		case <-ctx.Done():
+			delivered = ctx.Err()
+		}
+
+		switch {
+		case peek:
+			return nil
+		case delivered != nil:
+			// the client is gone so the message goes back to the entity for the other receivers
+			settleCtx, settleCancel := context.WithTimeout(context.Background(), cli.SettleTimeout)
+			defer settleCancel()
+			return msg.Abandon(settleCtx)
+		default:
+			return msg.Complete(msgCtx)
+		}
+	}
+
+	listenerResult := make(chan error, 1)
+	go func() {
+		if peek {
+			listenerResult <- sbcli.BrowseEndpointMessages(ctx, endpoint, true, func(msg *servicebus.Message) error {
+				return handler(ctx, msg)
+			})
+			return
+		}
+		listenerResult <- sbcli.StreamEndpointMessages(ctx, endpoint, handler)
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	disconnected := writer.Disconnected()
+	logger.Info("Client " + r.RemoteAddr + " connected to stream " + r.URL.Path)
+	for {
+		select {
+		case event := <-events:
+			err := writer.WriteMessage(event.message)
+			event.delivered <- err
+			if err != nil {
+				cancel()
+			}
+		case <-keepAlive.C:
+			if err := writer.WriteKeepAlive(); err != nil {
+				cancel()
+			}
+		case <-disconnected:
+			disconnected = nil
+			cancel()
+		case err := <-listenerResult:
+			if err != nil && ctx.Err() == nil {
+				writer.WriteError(entities.NewApiErrorResponse(http.StatusInternalServerError, "Stream Error", err.Error()))
+			}
+			logger.Info("Client " + r.RemoteAddr + " disconnected from stream " + r.URL.Path)
+			return
+		}
+	}
+}
+
+// serverSentEventsWriter writes messages as text/event-stream events
+type serverSentEventsWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    <-chan struct{}
+}
+
+func newServerSentEventsWriter(w http.ResponseWriter, r *http.Request) (*serverSentEventsWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errStreamingNotSupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &serverSentEventsWriter{
+		w:       w,
+		flusher: flusher,
+		done:    r.Context().Done(),
+	}, nil
+}
+
+func (s *serverSentEventsWriter) writeEvent(event string, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	if id != "" {
+		builder.WriteString("id: " + id + "\n")
+	}
+	builder.WriteString("event: " + event + "\n")
+	builder.WriteString("data: " + string(payload) + "\n\n")
+
+	if _, err := s.w.Write([]byte(builder.String())); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *serverSentEventsWriter) WriteMessage(message entities.MessageResponse) error {
+	return s.writeEvent("message", message.ID, message)
+}
+
+func (s *serverSentEventsWriter) WriteKeepAlive() error {
+	if _, err := s.w.Write([]byte(": keep-alive\n\n")); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *serverSentEventsWriter) WriteError(errorResponse *entities.ApiErrorResponse) error {
+	return s.writeEvent("error", "", errorResponse)
+}
+
+func (s *serverSentEventsWriter) Disconnected() <-chan struct{} {
+	return s.done
+}
+
+func (s *serverSentEventsWriter) Close() error {
+	return nil
+}
+
+// webSocketStreamWriter writes messages as json text frames
+type webSocketStreamWriter struct {
+	conn *websocket.Conn
+	done chan struct{}
+}
+
+func newWebSocketStreamWriter(conn *websocket.Conn) *webSocketStreamWriter {
+	writer := webSocketStreamWriter{
+		conn: conn,
+		done: make(chan struct{}),
+	}
+
+	// We need to keep reading from the connection to process the control frames and to detect
+	// when the client goes away, any message sent by the client is ignored
+	go func() {
+		defer close(writer.done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	return &writer
+}
+
+func (s *webSocketStreamWriter) WriteMessage(message entities.MessageResponse) error {
+	return s.conn.WriteJSON(message)
+}
+
+func (s *webSocketStreamWriter) WriteKeepAlive() error {
+	return s.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second))
+}
+
+func (s *webSocketStreamWriter) WriteError(errorResponse *entities.ApiErrorResponse) error {
+	return s.conn.WriteJSON(errorResponse)
+}
+
+func (s *webSocketStreamWriter) Disconnected() <-chan struct{} {
+	return s.done
+}
+
+func (s *webSocketStreamWriter) Close() error {
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	return s.conn.Close()
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	servicebus "github.com/Azure/azure-service-bus-go"
 	"github.com/cjlapao/servicebuscli-go/entities"
 	"github.com/gorilla/mux"
 )
@@ -316,7 +317,27 @@ func (c *Controller) GetSubscriptionMessages(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	result, err := sbcli.GetSubscriptionActiveMessages(topicName, subscriptionName, qty, peek)
+	search, searchErr := getSearchOptions(r, qty, false)
+	if searchErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(searchErr)
+		return
+	}
+
+	receive, receiveErr := getReceiveOptions(r)
+	if receiveErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(receiveErr)
+		return
+	}
+
+	var result []servicebus.Message
+	var err error
+	if search != nil {
+		result, err = sbcli.SearchSubscriptionMessages(r.Context(), topicName, subscriptionName, *search)
+	} else {
+		result, err = sbcli.GetSubscriptionActiveMessages(topicName, subscriptionName, qty, peek, receive)
+	}
 
 	// Body deserialization error
 	if err != nil {
@@ -336,6 +357,7 @@ func (c *Controller) GetSubscriptionMessages(w http.ResponseWriter, r *http.Requ
 		for _, msg := range result {
 			entityMsg := entities.MessageResponse{}
 			entityMsg.FromServiceBus(&msg)
+			entityMsg.SchemaErrors = schemaDetails(sbcli.CheckTopicMessageSchema(topicName, &msg))
 			response = append(response, entityMsg)
 		}
 		w.WriteHeader(http.StatusOK)
@@ -388,7 +410,27 @@ func (c *Controller) GetSubscriptionDeadLetterMessages(w http.ResponseWriter, r
 		return
 	}
 
-	result, err := sbcli.GetSubscriptionDeadLetterMessages(topicName, subscriptionName, qty, peek)
+	search, searchErr := getSearchOptions(r, qty, true)
+	if searchErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(searchErr)
+		return
+	}
+
+	receive, receiveErr := getReceiveOptions(r)
+	if receiveErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(receiveErr)
+		return
+	}
+
+	var result []servicebus.Message
+	var err error
+	if search != nil {
+		result, err = sbcli.SearchSubscriptionMessages(r.Context(), topicName, subscriptionName, *search)
+	} else {
+		result, err = sbcli.GetSubscriptionDeadLetterMessages(topicName, subscriptionName, qty, peek, receive)
+	}
 
 	// Body deserialization error
 	if err != nil {
@@ -404,10 +446,11 @@ func (c *Controller) GetSubscriptionDeadLetterMessages(w http.ResponseWriter, r
 		w.WriteHeader(http.StatusNoContent)
 		return
 	} else {
-		response := make([]entities.MessageRequest, 0)
+		response := make([]entities.MessageResponse, 0)
 		for _, msg := range result {
-			entityMsg := entities.MessageRequest{}
+			entityMsg := entities.MessageResponse{}
 			entityMsg.FromServiceBus(&msg)
+			entityMsg.SchemaErrors = schemaDetails(sbcli.CheckTopicMessageSchema(topicName, &msg))
 			response = append(response, entityMsg)
 		}
 		w.WriteHeader(http.StatusOK)
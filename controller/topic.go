@@ -1,15 +1,16 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"sync"
-	"time"
 
 	servicebus "github.com/Azure/azure-service-bus-go"
 	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/jobs"
+	"github.com/cjlapao/servicebuscli-go/templating"
 	"github.com/gorilla/mux"
 )
 
@@ -233,6 +234,9 @@ func (c *Controller) SendTopicMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = sbcli.SendTopicServiceBusMessage(topicName, sbMessage)
+	if writeSchemaError(w, err) {
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		errorResponse.Code = http.StatusBadRequest
@@ -291,6 +295,9 @@ func (c *Controller) SendBulkTopicMessage(w http.ResponseWriter, r *http.Request
 	}
 
 	err = sbcli.SendBulkTopicMessage(topicName, bulk.Messages...)
+	if writeSchemaError(w, err) {
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		errorResponse.Code = http.StatusBadRequest
@@ -313,7 +320,6 @@ func (c *Controller) SendBulkTemplateTopicMessage(w http.ResponseWriter, r *http
 	topicName := vars["topicName"]
 	reqBody, err := ioutil.ReadAll(r.Body)
 	errorResponse := entities.ApiErrorResponse{}
-	maxSizeOfPayload := 262144
 
 	// Topic Name cannot be nil
 	if topicName == "" {
@@ -348,91 +354,77 @@ func (c *Controller) SendBulkTemplateTopicMessage(w http.ResponseWriter, r *http
 		return
 	}
 
-	if bulk.BatchOf > bulk.TotalMessages {
+	if bulk.TotalMessages < 1 {
+		bulk.TotalMessages = 1
+	}
+	if bulk.BatchOf < 1 || bulk.BatchOf > bulk.TotalMessages {
 		bulk.BatchOf = 1
 	}
 
-	totalMessageSent := 0
-
-	messageTemplate, err := json.Marshal(bulk.Template)
-	if err != nil {
+	if _, err := templating.New(bulk.Template, bulk.Rows); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		errorResponse.Code = http.StatusBadRequest
-		errorResponse.Error = err.Error()
-		errorResponse.Message = "There was an error checking the templated message payload size"
+		errorResponse.Error = "Invalid Template"
+		errorResponse.Message = err.Error()
 		json.NewEncoder(w).Encode(errorResponse)
 		return
 	}
-	messageTemplateSize := len(messageTemplate)
-	totalMessagePayloadSize := messageTemplateSize * bulk.TotalMessages
-	minimumBatchSize := totalMessagePayloadSize / maxSizeOfPayload
-	if minimumBatchSize > bulk.BatchOf {
-		bulk.BatchOf = minimumBatchSize + 5
-		logger.Info("The total payload was too big for the given batch size, increasing it to the minimum of " + fmt.Sprint(bulk.BatchOf))
-	}
-
-	batchSize := bulk.TotalMessages / bulk.BatchOf
-	messages := make([]entities.MessageRequest, 0)
 
-	for i := 0; i < batchSize; i++ {
-		messages = append(messages, bulk.Template)
+	if err := sbcli.CheckTemplateSchema("", topicName, bulk.Template, bulk.Rows); writeSchemaError(w, err) {
+		return
 	}
 
-	if bulk.WaitBetweenBatchesInMilli > 0 {
-		for i := 0; i < bulk.BatchOf; i++ {
-			if i > 0 && bulk.WaitBetweenBatchesInMilli > 0 {
-				logger.Info("Waiting " + fmt.Sprint(bulk.WaitBetweenBatchesInMilli) + "ms for next batch")
-				time.Sleep(time.Duration(bulk.WaitBetweenBatchesInMilli) * time.Millisecond)
-			}
-
-			err = sbcli.SendBulkTopicMessage(topicName, messages...)
-
-			if err != nil {
-				w.WriteHeader(http.StatusBadRequest)
-				errorResponse.Code = http.StatusBadRequest
-				errorResponse.Error = err.Error()
-				errorResponse.Message = "There was an error sending bulk messages to topic " + topicName
-				json.NewEncoder(w).Encode(errorResponse)
-				return
-			}
-			totalMessageSent += len(messages)
-		}
-	} else {
-		logger.Info("Sending all batches without waiting")
-		var waitFor sync.WaitGroup
-		waitFor.Add(bulk.BatchOf)
-		for i := 0; i < bulk.BatchOf; i++ {
-			go sbcli.SendParallelBulkTopicMessage(&waitFor, topicName, messages...)
-			totalMessageSent += len(messages)
-		}
+	sender, err := sbcli.GetTopicSender(topicName)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		errorResponse.Code = http.StatusNotFound
+		errorResponse.Error = "Topic Not Found"
+		errorResponse.Message = err.Error()
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
 
-		waitFor.Wait()
-		logger.Success("Finished sending all the batches to service bus")
+	work := func(ctx context.Context, job *jobs.Job) error {
+		defer sender.Close(context.Background())
+		return sbcli.SendBulkTemplateMessages(ctx, sender, bulk, job.Add)
 	}
 
-	if totalMessageSent < bulk.TotalMessages {
-		missingMessageCount := bulk.TotalMessages - totalMessageSent
-		logger.Info("Sending remaining " + fmt.Sprint(missingMessageCount) + " messages in the payload")
-		messages := make([]entities.MessageRequest, 0)
-		for x := 0; x < missingMessageCount; x++ {
-			messages = append(messages, bulk.Template)
+	// POST runs the send in the background and replies straight away with the job to follow its progress
+	if r.Method == "POST" {
+		// the job closes the sender, it needs to be closed here if the job did not start
+		if !startJob(w, JobTypeSendBulkTemplate, "topics/"+topicName, bulk.TotalMessages, work) {
+			sender.Close(context.Background())
 		}
+		return
+	}
 
-		err = sbcli.SendBulkTopicMessage(topicName, messages...)
+	job, err := jobManager.Start(JobTypeSendBulkTemplate, "topics/"+topicName, bulk.TotalMessages, work)
+	if err != nil {
+		sender.Close(context.Background())
+		w.WriteHeader(http.StatusInternalServerError)
+		errorResponse.Code = http.StatusInternalServerError
+		errorResponse.Error = "Error Starting Job"
+		errorResponse.Message = err.Error()
+		json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+	<-job.Done()
 
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			errorResponse.Code = http.StatusBadRequest
-			errorResponse.Error = err.Error()
-			errorResponse.Message = "There was an error sending bulk messages to topic " + topicName
-			json.NewEncoder(w).Encode(errorResponse)
-			return
-		}
-		bulk.BatchOf += 1
+	progress := job.Progress()
+	if writeSchemaError(w, progress.Error) {
+		return
+	}
+	if progress.Error != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		errorResponse.Code = http.StatusBadRequest
+		errorResponse.Error = progress.Error.Error()
+		errorResponse.Message = "There was an error sending bulk messages to topic " + topicName
+		json.NewEncoder(w).Encode(errorResponse)
+		return
 	}
 
 	response := entities.ApiSuccessResponse{
-		Message: "Sent " + fmt.Sprint(bulk.TotalMessages) + " Messages in " + fmt.Sprint(bulk.BatchOf) + " batches successfully to " + topicName + " topic",
+		Message: "Sent " + fmt.Sprint(progress.Succeeded) + " Messages in " + fmt.Sprint(bulk.BatchOf) + " batches successfully to " + topicName + " topic",
 	}
 
 	w.WriteHeader(http.StatusAccepted)
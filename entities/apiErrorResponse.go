@@ -2,9 +2,16 @@ package entities
 
 // LoginErrorResponse entity
 type ApiErrorResponse struct {
-	Code    int64  `json:"code,omitempty"`
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Code    int64            `json:"code,omitempty"`
+	Error   string           `json:"error"`
+	Message string           `json:"message,omitempty"`
+	Details []ApiErrorDetail `json:"details,omitempty"`
+}
+
+// ApiErrorDetail entity, field level error of a request
+type ApiErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // NewApiSuccessResponse Creates a new API Success Response struct
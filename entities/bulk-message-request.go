@@ -1,12 +1,14 @@
 package entities
 
 type BulkMessageRequest struct {
-	Messages []MessageRequest `json:"messages"`
+	Messages []MessageRequest `json:"messages" openapi:"required"`
 }
 
 type BulkTemplateMessageRequest struct {
-	TotalMessages             int            `json:"totalMessages"`
+	TotalMessages             int            `json:"totalMessages" openapi:"minimum=1"`
 	Template                  MessageRequest `json:"template"`
-	BatchOf                   int            `json:"batchOf"`
-	WaitBetweenBatchesInMilli int            `json:"waitBetweenBatchesInMilli"`
+	BatchOf                   int            `json:"batchOf" openapi:"minimum=1"`
+	WaitBetweenBatchesInMilli int            `json:"waitBetweenBatchesInMilli" openapi:"minimum=0"`
+	// Rows are the values of .Row in the template, each message uses the next row
+	Rows []map[string]interface{} `json:"rows"`
 }
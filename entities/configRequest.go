@@ -2,5 +2,5 @@ package entities
 
 // Forward struct
 type ConfigRequest struct {
-	ConnectionString string `json:"connectionString"`
+	ConnectionString string `json:"connectionString" openapi:"required,minLength=1"`
 }
@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// maxExpectationWithinInSeconds is the longest an expectation can keep a request waiting
+const maxExpectationWithinInSeconds = 300
+
+// ExpectationRequest entity
+type ExpectationRequest struct {
+	// Queue or Topic and Subscription are where the message is expected
+	Queue        string `json:"queue"`
+	Topic        string `json:"topic"`
+	Subscription string `json:"subscription"`
+	// Where is the filter expression the message needs to match, any message matches an empty expression
+	Where string `json:"where"`
+	// WithinInSeconds is how long to wait for the message, defaults to 30 and can be up to 300
+	WithinInSeconds int `json:"withinInSeconds" openapi:"minimum=0"`
+	// Consume receives and completes the matching message instead of peeking it
+	Consume bool `json:"consume"`
+	// DeadLetter expects the message in the dead letter queue
+	DeadLetter bool `json:"deadLetter"`
+}
+
+// Validate Checks the expectation has a queue or subscription setting the defaults for the missing values
+func (e *ExpectationRequest) Validate() error {
+	if (e.Topic == "") == (e.Queue == "") {
+		return errors.New("the expectation needs either a queue or a topic and subscription")
+	}
+	if e.Topic != "" && e.Subscription == "" {
+		return errors.New("the expectation needs the subscription of the topic")
+	}
+	if e.WithinInSeconds <= 0 {
+		e.WithinInSeconds = 30
+	}
+	if e.WithinInSeconds > maxExpectationWithinInSeconds {
+		return errors.New("the expectation can wait up to 300 seconds")
+	}
+
+	return nil
+}
+
+// Within Gets how long to wait for the message
+func (e *ExpectationRequest) Within() time.Duration {
+	return time.Duration(e.WithinInSeconds) * time.Second
+}
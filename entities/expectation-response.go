@@ -0,0 +1,11 @@
+package entities
+
+// ExpectationResponse entity
+type ExpectationResponse struct {
+	// Matched is true when a matching message arrived in time
+	Matched bool `json:"matched"`
+	// ElapsedInMilli is how long it took for the message to arrive, or the whole wait when it did not
+	ElapsedInMilli int64 `json:"elapsedInMilli"`
+	// Message is the matching message
+	Message *MessageEnvelope `json:"message,omitempty"`
+}
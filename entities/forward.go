@@ -2,6 +2,6 @@ package entities
 
 // Forward struct
 type Forward struct {
-	To string
-	In ForwardDestination
+	To string             `json:"to" openapi:"required,minLength=1"`
+	In ForwardDestination `json:"in"`
 }
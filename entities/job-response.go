@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/jobs"
+)
+
+// JobResponse entity
+type JobResponse struct {
+	ID                  string      `json:"id"`
+	Type                string      `json:"type"`
+	Target              string      `json:"target"`
+	Status              string      `json:"status"`
+	Total               int         `json:"total"`
+	Processed           int         `json:"processed"`
+	Failed              int         `json:"failed"`
+	Percentage          float64     `json:"percentage"`
+	RatePerSecond       float64     `json:"ratePerSecond"`
+	EtaInSeconds        float64     `json:"etaInSeconds"`
+	EstimatedCompletion *time.Time  `json:"estimatedCompletion,omitempty"`
+	Error               string      `json:"error,omitempty"`
+	Result              interface{} `json:"result,omitempty"`
+	CreatedAt           time.Time   `json:"createdAt"`
+	StartedAt           *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt          *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// FromJob Fills the response with the current progress of a job
+func (j *JobResponse) FromJob(job *jobs.Job) {
+	progress := job.Progress()
+
+	j.ID = job.ID
+	j.Type = job.Type
+	j.Target = job.Target
+	j.Status = progress.Status.String()
+	j.Total = progress.Total
+	j.Processed = progress.Succeeded
+	j.Failed = progress.Failed
+	j.RatePerSecond = progress.Rate
+	j.EtaInSeconds = progress.ETA.Seconds()
+	j.CreatedAt = progress.CreatedAt
+
+	if progress.Total > 0 {
+		j.Percentage = float64(progress.Succeeded+progress.Failed) / float64(progress.Total) * 100
+		if j.Percentage > 100 {
+			j.Percentage = 100
+		}
+	}
+	// the total of some jobs is an estimate so we always report completed jobs as done
+	if progress.Status == jobs.StatusCompleted {
+		j.Percentage = 100
+	}
+	if progress.ETA > 0 {
+		estimated := time.Now().Add(progress.ETA)
+		j.EstimatedCompletion = &estimated
+	}
+	j.Result = progress.Result
+	if progress.Error != nil {
+		j.Error = progress.Error.Error()
+	}
+	if !progress.StartedAt.IsZero() {
+		j.StartedAt = &progress.StartedAt
+	}
+	if !progress.FinishedAt.IsZero() {
+		j.FinishedAt = &progress.FinishedAt
+	}
+}
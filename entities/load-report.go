@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LoadReport entity
+type LoadReport struct {
+	Sent                    int           `json:"sent"`
+	Failed                  int           `json:"failed"`
+	Concurrency             int           `json:"concurrency"`
+	DurationInSeconds       float64       `json:"durationInSeconds"`
+	TargetMessagesPerSecond float64       `json:"targetMessagesPerSecond"`
+	MessagesPerSecond       float64       `json:"messagesPerSecond"`
+	Latency                 LatencyReport `json:"latency"`
+}
+
+// LatencyReport entity, all the values are in milliseconds
+type LatencyReport struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// NewLatencyReport Creates the latency report of a set of samples, the samples are sorted in place
+func NewLatencyReport(samples []time.Duration) LatencyReport {
+	result := LatencyReport{
+		Count: len(samples),
+	}
+	if len(samples) == 0 {
+		return result
+	}
+
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+
+	var total time.Duration
+	for _, sample := range samples {
+		total += sample
+	}
+
+	result.Min = toMilliseconds(samples[0])
+	result.Max = toMilliseconds(samples[len(samples)-1])
+	result.Mean = toMilliseconds(total / time.Duration(len(samples)))
+	result.P50 = toMilliseconds(percentile(samples, 50))
+	result.P95 = toMilliseconds(percentile(samples, 95))
+	result.P99 = toMilliseconds(percentile(samples, 99))
+
+	return result
+}
+
+// percentile gets the nearest rank percentile of sorted samples
+func percentile(samples []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p/100*float64(len(samples)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(samples) {
+		rank = len(samples) - 1
+	}
+
+	return samples[rank]
+}
+
+func toMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// LoadRequest entity
+type LoadRequest struct {
+	Template MessageRequest `json:"template"`
+	// MessagesPerSecond is the rate the load is sent at, zero sends as fast as the senders can
+	MessagesPerSecond float64 `json:"messagesPerSecond" openapi:"minimum=0"`
+	DurationInSeconds int     `json:"durationInSeconds" openapi:"minimum=0"`
+	TotalMessages     int     `json:"totalMessages" openapi:"minimum=0"`
+	RampUpInSeconds   int     `json:"rampUpInSeconds" openapi:"minimum=0"`
+	RampDownInSeconds int     `json:"rampDownInSeconds" openapi:"minimum=0"`
+	Concurrency       int     `json:"concurrency" openapi:"minimum=0"`
+	// Rows are the values of .Row in the template, each message uses the next row
+	Rows []map[string]interface{} `json:"rows"`
+}
+
+// Duration Gets the duration of the load, zero if it only stops when all the messages are sent
+func (l *LoadRequest) Duration() time.Duration {
+	return time.Duration(l.DurationInSeconds) * time.Second
+}
+
+// RampUp Gets the time the load takes to reach the target rate
+func (l *LoadRequest) RampUp() time.Duration {
+	return time.Duration(l.RampUpInSeconds) * time.Second
+}
+
+// RampDown Gets the time the load takes to go from the target rate to zero at the end of the duration
+func (l *LoadRequest) RampDown() time.Duration {
+	return time.Duration(l.RampDownInSeconds) * time.Second
+}
+
+// Validate Checks if the load can be run setting the defaults for the missing values
+func (l *LoadRequest) Validate() error {
+	if l.DurationInSeconds <= 0 && l.TotalMessages <= 0 {
+		return errors.New("the load needs a duration or a total of messages to know when to stop")
+	}
+	if l.RampDownInSeconds > 0 && l.DurationInSeconds <= 0 {
+		return errors.New("the ramp down needs a duration to know when to start")
+	}
+	if l.DurationInSeconds > 0 && l.RampUpInSeconds+l.RampDownInSeconds > l.DurationInSeconds {
+		return errors.New("the ramp up and ramp down cannot be longer than the duration")
+	}
+	if (l.RampUpInSeconds > 0 || l.RampDownInSeconds > 0) && l.MessagesPerSecond <= 0 {
+		return errors.New("the ramp up and ramp down need a rate of messages per second")
+	}
+	if l.Concurrency < 1 {
+		l.Concurrency = 1
+	}
+
+	return nil
+}
+
+// ExpectedMessages Gets an estimate of the number of messages the load will send, zero if it cannot be estimated
+func (l *LoadRequest) ExpectedMessages() int {
+	if l.MessagesPerSecond <= 0 || l.DurationInSeconds <= 0 {
+		return l.TotalMessages
+	}
+
+	// during the ramps the load sends on average half of the rate
+	seconds := float64(l.DurationInSeconds) - float64(l.RampUpInSeconds+l.RampDownInSeconds)/2
+	expected := int(l.MessagesPerSecond * seconds)
+	if l.TotalMessages > 0 && l.TotalMessages < expected {
+		return l.TotalMessages
+	}
+
+	return expected
+}
@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+)
+
+// MessageEnvelope Properties and body of a message, used when messages leave the service bus as json. Json
+// object and array bodies are decoded so they can be read, other bodies are kept as strings
+type MessageEnvelope struct {
+	ID             string                 `json:"id"`
+	Label          string                 `json:"label,omitempty"`
+	CorrelationID  string                 `json:"correlationId,omitempty"`
+	ContentType    string                 `json:"contentType,omitempty"`
+	SequenceNumber int64                  `json:"sequenceNumber,omitempty"`
+	DeliveryCount  uint32                 `json:"deliveryCount"`
+	EnqueuedTime   *time.Time             `json:"enqueuedTime,omitempty"`
+	UserProperties map[string]interface{} `json:"userProperties,omitempty"`
+	Body           interface{}            `json:"body"`
+}
+
+// FromServiceBus Fills the envelope with a received message
+func (e *MessageEnvelope) FromServiceBus(msg *servicebus.Message) {
+	e.ID = msg.ID
+	e.Label = msg.Label
+	e.CorrelationID = msg.CorrelationID
+	e.ContentType = msg.ContentType
+	e.DeliveryCount = msg.DeliveryCount
+	e.UserProperties = msg.UserProperties
+	if msg.SystemProperties != nil {
+		e.EnqueuedTime = msg.SystemProperties.EnqueuedTime
+		if msg.SystemProperties.SequenceNumber != nil {
+			e.SequenceNumber = *msg.SystemProperties.SequenceNumber
+		}
+	}
+
+	e.Body = string(msg.Data)
+	trimmed := bytes.TrimSpace(msg.Data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		// numbers are kept as they are instead of being converted to floats
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		var body interface{}
+		if err := decoder.Decode(&body); err == nil && !decoder.More() {
+			e.Body = body
+		}
+	}
+}
+
+// ToServiceBus Creates a message to send again with the properties and body of the envelope, the id is not
+// kept so the message is not taken as a duplicate
+func (e *MessageEnvelope) ToServiceBus() (*servicebus.Message, error) {
+	var data []byte
+	switch body := e.Body.(type) {
+	case string:
+		data = []byte(body)
+	case nil:
+		data = []byte{}
+	default:
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		data = content
+	}
+
+	msg := servicebus.NewMessage(data)
+	msg.Label = e.Label
+	msg.CorrelationID = e.CorrelationID
+	msg.ContentType = e.ContentType
+	if len(e.UserProperties) > 0 {
+		msg.UserProperties = make(map[string]interface{})
+		for key, value := range e.UserProperties {
+			msg.UserProperties[key] = jsonValue(value)
+		}
+	}
+
+	return msg, nil
+}
+
+// jsonValue converts the numbers of a decoded json value to integers or floats as the service bus can not
+// send json numbers
+func jsonValue(value interface{}) interface{} {
+	number, isNumber := value.(json.Number)
+	if !isNumber {
+		return value
+	}
+
+	if integer, err := number.Int64(); err == nil {
+		return integer
+	}
+	if float, err := number.Float64(); err == nil {
+		return float
+	}
+	return number.String()
+}
@@ -0,0 +1,14 @@
+package entities
+
+// MessageOperationRequest Options of an operation on messages picked by their sequence number or id, the reason and
+// description are used when dead lettering and the destination and keep id when moving, lock ahead allows locking and
+// abandoning the messages in front of the picked ones
+type MessageOperationRequest struct {
+	SequenceNumbers []int64  `json:"sequenceNumbers"`
+	MessageIDs      []string `json:"messageIds"`
+	Reason          string   `json:"reason"`
+	Description     string   `json:"description"`
+	To              string   `json:"to"`
+	KeepID          bool     `json:"keepId"`
+	LockAhead       bool     `json:"lockAhead"`
+}
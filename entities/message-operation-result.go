@@ -0,0 +1,13 @@
+package entities
+
+// MessageOperationResult Outcome of an operation on a message picked by its sequence number or id
+type MessageOperationResult struct {
+	SequenceNumber int64  `json:"sequenceNumber"`
+	MessageID      string `json:"messageId,omitempty"`
+	Action         string `json:"action"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	// Abandoned is how many messages in front of the message were locked and abandoned to reach it
+	Abandoned int              `json:"abandoned,omitempty"`
+	Message   *MessageEnvelope `json:"message,omitempty"`
+}
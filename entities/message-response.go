@@ -8,11 +8,13 @@ import (
 
 type MessageResponse struct {
 	ID             string                 `json:"id"`
+	SequenceNumber int64                  `json:"sequenceNumber,omitempty"`
 	Label          string                 `json:"label"`
 	CorrelationID  string                 `json:"correlationId"`
 	ContentType    string                 `json:"contentType"`
 	Data           map[string]interface{} `json:"data"`
 	UserProperties map[string]interface{} `json:"userProperties"`
+	SchemaErrors   []ApiErrorDetail       `json:"schemaErrors,omitempty"`
 }
 
 func (m *MessageResponse) FromServiceBus(msg *servicebus.Message) error {
@@ -23,6 +25,9 @@ func (m *MessageResponse) FromServiceBus(msg *servicebus.Message) error {
 	}
 
 	m.ID = msg.ID
+	if msg.SystemProperties != nil && msg.SystemProperties.SequenceNumber != nil {
+		m.SequenceNumber = *msg.SystemProperties.SequenceNumber
+	}
 	m.UserProperties = msg.UserProperties
 
 	if msg.Label != "" {
@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+// ProbeReport entity
+type ProbeReport struct {
+	ProbeID  string `json:"probeId"`
+	Target   string `json:"target"`
+	Source   string `json:"source"`
+	Sent     int    `json:"sent"`
+	Received int    `json:"received"`
+	Pending  int    `json:"pending"`
+	// Lost are the messages not received within the timeout, Late the ones received after it
+	Lost        int     `json:"lost"`
+	Late        int     `json:"late"`
+	OutOfOrder  int     `json:"outOfOrder"`
+	Duplicates  int     `json:"duplicates"`
+	OverSlo     int     `json:"overSlo"`
+	SloInMilli  int     `json:"sloInMilli,omitempty"`
+	LastInMilli float64 `json:"lastInMilli"`
+	// Latency is calculated over the most recent messages so long running probes show the current state
+	Latency   LatencyReport `json:"latency"`
+	StartedAt time.Time     `json:"startedAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+}
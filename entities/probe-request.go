@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"errors"
+	"time"
+)
+
+// ProbeRequest entity
+type ProbeRequest struct {
+	// Topic or Queue is where the probe messages are sent to
+	Topic string `json:"topic"`
+	Queue string `json:"queue"`
+	// Subscription is the topic subscription the probe messages are received from
+	Subscription string `json:"subscription"`
+	// ReceiveTopic or ReceiveQueue are used when the messages are forwarded to another entity
+	ReceiveTopic     string `json:"receiveTopic"`
+	ReceiveQueue     string `json:"receiveQueue"`
+	IntervalInMilli  int    `json:"intervalInMilli" openapi:"minimum=0"`
+	TimeoutInSeconds int    `json:"timeoutInSeconds" openapi:"minimum=0"`
+	// TotalMessages stops the probe once they are all received or lost, zero runs until cancelled
+	TotalMessages int `json:"totalMessages" openapi:"minimum=0"`
+	// SloInMilli counts the messages delivered slower than it, zero does not check it
+	SloInMilli int `json:"sloInMilli" openapi:"minimum=0"`
+}
+
+// Validate Checks the probe has an entity to send to and to receive from setting the defaults for the missing values
+func (p *ProbeRequest) Validate() error {
+	if (p.Topic == "") == (p.Queue == "") {
+		return errors.New("the probe needs either a topic or a queue to send the messages to")
+	}
+	if p.ReceiveTopic != "" && p.ReceiveQueue != "" {
+		return errors.New("the probe can only receive from a topic or from a queue")
+	}
+	if p.ReceiveQueue == "" && (p.Topic != "" || p.ReceiveTopic != "") && p.Subscription == "" {
+		return errors.New("the probe needs the subscription to receive the messages from")
+	}
+	if p.IntervalInMilli <= 0 {
+		p.IntervalInMilli = 1000
+	}
+	if p.TimeoutInSeconds <= 0 {
+		p.TimeoutInSeconds = 30
+	}
+
+	return nil
+}
+
+// Interval Gets the time between probe messages
+func (p *ProbeRequest) Interval() time.Duration {
+	return time.Duration(p.IntervalInMilli) * time.Millisecond
+}
+
+// Timeout Gets how long a probe message can take before being considered lost
+func (p *ProbeRequest) Timeout() time.Duration {
+	return time.Duration(p.TimeoutInSeconds) * time.Second
+}
+
+// Target Gets the path of the entity the messages are sent to
+func (p *ProbeRequest) Target() string {
+	if p.Queue != "" {
+		return "queues/" + p.Queue
+	}
+
+	return "topics/" + p.Topic
+}
+
+// Source Gets the path of the entity the messages are received from
+func (p *ProbeRequest) Source() string {
+	switch {
+	case p.ReceiveQueue != "":
+		return "queues/" + p.ReceiveQueue
+	case p.ReceiveTopic != "":
+		return "topics/" + p.ReceiveTopic + "/" + p.Subscription
+	case p.Queue != "":
+		return "queues/" + p.Queue
+	}
+
+	return "topics/" + p.Topic + "/" + p.Subscription
+}
@@ -14,7 +14,7 @@ import (
 
 // QueueEntity structure
 type QueueRequest struct {
-	Name              string               `json:"name"`
+	Name              string               `json:"name" openapi:"required,minLength=1"`
 	MaxDeliveryCount  int32                `json:"maxDeliveryCount"`
 	Forward           *Forward             `json:"forward"`
 	ForwardDeadLetter *Forward             `json:"forwardDeadLetter"`
@@ -0,0 +1,8 @@
+package entities
+
+// ResubmitRequest Options of a dead letter resubmit job, the body is optional
+type ResubmitRequest struct {
+	// Transform is the transform pipeline applied to the messages before they are sent, it has the same steps as
+	// the yaml files of the --transform flag
+	Transform map[string]interface{} `json:"transform"`
+}
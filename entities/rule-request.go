@@ -11,7 +11,7 @@ import (
 
 // RuleRequest struct
 type RuleRequest struct {
-	Name      string `json:"name"`
+	Name      string `json:"name" openapi:"required,minLength=1"`
 	SQLFilter string `json:"sqlFilter"`
 	SQLAction string `json:"sqlAction"`
 }
@@ -13,7 +13,7 @@ import (
 )
 
 type SubscriptionRequest struct {
-	Name              string                      `json:"name"`
+	Name              string                      `json:"name" openapi:"required,minLength=1"`
 	TopicName         string                      `json:"topicName"`
 	UserDescription   string                      `json:"userDescription"`
 	MaxDeliveryCount  int32                       `json:"maxDeliveryCount,omitempty"`
@@ -8,7 +8,7 @@ import (
 )
 
 type TopicRequestEntity struct {
-	Name    string               `json:"name"`
+	Name    string               `json:"name" openapi:"required,minLength=1"`
 	Options *TopicRequestOptions `json:"options,omitempty"`
 }
 
@@ -0,0 +1,125 @@
+package filter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenOpenParenthesis
+	tokenCloseParenthesis
+	tokenComma
+)
+
+type token struct {
+	kind     tokenKind
+	value    string
+	position int
+}
+
+// keyword returns the upper case value of an identifier token so it can be compared against reserved words
+func (t token) keyword() string {
+	if t.kind != tokenIdentifier {
+		return ""
+	}
+
+	return strings.ToUpper(t.value)
+}
+
+func tokenize(expression string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expression)
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpenParenthesis, value: "(", position: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenCloseParenthesis, value: ")", position: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", position: i})
+			i++
+		case r == '\'' || r == '"':
+			start := i
+			quote := r
+			i++
+			var value strings.Builder
+			closed := false
+			for i < len(runes) {
+				if runes[i] == quote {
+					// a doubled quote is an escaped quote inside the string
+					if i+1 < len(runes) && runes[i+1] == quote {
+						value.WriteRune(quote)
+						i += 2
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, errors.New("unterminated string starting at position " + strconv.Itoa(start))
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value.String(), position: start})
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			start := i
+			operator := string(r)
+			if i+1 < len(runes) {
+				next := runes[i+1]
+				if next == '=' || (r == '<' && next == '>') {
+					operator += string(next)
+				}
+			}
+			if operator == "!" {
+				return nil, errors.New("invalid operator ! at position " + strconv.Itoa(start))
+			}
+			i += len(operator)
+			tokens = append(tokens, token{kind: tokenOperator, value: operator, position: start})
+		case unicode.IsDigit(r) || ((r == '-' || r == '.') && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i]), position: start})
+		case isIdentifierStart(r):
+			start := i
+			i++
+			for i < len(runes) && isIdentifierPart(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, value: string(runes[start:i]), position: start})
+		default:
+			return nil, errors.New("unexpected character " + string(r) + " at position " + strconv.Itoa(i))
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, position: len(runes)})
+	return tokens, nil
+}
+
+func isIdentifierStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '$'
+}
+
+func isIdentifierPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '.' || r == '-' || r == '[' || r == ']'
+}
@@ -0,0 +1,201 @@
+package filter
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+)
+
+// Expression is a parsed filter expression that can be evaluated against service bus messages
+//
+// Expressions compare message fields with values, for example:
+//
+//	label = 'OrderCreated' AND userProperties.tenant = 'acme' AND data.amount > 100
+//
+// Fields can be any system property (id, label, correlationId, sequenceNumber, ...),
+// userProperties.[name] or data.[json path] for the message body
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses a filter expression, an empty expression matches every message
+func Parse(expression string) (*Expression, error) {
+	result := Expression{
+		source: strings.TrimSpace(expression),
+	}
+
+	if result.source == "" {
+		return &result, nil
+	}
+
+	tokens, err := tokenize(result.source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected("end of expression")
+	}
+
+	result.root = root
+	return &result, nil
+}
+
+// String returns the original expression
+func (e *Expression) String() string {
+	if e == nil {
+		return ""
+	}
+	return e.source
+}
+
+// IsEmpty returns true if the expression has no conditions and will match everything
+func (e *Expression) IsEmpty() bool {
+	return e == nil || e.root == nil
+}
+
+// Match evaluates the expression against a service bus message
+func (e *Expression) Match(msg *servicebus.Message) bool {
+	if e.IsEmpty() {
+		return true
+	}
+	if msg == nil {
+		return false
+	}
+
+	return e.root.evaluate(newDocument(msg))
+}
+
+type document struct {
+	values map[string]interface{}
+}
+
+func newDocument(msg *servicebus.Message) document {
+	values := map[string]interface{}{
+		"id":             msg.ID,
+		"messageid":      msg.ID,
+		"label":          msg.Label,
+		"correlationid":  msg.CorrelationID,
+		"contenttype":    msg.ContentType,
+		"replyto":        msg.ReplyTo,
+		"replytogroupid": msg.ReplyToGroupID,
+		"to":             msg.To,
+		"deliverycount":  msg.DeliveryCount,
+	}
+
+	if msg.SessionID != nil {
+		values["sessionid"] = *msg.SessionID
+	}
+	if msg.TTL != nil {
+		values["ttl"] = msg.TTL.String()
+	}
+	if msg.SystemProperties != nil {
+		if msg.SystemProperties.SequenceNumber != nil {
+			values["sequencenumber"] = *msg.SystemProperties.SequenceNumber
+		}
+		if msg.SystemProperties.EnqueuedTime != nil {
+			values["enqueuedtime"] = *msg.SystemProperties.EnqueuedTime
+		}
+		if msg.SystemProperties.ScheduledEnqueueTime != nil {
+			values["scheduledenqueuetime"] = *msg.SystemProperties.ScheduledEnqueueTime
+		}
+		if msg.SystemProperties.LockedUntil != nil {
+			values["lockeduntil"] = *msg.SystemProperties.LockedUntil
+		}
+		if msg.SystemProperties.PartitionKey != nil {
+			values["partitionkey"] = *msg.SystemProperties.PartitionKey
+		}
+		if msg.SystemProperties.DeadLetterSource != nil {
+			values["deadlettersource"] = *msg.SystemProperties.DeadLetterSource
+		}
+	}
+
+	userProperties := map[string]interface{}{}
+	for key, value := range msg.UserProperties {
+		userProperties[key] = value
+	}
+	values["userproperties"] = userProperties
+
+	var data interface{}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		data = string(msg.Data)
+	}
+	values["data"] = data
+
+	return document{values: values}
+}
+
+// lookup resolves a field path, the first segment is case insensitive and sys. prefixes are ignored
+func (d document) lookup(path []string) (interface{}, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+
+	if strings.EqualFold(path[0], "sys") && len(path) > 1 {
+		path = path[1:]
+	}
+
+	root := strings.ToLower(path[0])
+	switch root {
+	case "user", "properties":
+		root = "userproperties"
+	case "body":
+		root = "data"
+	}
+
+	current, found := d.values[root]
+	if !found {
+		for key, value := range d.values {
+			if strings.EqualFold(key, path[0]) {
+				current = value
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	for _, segment := range path[1:] {
+		if strings.HasPrefix(segment, "[") {
+			index, _ := strconv.Atoi(strings.Trim(segment, "[]"))
+			items, ok := current.([]interface{})
+			if !ok || index < 0 || index >= len(items) {
+				return nil, false
+			}
+			current = items[index]
+			continue
+		}
+
+		values, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, found := values[segment]
+		if !found {
+			for key, candidate := range values {
+				if strings.EqualFold(key, segment) {
+					value = candidate
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return nil, false
+		}
+		current = value
+	}
+
+	return current, current != nil
+}
@@ -0,0 +1,87 @@
+package filter
+
+import (
+	"testing"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+)
+
+func testMessage() *servicebus.Message {
+	sequenceNumber := int64(42)
+	return &servicebus.Message{
+		ID:            "order-1",
+		Label:         "1.1",
+		DeliveryCount: 3,
+		UserProperties: map[string]interface{}{
+			"tenant":  "acme",
+			"version": "123",
+			"retries": int32(2),
+			"amount":  "150",
+		},
+		Data: []byte(`{"amount": 120.5, "code": "0123", "tags": ["new", "vip"], "customer": {"id": "c-1", "active": true}}`),
+		SystemProperties: &servicebus.SystemProperties{
+			SequenceNumber: &sequenceNumber,
+		},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{"", true},
+		{"label = '1.1'", true},
+		{"label = '1.10'", false},
+		{"label = 'nan'", false},
+		{"sequenceNumber = 42", true},
+		{"sequenceNumber = 'nan'", false},
+		{"sequenceNumber > 'inf'", false},
+		{"sequenceNumber = '42'", true},
+		{"userProperties.version = '0123'", false},
+		{"userProperties.version = 123", true},
+		{"userProperties.amount > 100", true},
+		{"userProperties.amount > '100'", true},
+		{"userProperties.amount < '2'", true},
+		{"userProperties.retries >= 2", true},
+		{"deliveryCount < 3", false},
+		{"data.code = '123'", false},
+		{"data.code = 123", true},
+		{"data.amount > 100 AND data.amount < 121", true},
+		{"data.customer.active = true", true},
+		{"data.tags CONTAINS 'vip'", true},
+		{"data.tags CONTAINS 'old'", false},
+		{"userProperties.tenant IN ('acme', 'other')", true},
+		{"userProperties.tenant LIKE 'ac%'", true},
+		{"userProperties.tenant LIKE 'a_'", false},
+		{"userProperties.missing IS NULL", true},
+		{"NOT (userProperties.tenant = 'acme' OR label = 'x')", false},
+	}
+
+	for _, test := range tests {
+		expression, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.expression, err)
+			continue
+		}
+		if matched := expression.Match(testMessage()); matched != test.expected {
+			t.Errorf("Match(%q) = %v, expected %v", test.expression, matched, test.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	expressions := []string{
+		"label =",
+		"label = 'unterminated",
+		"(label = 'a'",
+		"label = 'a' label",
+		"AND label = 'a'",
+	}
+
+	for _, expression := range expressions {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("Parse(%q) should fail", expression)
+		}
+	}
+}
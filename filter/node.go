@@ -0,0 +1,297 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type node interface {
+	evaluate(doc document) bool
+}
+
+type operand interface {
+	resolve(doc document) (interface{}, bool)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l literal) resolve(doc document) (interface{}, bool) {
+	return l.value, l.value != nil
+}
+
+type field struct {
+	name string
+	path []string
+}
+
+func (f field) resolve(doc document) (interface{}, bool) {
+	return doc.lookup(f.path)
+}
+
+type andNode struct {
+	left  node
+	right node
+}
+
+func (n *andNode) evaluate(doc document) bool {
+	return n.left.evaluate(doc) && n.right.evaluate(doc)
+}
+
+type orNode struct {
+	left  node
+	right node
+}
+
+func (n *orNode) evaluate(doc document) bool {
+	return n.left.evaluate(doc) || n.right.evaluate(doc)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) evaluate(doc document) bool {
+	return !n.operand.evaluate(doc)
+}
+
+type existsNode struct {
+	operand operand
+}
+
+func (n *existsNode) evaluate(doc document) bool {
+	_, found := n.operand.resolve(doc)
+	return found
+}
+
+type inNode struct {
+	operand operand
+	values  []operand
+}
+
+func (n *inNode) evaluate(doc document) bool {
+	value, found := n.operand.resolve(doc)
+	if !found {
+		return false
+	}
+
+	for _, candidate := range n.values {
+		other, found := candidate.resolve(doc)
+		if found && compare(value, other) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+type comparisonNode struct {
+	left     operand
+	operator string
+	right    operand
+}
+
+func (n *comparisonNode) evaluate(doc document) bool {
+	left, leftFound := n.left.resolve(doc)
+	right, rightFound := n.right.resolve(doc)
+
+	// Comparing against null only makes sense for equality, anything else behaves like sql and is false
+	if !leftFound || !rightFound {
+		switch n.operator {
+		case "=":
+			return !leftFound && !rightFound
+		case "!=":
+			return leftFound != rightFound
+		}
+		return false
+	}
+
+	switch n.operator {
+	case "=":
+		return compare(left, right) == 0
+	case "!=":
+		return compare(left, right) != 0
+	case ">":
+		return compare(left, right) > 0
+	case ">=":
+		return compare(left, right) >= 0
+	case "<":
+		return compare(left, right) < 0
+	case "<=":
+		return compare(left, right) <= 0
+	case "LIKE":
+		return like(toString(left), toString(right))
+	case "CONTAINS":
+		return contains(left, right)
+	}
+
+	return false
+}
+
+// compare returns -1, 0 or 1 comparing both values, numbers, booleans and dates are compared by value
+// and everything else falls back to a string comparison. Values are only compared as numbers when one of them
+// is a number, so two strings like '1.10' and '1.1' are different
+func compare(left interface{}, right interface{}) int {
+	if isNumber(left) || isNumber(right) {
+		l, leftOk := toNumber(left)
+		r, rightOk := toNumber(right)
+		if leftOk && rightOk {
+			switch {
+			case l < r:
+				return -1
+			case l > r:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if l, ok := left.(bool); ok {
+		if r, ok := toBool(right); ok {
+			if l == r {
+				return 0
+			}
+			if !l {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	if l, ok := toTime(left); ok {
+		if r, ok := toTime(right); ok {
+			switch {
+			case l.Before(r):
+				return -1
+			case l.After(r):
+				return 1
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(toString(left), toString(right))
+}
+
+func contains(container interface{}, value interface{}) bool {
+	switch typed := container.(type) {
+	case []interface{}:
+		for _, item := range typed {
+			if compare(item, value) == 0 {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		_, found := typed[toString(value)]
+		return found
+	}
+
+	return strings.Contains(toString(container), toString(value))
+}
+
+func like(value string, pattern string) bool {
+	var expression strings.Builder
+	expression.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expression.WriteString(".*")
+		case '_':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expression.WriteString("$")
+
+	matched, err := regexp.MatchString(expression.String(), value)
+	return err == nil && matched
+}
+
+// isNumber returns true if the value is a number and not a string that looks like one
+func isNumber(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// toNumber converts numbers and strings with a decimal number to a float, NaN and infinite values are not numbers
+func toNumber(value interface{}) (float64, bool) {
+	var number float64
+	switch typed := value.(type) {
+	case float64:
+		number = typed
+	case float32:
+		number = float64(typed)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err != nil {
+			return 0, false
+		}
+		number = parsed
+	case bool, nil, time.Time:
+		return 0, false
+	default:
+		reflected := reflect.ValueOf(value)
+		switch reflected.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			number = float64(reflected.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			number = float64(reflected.Uint())
+		default:
+			return 0, false
+		}
+	}
+
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+
+	return number, true
+}
+
+func toBool(value interface{}) (bool, bool) {
+	switch typed := value.(type) {
+	case bool:
+		return typed, true
+	case string:
+		result, err := strconv.ParseBool(typed)
+		return result, err == nil
+	}
+	return false, false
+}
+
+func toTime(value interface{}) (time.Time, bool) {
+	switch typed := value.(type) {
+	case time.Time:
+		return typed, true
+	case string:
+		result, err := time.Parse(time.RFC3339, typed)
+		return result, err == nil
+	}
+	return time.Time{}, false
+}
+
+func toString(value interface{}) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case time.Time:
+		return typed.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
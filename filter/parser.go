@@ -0,0 +1,291 @@
+package filter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	tokens  []token
+	current int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.current]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.current]
+	if t.kind != tokenEOF {
+		p.current++
+	}
+	return t
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.peek().keyword() == keyword {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.acceptKeyword("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if p.peek().kind == tokenOpenParenthesis {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenCloseParenthesis {
+			return nil, p.unexpected("closing parenthesis")
+		}
+		p.next()
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenOperator {
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &comparisonNode{left: left, operator: normalizeOperator(t.value), right: right}, nil
+	}
+
+	negate := false
+	switch t.keyword() {
+	case "IS":
+		p.next()
+		if p.acceptKeyword("NOT") {
+			negate = true
+		}
+		if !p.acceptKeyword("NULL") {
+			return nil, p.unexpected("NULL")
+		}
+		var result node = &existsNode{operand: left}
+		if !negate {
+			result = &notNode{operand: result}
+		}
+		return result, nil
+	case "EXISTS":
+		p.next()
+		return &existsNode{operand: left}, nil
+	case "NOT":
+		p.next()
+		negate = true
+	}
+
+	var result node
+	switch p.peek().keyword() {
+	case "LIKE":
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		result = &comparisonNode{left: left, operator: "LIKE", right: right}
+	case "CONTAINS":
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		result = &comparisonNode{left: left, operator: "CONTAINS", right: right}
+	case "IN":
+		p.next()
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		result = &inNode{operand: left, values: values}
+	default:
+		if negate {
+			return nil, p.unexpected("LIKE, CONTAINS or IN")
+		}
+		return nil, p.unexpected("comparison operator")
+	}
+
+	if negate {
+		result = &notNode{operand: result}
+	}
+
+	return result, nil
+}
+
+func (p *parser) parseList() ([]operand, error) {
+	if p.peek().kind != tokenOpenParenthesis {
+		return nil, p.unexpected("opening parenthesis")
+	}
+	p.next()
+
+	values := make([]operand, 0)
+	for {
+		value, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.next()
+		if t.kind == tokenCloseParenthesis {
+			break
+		}
+		if t.kind != tokenComma {
+			p.current--
+			return nil, p.unexpected("comma or closing parenthesis")
+		}
+	}
+
+	return values, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenString:
+		p.next()
+		return literal{value: t.value}, nil
+	case tokenNumber:
+		p.next()
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, errors.New("invalid number " + t.value + " at position " + strconv.Itoa(t.position))
+		}
+		return literal{value: number}, nil
+	case tokenIdentifier:
+		switch t.keyword() {
+		case "TRUE":
+			p.next()
+			return literal{value: true}, nil
+		case "FALSE":
+			p.next()
+			return literal{value: false}, nil
+		case "NULL":
+			p.next()
+			return literal{value: nil}, nil
+		case "AND", "OR", "NOT", "LIKE", "CONTAINS", "IN", "IS", "EXISTS":
+			return nil, p.unexpected("field or value")
+		}
+		p.next()
+		path, err := parsePath(t.value)
+		if err != nil {
+			return nil, errors.New(err.Error() + " at position " + strconv.Itoa(t.position))
+		}
+		return field{name: t.value, path: path}, nil
+	}
+
+	return nil, p.unexpected("field or value")
+}
+
+func (p *parser) unexpected(expected string) error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return errors.New("unexpected end of expression, expected " + expected)
+	}
+
+	return errors.New("unexpected " + t.value + " at position " + strconv.Itoa(t.position) + ", expected " + expected)
+}
+
+func normalizeOperator(operator string) string {
+	switch operator {
+	case "==":
+		return "="
+	case "<>":
+		return "!="
+	}
+	return operator
+}
+
+// parsePath splits a field reference like data.items[0].sku into its segments
+func parsePath(value string) ([]string, error) {
+	segments := make([]string, 0)
+	for _, part := range strings.Split(value, ".") {
+		if part == "" {
+			return nil, errors.New("invalid field " + value)
+		}
+
+		for {
+			open := strings.Index(part, "[")
+			if open < 0 {
+				segments = append(segments, part)
+				break
+			}
+			closing := strings.Index(part, "]")
+			if closing < open {
+				return nil, errors.New("invalid index in field " + value)
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			index := part[open+1 : closing]
+			if _, err := strconv.Atoi(index); err != nil {
+				return nil, errors.New("invalid index in field " + value)
+			}
+			segments = append(segments, "["+index+"]")
+			part = part[closing+1:]
+			if part == "" {
+				break
+			}
+		}
+	}
+
+	return segments, nil
+}
@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Defaults of the routes
+const (
+	DefaultRequestIDHeader = "X-Request-Id"
+	// DefaultMaxBodySize is the biggest message of the standard tier
+	DefaultMaxBodySize = 256 * 1024
+)
+
+// Config Routes of the gateway loaded from a yaml file, for example:
+//
+//	routes:
+//	  - path: /hooks/github/{event}
+//	    topic: github-events
+//	    label: GitHub.Event
+//	    headers:
+//	      - X-GitHub-Event
+//	      - X-GitHub-Delivery
+//	    properties:
+//	      source: github
+//	  - path: /hooks/orders
+//	    methods: [POST, PUT]
+//	    queue: orders
+type Config struct {
+	Routes []Route `yaml:"routes"`
+}
+
+// Route Http route turned into messages of a queue or topic, the listed headers and the path variables become
+// user properties of the message and the request id header becomes its correlation id
+type Route struct {
+	Name            string            `yaml:"name"`
+	Path            string            `yaml:"path"`
+	Methods         []string          `yaml:"methods"`
+	Queue           string            `yaml:"queue"`
+	Topic           string            `yaml:"topic"`
+	Label           string            `yaml:"label"`
+	ContentType     string            `yaml:"contentType"`
+	Headers         []string          `yaml:"headers"`
+	Properties      map[string]string `yaml:"properties"`
+	RequestIDHeader string            `yaml:"requestIdHeader"`
+	MaxBodySize     int64             `yaml:"maxBodySize"`
+}
+
+// LoadConfig Reads and validates the routes from a yaml file
+func LoadConfig(filePath string) (*Config, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate Checks the routes setting the defaults
+func (c *Config) Validate() error {
+	if len(c.Routes) == 0 {
+		return errors.New("there are no routes in the gateway")
+	}
+
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		if !strings.HasPrefix(route.Path, "/") {
+			return fmt.Errorf("route %v needs a path starting with /", i+1)
+		}
+		if route.Name == "" {
+			route.Name = route.Path
+		}
+		if (route.Queue == "") == (route.Topic == "") {
+			return fmt.Errorf("route %v needs either a queue or a topic", route.Name)
+		}
+		if len(route.Methods) == 0 {
+			route.Methods = []string{http.MethodPost}
+		}
+		for j, method := range route.Methods {
+			route.Methods[j] = strings.ToUpper(method)
+		}
+		if route.RequestIDHeader == "" {
+			route.RequestIDHeader = DefaultRequestIDHeader
+		}
+		if route.MaxBodySize <= 0 {
+			route.MaxBodySize = DefaultMaxBodySize
+		}
+	}
+
+	return nil
+}
+
+// Target Gets the description of the queue or topic of the route
+func (r *Route) Target() string {
+	if r.Queue != "" {
+		return "queue " + r.Queue
+	}
+
+	return "topic " + r.Topic
+}
@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/Azure/azure-amqp-common-go/v3/uuid"
+	servicebus "github.com/Azure/azure-service-bus-go"
+)
+
+// ErrBodyTooLarge is returned when the body of the request is bigger than the maximum of the route
+var ErrBodyTooLarge = errors.New("the body of the request is too large")
+
+// NewMessage Turns a request into the message of the route, vars are the path variables of the request. A
+// correlation id is generated when the request has no request id so every message can be traced
+func (r *Route) NewMessage(request *http.Request, vars map[string]string) (*servicebus.Message, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, request.Body, r.MaxBodySize))
+	if err != nil {
+		if int64(len(body)) >= r.MaxBodySize {
+			return nil, ErrBodyTooLarge
+		}
+		return nil, err
+	}
+
+	message := servicebus.NewMessage(body)
+	message.Label = r.Label
+	if message.Label == "" {
+		message.Label = r.Name
+	}
+
+	message.ContentType = r.ContentType
+	if message.ContentType == "" {
+		message.ContentType = request.Header.Get("Content-Type")
+	}
+	if message.ContentType == "" {
+		message.ContentType = "application/json"
+	}
+
+	message.CorrelationID = request.Header.Get(r.RequestIDHeader)
+	if message.CorrelationID == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, err
+		}
+		message.CorrelationID = id.String()
+	}
+
+	properties := make(map[string]interface{})
+	for key, value := range r.Properties {
+		properties[key] = value
+	}
+	for key, value := range vars {
+		properties[key] = value
+	}
+	for _, header := range r.Headers {
+		if value := request.Header.Get(header); value != "" {
+			properties[header] = value
+		}
+	}
+	if len(properties) > 0 {
+		message.UserProperties = properties
+	}
+
+	return message, nil
+}
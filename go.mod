@@ -3,19 +3,22 @@ module github.com/cjlapao/servicebuscli-go
 go 1.16
 
 require (
+	github.com/Azure/azure-amqp-common-go/v3 v3.0.1
 	github.com/Azure/azure-service-bus-go v0.10.7
 	github.com/cjlapao/common-go v0.0.9
 	github.com/fatih/color v1.10.0
 	github.com/golang/protobuf v1.4.2 // indirect
 	github.com/gomarkdown/markdown v0.0.0-20210408062403-ad838ccf8cdd
 	github.com/gorilla/mux v1.8.0
-	github.com/gorilla/websocket v1.4.2 // indirect
+	github.com/gorilla/websocket v1.4.2
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.1 // indirect
 	github.com/stretchr/objx v0.1.1 // indirect
 	golang.org/x/sys v0.0.0-20210420072515-93ed5bcd2bfe // indirect
+	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	google.golang.org/protobuf v1.25.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
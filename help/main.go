@@ -21,6 +21,21 @@ func PrintMainCommandHelper() {
 	logger.Info("  api           Starts Service Bus Client in Api Mode")
 	logger.Info("  topic         Service bus topic command")
 	logger.Info("  queue         Service bus queue command")
+	logger.Info("  load          Sends load to a queue or topic and reports the throughput and latency")
+	logger.Info("  probe         Sends probe messages and reports their end to end delivery latency")
+	logger.Info("  exporter      Exposes the message counts of every entity as prometheus metrics")
+	logger.Info("  watch         Watches the message counts and notifies webhooks when alert rules fire")
+	logger.Info("  top           Live dashboard with the message counts of every entity")
+	logger.Info("  shell         Interactive session to explore and work with the entities")
+	logger.Info("  bridge        Posts the messages of a queue or subscription to an http endpoint")
+	logger.Info("  sink          Writes the messages of a queue or subscription to a folder")
+	logger.Info("  source        Sends the files of a folder as messages to a queue or topic")
+	logger.Info("  move          Moves or copies messages from a queue or subscription to a queue or topic")
+	logger.Info("  expect        Waits for a message matching an expression, exiting with 1 if it does not arrive")
+	logger.Info("  message       Completes, dead letters, moves, defers or receives messages by sequence number or id")
+	logger.Info("")
+	logger.Info("Global Flags:")
+	logger.Info("  --schemas     Schema registry folder used to validate the messages, defaults to SERVICEBUS_SCHEMA_REGISTRY")
 }
 
 // PrintMissingServiceBusConnectionHelper Prints specific Help
@@ -53,6 +68,7 @@ func PrintTopicMainCommandHelper() {
 	logger.Info("  create-subscription  Creates a Subscription on a specific Topic in a Namespace")
 	logger.Info("  delete-subscription  Deletes a Subscription from a specific Topic in a Namespace")
 	logger.Info("  subscribe            Subscribe to a Subscription and prints the message")
+	logger.Info("  search               Searches the whole backlog of a Subscription for the messages matching an expression")
 }
 
 // PrintTopicListSubscriptionsCommandHelper Prints specific Help
@@ -171,7 +187,30 @@ func PrintTopicSubscribeCommandHelper() {
 	logger.Info("  %v              connects to a wiretap in the topic, if this subscription", "--wiretap")
 	logger.Info("                         does not exist it will be created and deleted on exit")
 	logger.Info("                         this will also override the %v flag", "--subscription")
-	logger.Info("  %v                 peeks into the subscription leaving the messages there", "--peek")
+	logger.Info("  %v                 browses the messages without locking or removing them, following", "--peek")
+	logger.Info("                         the new ones as they arrive, their delivery count never changes")
+	logger.Info("  %v                 with --peek only prints the messages arriving after it started", "--tail")
+	logger.Info("  %v=string     yaml file with the transformations applied before printing", "--transform")
+	logger.Info("  %v=string        how the messages are printed, raw, json, compact or template", "--format")
+	logger.Info("                         defaults to raw, json and compact print the envelope as colored json")
+	logger.Info("  %v=string        comma separated envelope fields printed by json and compact", "--fields")
+	logger.Info("                         id,label,correlationId,contentType,sequenceNumber,deliveryCount,")
+	logger.Info("                         enqueuedTime,userProperties,body")
+	logger.Info("  %v=string       prints only the value in a path of the json body, like .customer.id", "--extract")
+	logger.Info("  %v=string      file with a go template for the template format, it gets the envelope", "--template")
+	logger.Info("  %v=string        appends the messages to a ndjson file the source command can send again", "--output")
+	logger.Info("  %v=number  stops after receiving the number of messages, exits with 1 if", "--max-messages")
+	logger.Info("                         they were not all received")
+	logger.Info("  %v=duration     stops after the time, like 30s or 5m", "--timeout")
+	logger.Info("  %v=duration stops when no message is received for the time", "--idle-timeout")
+	logger.Info("  %v=number      messages the receiver asks for ahead of the handlers", "--prefetch")
+	logger.Info("  %v=number   messages handled at the same time, defaults to 1", "--concurrency")
+	logger.Info("  %v=string  peeklock or receiveanddelete, defaults to peeklock, receive and", "--receive-mode")
+	logger.Info("                         delete does not need completions but loses the prefetched messages")
+	logger.Info("                         that were not printed when the command stops")
+	logger.Info("  %v=string        how the messages are settled once printed, complete, abandon,", "--settle")
+	logger.Info("                         defer, deadletter or interactive, defaults to complete, interactive")
+	logger.Info("                         asks for every message keeping it locked until it is answered")
 	logger.Info("")
 	logger.Info("example:")
 	os := runtime.GOOS
@@ -191,6 +230,31 @@ func PrintTopicSubscribeCommandHelper() {
 	}
 }
 
+// PrintTopicSearchCommandHelper Prints specific Help
+func PrintTopicSearchCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus topic search [options]")
+	logger.Info("")
+	logger.Info("Peeks through every message leaving them in the subscription, the matches are printed as json")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  %v=string         Name of the topic (mandatory)", "--topic")
+	logger.Info("  %v=string  Name of the subscription to search (mandatory)", "--subscription")
+	logger.Info("  %v=string         Filter expression with system properties, userProperties.[name] and data.[json path]", "--where")
+	logger.Info("                         all the messages match if it is not set")
+	logger.Info("  %v          Searches the dead letter queue instead of the active messages", "--dead-letter")
+	logger.Info("  %v=number           Stops the search once this many messages match", "--max")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v topic search %v", color.HiYellowString("servicebus"), color.HiBlackString("--topic=example.topic --subscription=example.subscription --where=\"userProperties.tenant = 'acme' AND data.amount > 100\""))
+	case "windows":
+		color.White("%v topic search %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--topic=example.topic --subscription=example.subscription --where=\"userProperties.tenant = 'acme' AND data.amount > 100\""))
+	}
+}
+
 // PrintTopicSendCommandHelper Prints specific Help
 func PrintTopicSendCommandHelper() {
 	logger.Info("Usage:")
@@ -205,6 +269,9 @@ func PrintTopicSendCommandHelper() {
 	logger.Info("                        This option can be repeated to add more than one property")
 	logger.Info("                        the format will be [key]:[value]")
 	logger.Info("                        example: X-Sender:example")
+	logger.Info("  --template            Renders the message as a message template before sending it")
+	logger.Info("  --data-file string    CSV or NDJSON file with the rows for the message template")
+	logger.Info("                        a message is sent for every row of the file")
 	logger.Info("")
 	logger.Info("example:")
 	os := runtime.GOOS
@@ -227,6 +294,7 @@ func PrintQueueMainCommandHelper() {
 	logger.Info("  delete               Deletes a Queues in a Namespace")
 	logger.Info("  send                 Sends a Json Message to a specific Queue in a Namespace")
 	logger.Info("  subscribe            Subscribe to a Queue and prints the messages")
+	logger.Info("  search               Searches the whole backlog of a Queue for the messages matching an expression")
 }
 
 // PrintQueueDeleteCommandHelper Prints specific Help
@@ -285,6 +353,9 @@ func PrintQueueSendCommandHelper() {
 	logger.Info("                        This option can be repeated to add more than one property")
 	logger.Info("                        the format will be [key]:[value]")
 	logger.Info("                        example: X-Sender:example")
+	logger.Info("  --template            Renders the message as a message template before sending it")
+	logger.Info("  --data-file string    CSV or NDJSON file with the rows for the message template")
+	logger.Info("                        a message is sent for every row of the file")
 	logger.Info("")
 	logger.Info("example:")
 	os := runtime.GOOS
@@ -306,7 +377,30 @@ func PrintQueueSubscribeCommandHelper() {
 	logger.Info("Available Options:")
 	logger.Info("  %v=string         Name of the queue to listen to (mandatory)", "--queue")
 	logger.Info("                         this flag can be repeated to listen to several queues")
-	logger.Info("  %v                 peeks into the subscription leaving the messages there", "--peek")
+	logger.Info("  %v                 browses the messages without locking or removing them, following", "--peek")
+	logger.Info("                         the new ones as they arrive, their delivery count never changes")
+	logger.Info("  %v                 with --peek only prints the messages arriving after it started", "--tail")
+	logger.Info("  %v=string     yaml file with the transformations applied before printing", "--transform")
+	logger.Info("  %v=string        how the messages are printed, raw, json, compact or template", "--format")
+	logger.Info("                         defaults to raw, json and compact print the envelope as colored json")
+	logger.Info("  %v=string        comma separated envelope fields printed by json and compact", "--fields")
+	logger.Info("                         id,label,correlationId,contentType,sequenceNumber,deliveryCount,")
+	logger.Info("                         enqueuedTime,userProperties,body")
+	logger.Info("  %v=string       prints only the value in a path of the json body, like .customer.id", "--extract")
+	logger.Info("  %v=string      file with a go template for the template format, it gets the envelope", "--template")
+	logger.Info("  %v=string        appends the messages to a ndjson file the source command can send again", "--output")
+	logger.Info("  %v=number  stops after receiving the number of messages, exits with 1 if", "--max-messages")
+	logger.Info("                         they were not all received")
+	logger.Info("  %v=duration     stops after the time, like 30s or 5m", "--timeout")
+	logger.Info("  %v=duration stops when no message is received for the time", "--idle-timeout")
+	logger.Info("  %v=number      messages the receiver asks for ahead of the handlers", "--prefetch")
+	logger.Info("  %v=number   messages handled at the same time, defaults to 1", "--concurrency")
+	logger.Info("  %v=string  peeklock or receiveanddelete, defaults to peeklock, receive and", "--receive-mode")
+	logger.Info("                         delete does not need completions but loses the prefetched messages")
+	logger.Info("                         that were not printed when the command stops")
+	logger.Info("  %v=string        how the messages are settled once printed, complete, abandon,", "--settle")
+	logger.Info("                         defer, deadletter or interactive, defaults to complete, interactive")
+	logger.Info("                         asks for every message keeping it locked until it is answered")
 	logger.Info("")
 	logger.Info("example:")
 	os := runtime.GOOS
@@ -325,3 +419,344 @@ func PrintQueueSubscribeCommandHelper() {
 		color.White("%v queue subscribe %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--queue=example.queue --topic=example.queue2"))
 	}
 }
+
+// PrintQueueSearchCommandHelper Prints specific Help
+func PrintQueueSearchCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus queue search [options]")
+	logger.Info("")
+	logger.Info("Peeks through every message leaving them in the queue, the matches are printed as json")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  %v=string         Name of the queue to search (mandatory)", "--queue")
+	logger.Info("  %v=string         Filter expression with system properties, userProperties.[name] and data.[json path]", "--where")
+	logger.Info("                         all the messages match if it is not set")
+	logger.Info("  %v          Searches the dead letter queue instead of the active messages", "--dead-letter")
+	logger.Info("  %v=number           Stops the search once this many messages match", "--max")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v queue search %v", color.HiYellowString("servicebus"), color.HiBlackString("--queue=example.queue --where=\"userProperties.tenant = 'acme' AND data.amount > 100\""))
+	case "windows":
+		color.White("%v queue search %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--queue=example.queue --where=\"userProperties.tenant = 'acme' AND data.amount > 100\""))
+	}
+}
+
+// PrintLoadCommandHelper Prints specific Help
+func PrintLoadCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus load [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --queue        string     Name of the queue where to send the load")
+	logger.Info("  --topic        string     Name of the topic where to send the load")
+	logger.Info("  --rate         number     Messages per second to send, sends as fast as possible if not set")
+	logger.Info("  --duration     duration   How long the load runs for, example: 90s or 5m")
+	logger.Info("  --count        integer    Total of messages to send, the load stops at whichever")
+	logger.Info("                            of the duration or the count comes first")
+	logger.Info("  --concurrency  integer    Number of senders, each with its own connection, defaults to 1")
+	logger.Info("  --ramp-up      duration   Time to go from zero to the rate at the start of the load")
+	logger.Info("  --ramp-down    duration   Time to go from the rate to zero at the end of the duration")
+	logger.Info("  --file         string     File path for the message to be sent, this will include all of the options")
+	logger.Info("  --body         json       Message body in json (please escape the json correctly as this is validated)")
+	logger.Info("  --label        string     Message Label")
+	logger.Info("  --property     key:value  Add a User property to the message")
+	logger.Info("                            This option can be repeated to add more than one property")
+	logger.Info("  --data-file    string     CSV or NDJSON file with the rows for the message template")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v load %v", color.HiYellowString("servicebus"), color.HiBlackString("--queue=example.queue --body='{\\\"example\\\":\\\"document\\\"}' --rate=200 --duration=5m --ramp-up=30s --ramp-down=30s --concurrency=4"))
+	case "windows":
+		color.White("%v load %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--queue=example.queue --body='{\\\"example\\\":\\\"document\\\"}' --rate=200 --duration=5m --ramp-up=30s --ramp-down=30s --concurrency=4"))
+	}
+}
+
+// PrintProbeCommandHelper Prints specific Help
+func PrintProbeCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus probe [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --topic          string    Name of the topic where to send the probe messages")
+	logger.Info("  --queue          string    Name of the queue where to send the probe messages")
+	logger.Info("  --subscription   string    Name of the subscription where to receive the probe messages from")
+	logger.Info("  --receive-topic  string    Receives from the subscription on this topic instead, for forwarding chains")
+	logger.Info("  --receive-queue  string    Receives from this queue instead, for forwarding chains")
+	logger.Info("  --interval       duration  Time between probe messages, defaults to 1s")
+	logger.Info("  --timeout        duration  Time after which a message is considered lost, defaults to 30s")
+	logger.Info("  --slo            duration  Counts the messages delivered slower than it, example: 500ms")
+	logger.Info("  --count          integer   Number of probe messages to send, runs until stopped if not set")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v probe %v", color.HiYellowString("servicebus"), color.HiBlackString("--topic=example.topic --subscription=probe --receive-queue=example.queue --slo=500ms"))
+	case "windows":
+		color.White("%v probe %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--topic=example.topic --subscription=probe --receive-queue=example.queue --slo=500ms"))
+	}
+}
+
+// PrintExporterCommandHelper Prints specific Help
+func PrintExporterCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus exporter [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --port      integer   Port where the metrics are served on /metrics, defaults to 9090")
+	logger.Info("  --interval  duration  Time between polls of the service bus, defaults to 30s")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v exporter %v", color.HiYellowString("servicebus"), color.HiBlackString("--port=9090 --interval=1m"))
+	case "windows":
+		color.White("%v exporter %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--port=9090 --interval=1m"))
+	}
+}
+
+// PrintWatchCommandHelper Prints specific Help
+func PrintWatchCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus watch [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --rules  string  Yaml file with the alert rules and the webhooks to notify")
+	logger.Info("  --once           Checks the rules once and exits, a rule with a duration will not fire")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v watch %v", color.HiYellowString("servicebus"), color.HiBlackString("--rules=alerts.yaml"))
+	case "windows":
+		color.White("%v watch %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--rules=alerts.yaml"))
+	}
+}
+
+// PrintTopCommandHelper Prints specific Help
+func PrintTopCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus top [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --interval  duration  Time between refreshes of the counts, defaults to 5s")
+	logger.Info("")
+	logger.Info("Press ? in the dashboard to see the keys")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v top %v", color.HiYellowString("servicebus"), color.HiBlackString("--interval=10s"))
+	case "windows":
+		color.White("%v top %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--interval=10s"))
+	}
+}
+
+// PrintShellCommandHelper Prints specific Help
+func PrintShellCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus shell")
+	logger.Info("")
+	logger.Info("Available Commands:")
+	logger.Info("  use <queue|topic|subscription> <name>  Changes the entity the commands run against")
+	logger.Info("  ls                                     Lists the entities or the counts of the entity in use")
+	logger.Info("  info                                   Shows the details of the entity in use")
+	logger.Info("  peek [count] [dead-letter]             Peeks the messages of the queue or subscription in use")
+	logger.Info("  send <@file.json|json> [label]         Sends a message to the queue or topic in use")
+	logger.Info("  rules                                  Lists the rules of the subscription in use")
+	logger.Info("  purge [dead-letter]                    Removes all the messages of the queue or subscription in use")
+	logger.Info("  resubmit [max] [--transform=file.yaml] Sends the dead letters back to the queue or subscription in use")
+	logger.Info("  help [command]                         Shows the commands, exit or ctrl+d leaves the shell")
+	logger.Info("")
+	logger.Info("Commands are read from the input when it is not a terminal")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v shell", color.HiYellowString("servicebus"))
+		color.White("%v shell %v", color.HiYellowString("servicebus"), color.HiBlackString("< commands.txt"))
+	case "windows":
+		color.White("%v shell", color.HiYellowString("servicebus.exe"))
+		color.White("%v shell %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("< commands.txt"))
+	}
+}
+
+// PrintBridgeCommandHelper Prints specific Help
+func PrintBridgeCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus bridge [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --from         string    Queue or subscription to receive from, queue:name or topic:name/sub:name")
+	logger.Info("  --to-http      string    Url the messages are posted to")
+	logger.Info("  --header       key=value Header added to the requests, can be repeated")
+	logger.Info("  --body         string    Body of the requests, data posts the message body and envelope")
+	logger.Info("                           posts the message properties and body as json, defaults to data")
+	logger.Info("  --template     string    File with a go template for the body, it gets the envelope")
+	logger.Info("  --retries      number    Times a failed request is retried, defaults to 3")
+	logger.Info("  --backoff      duration  Wait before the first retry, doubled on every retry, defaults to 1s")
+	logger.Info("  --timeout      duration  Time the endpoint has to answer, defaults to 10s")
+	logger.Info("  --dead-letter            Dead letters the messages that could not be delivered instead of abandoning them")
+	logger.Info("  --transform    string    Yaml file with the transformations applied before posting the messages")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v bridge %v", color.HiYellowString("servicebus"), color.HiBlackString("--from=topic:orders/sub:audit --to-http=https://partner.example.com/orders --header=\"Authorization=Bearer token\""))
+	case "windows":
+		color.White("%v bridge %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--from=topic:orders/sub:audit --to-http=https://partner.example.com/orders --header=\"Authorization=Bearer token\""))
+	}
+}
+
+// PrintSinkCommandHelper Prints specific Help
+func PrintSinkCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus sink [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --from      string    Queue or subscription to receive from, queue:name or topic:name/sub:name")
+	logger.Info("  --to-dir    string    Folder the messages are written to")
+	logger.Info("  --format    string    files writes every message to its own json file and ndjson writes them")
+	logger.Info("                        as lines of rolling segments, defaults to files")
+	logger.Info("  --max-size  number    Size in bytes a segment can get to before a new one is started, defaults to 64MB")
+	logger.Info("  --max-age   duration  Time a segment is written to before a new one is started, defaults to 1h")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v sink %v", color.HiYellowString("servicebus"), color.HiBlackString("--from=queue:orders --to-dir=./out --format=ndjson --max-age=15m"))
+	case "windows":
+		color.White("%v sink %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--from=queue:orders --to-dir=./out --format=ndjson --max-age=15m"))
+	}
+}
+
+// PrintSourceCommandHelper Prints specific Help
+func PrintSourceCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus source [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --from-dir     string    Folder watched for new files")
+	logger.Info("  --to           string    Queue or topic the files are sent to, queue:name or topic:name")
+	logger.Info("  --format       string    body sends the content of every file as a message, files and ndjson")
+	logger.Info("                           send the envelopes written by the sink, defaults to body")
+	logger.Info("  --label        string    Label of the messages, defaults to the file name")
+	logger.Info("  --contentType  string    Content type of the messages, defaults to the one of the file extension")
+	logger.Info("  --done-dir     string    Folder the sent files are moved to, defaults to the done folder inside --from-dir")
+	logger.Info("  --failed-dir   string    Folder the failed files are moved to, defaults to the failed folder inside --from-dir")
+	logger.Info("  --interval     duration  Time between looking for new files, defaults to 5s")
+	logger.Info("  --transform    string    Yaml file with the transformations applied before sending the messages")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v source %v", color.HiYellowString("servicebus"), color.HiBlackString("--from-dir=./in --to=topic:orders"))
+	case "windows":
+		color.White("%v source %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--from-dir=./in --to=topic:orders"))
+	}
+}
+
+// PrintMessageCommandHelper Prints specific Help
+func PrintMessageCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus message [action] [options]")
+	logger.Info("")
+	logger.Info("Available Actions:")
+	logger.Info("  complete      Removes the messages")
+	logger.Info("  deadletter    Moves the messages to the dead letter queue with a reason")
+	logger.Info("  move          Sends the messages to another queue or topic and removes them once sent")
+	logger.Info("  defer         Sets the messages aside, they can only be received by their sequence number")
+	logger.Info("  receive       Receives deferred messages printing and removing them")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --from         string    Queue or subscription of the messages, queue:name or topic:name/sub:name")
+	logger.Info("  --seq          number    Sequence number of a message, can be repeated")
+	logger.Info("  --id           string    Id of a message, can be repeated")
+	logger.Info("  --dead-letter            Picks the messages from the dead letter queue")
+	logger.Info("  --reason       string    Dead letter reason, defaults to ManuallyDeadLettered")
+	logger.Info("  --description  string    Dead letter error description")
+	logger.Info("  --to           string    Queue or topic the messages are moved to, queue:name or topic:name")
+	logger.Info("  --keep-id                Moves the messages with their id instead of a new one")
+	logger.Info("  --lock-ahead             Locks the messages in front of the picked ones to reach them")
+	logger.Info("")
+	logger.Info("Picked messages with others in front fail unless --lock-ahead is set, then the messages in front")
+	logger.Info("are locked while looking for them and abandoned at the end, which increases their delivery count,")
+	logger.Info("only the first 1000 active messages can be picked")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v message deadletter %v", color.HiYellowString("servicebus"), color.HiBlackString("--from=queue:orders --seq=1042 --reason=Poison --description=\"invalid customer id\""))
+	case "windows":
+		color.White("%v message deadletter %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--from=queue:orders --seq=1042 --reason=Poison --description=\"invalid customer id\""))
+	}
+}
+
+// PrintMoveCommandHelper Prints specific Help
+func PrintMoveCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus move [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --from         string    Queue or subscription to take the messages from, queue:name or topic:name/sub:name")
+	logger.Info("  --to           string    Queue or topic the messages are sent to, queue:name or topic:name")
+	logger.Info("  --dead-letter            Takes the messages from the dead letter queue")
+	logger.Info("  --count        number    Maximum number of messages, defaults to all of them")
+	logger.Info("  --where        string    Filter expression, only the matching messages are moved")
+	logger.Info("  --copy                   Sends copies leaving the messages in the source")
+	logger.Info("  --transform    string    Yaml file with the transformations applied before sending the messages")
+	logger.Info("  --keep-id                Sends the messages with their id instead of a new one")
+	logger.Info("  --dry-run                Lists the messages that would be sent without sending them, with")
+	logger.Info("                           --transform it also prints their transformed body")
+	logger.Info("")
+	logger.Info("With --where and without --copy the messages that do not match are locked while moving and abandoned")
+	logger.Info("at the end, which increases their delivery count")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v move %v", color.HiYellowString("servicebus"), color.HiBlackString("--from=queue:orders --to=queue:orders-overflow --count=5000 --where=\"label = 'Order.Created'\""))
+	case "windows":
+		color.White("%v move %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--from=queue:orders --to=queue:orders-overflow --count=5000 --where=\"label = 'Order.Created'\""))
+	}
+}
+
+// PrintExpectCommandHelper Prints specific Help
+func PrintExpectCommandHelper() {
+	logger.Info("Usage:")
+	logger.Info("  servicebus expect [options]")
+	logger.Info("")
+	logger.Info("Available Options:")
+	logger.Info("  --from          string    Queue or subscription to wait in, queue:name or topic:name/sub:name")
+	logger.Info("  --queue         string    Queue to wait in, instead of --from")
+	logger.Info("  --subscription  string    Subscription to wait in written as topic/subscription, instead of --from")
+	logger.Info("  --where         string    Filter expression the message needs to match, any message matches without it")
+	logger.Info("  --within        duration  Time to wait for the message, defaults to 30s")
+	logger.Info("  --consume                 Receives and completes the matching message instead of peeking it")
+	logger.Info("  --dead-letter             Waits for the message in the dead letter queue")
+	logger.Info("")
+	logger.Info("The matching message is printed as json and the command exits with 1 when it does not arrive in time")
+	logger.Info("")
+	logger.Info("example:")
+	os := runtime.GOOS
+	switch strings.ToLower(os) {
+	case "linux":
+		color.White("%v expect %v", color.HiYellowString("servicebus"), color.HiBlackString("--subscription=orders/audit --where=\"label = 'OrderCreated' AND data.orderId = '123'\" --within=30s"))
+	case "windows":
+		color.White("%v expect %v", color.HiYellowString("servicebus.exe"), color.HiBlackString("--subscription=orders/audit --where=\"label = 'OrderCreated' AND data.orderId = '123'\" --within=30s"))
+	}
+}
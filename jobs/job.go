@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status Enum
+type Status int
+
+// Status Enum definition
+const (
+	StatusPending Status = iota
+	StatusRunning
+	StatusCompleted
+	StatusFailed
+	StatusCancelled
+)
+
+// String Gets the Status Enum string representation
+func (s Status) String() string {
+	return statusToString[s]
+}
+
+var statusToString = map[Status]string{
+	StatusPending:   "Pending",
+	StatusRunning:   "Running",
+	StatusCompleted: "Completed",
+	StatusFailed:    "Failed",
+	StatusCancelled: "Cancelled",
+}
+
+// IsFinished returns true if the job will not change anymore
+func (s Status) IsFinished() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
+// RunFunc Work executed by a job, it needs to stop when the context is cancelled and report its progress to the job
+type RunFunc func(ctx context.Context, job *Job) error
+
+// Job Long running operation executed in the background
+type Job struct {
+	ID     string
+	Type   string
+	Target string
+
+	mu         sync.RWMutex
+	status     Status
+	total      int
+	succeeded  int
+	failed     int
+	err        error
+	result     interface{}
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+	cancel     context.CancelFunc
+	done       chan struct{}
+}
+
+// Progress Point in time copy of the state of a job
+type Progress struct {
+	Status     Status
+	Total      int
+	Succeeded  int
+	Failed     int
+	Error      error
+	Result     interface{}
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Rate is the number of items processed per second
+	Rate float64
+	// ETA is the estimated time left, zero if it cannot be estimated
+	ETA time.Duration
+}
+
+// Add Adds to the number of items processed successfully and with errors, it matches servicebus.ProgressFunc
+func (j *Job) Add(succeeded int, failed int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.succeeded += succeeded
+	j.failed += failed
+}
+
+// SetTotal Sets the number of items the job is expected to process, zero if it is unknown
+func (j *Job) SetTotal(total int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.total = total
+}
+
+// SetResult Sets the outcome of the job, it is returned with the progress
+func (j *Job) SetResult(result interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.result = result
+}
+
+// Cancel Requests the job to stop, it will be marked as cancelled once the work returns
+func (j *Job) Cancel() {
+	j.cancel()
+}
+
+// Done Returns a channel that is closed when the job finishes
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Progress Gets the current progress of the job
+func (j *Job) Progress() Progress {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	result := Progress{
+		Status:     j.status,
+		Total:      j.total,
+		Succeeded:  j.succeeded,
+		Failed:     j.failed,
+		Error:      j.err,
+		Result:     j.result,
+		CreatedAt:  j.createdAt,
+		StartedAt:  j.startedAt,
+		FinishedAt: j.finishedAt,
+	}
+
+	if j.startedAt.IsZero() {
+		return result
+	}
+
+	end := time.Now()
+	if !j.finishedAt.IsZero() {
+		end = j.finishedAt
+	}
+
+	processed := j.succeeded + j.failed
+	elapsed := end.Sub(j.startedAt).Seconds()
+	if elapsed > 0 {
+		result.Rate = float64(processed) / elapsed
+	}
+
+	if j.status == StatusRunning && j.total > processed && result.Rate > 0 {
+		result.ETA = time.Duration(float64(j.total-processed) / result.Rate * float64(time.Second))
+	}
+
+	return result
+}
+
+func (j *Job) run(ctx context.Context, work RunFunc) {
+	defer close(j.done)
+	defer j.cancel()
+
+	j.mu.Lock()
+	j.status = StatusRunning
+	j.startedAt = time.Now()
+	j.mu.Unlock()
+
+	err := work(ctx, j)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.finishedAt = time.Now()
+	switch {
+	case ctx.Err() == context.Canceled:
+		j.status = StatusCancelled
+	case err != nil:
+		j.status = StatusFailed
+		j.err = err
+	default:
+		j.status = StatusCompleted
+	}
+}
@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/v3/uuid"
+)
+
+// DefaultRetention is how long finished jobs are kept so their results can still be queried
+const DefaultRetention = 24 * time.Hour
+
+// Manager Keeps track of the jobs running in the background
+type Manager struct {
+	Retention time.Duration
+
+	mu   sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewManager Creates a new job manager
+func NewManager() *Manager {
+	result := Manager{
+		Retention: DefaultRetention,
+		jobs:      make(map[string]*Job),
+	}
+
+	return &result
+}
+
+// Start Starts a job in the background, the work is cancelled if the job is cancelled
+func (m *Manager) Start(jobType string, target string, total int, work RunFunc) (*Job, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := Job{
+		ID:        id.String(),
+		Type:      jobType,
+		Target:    target,
+		status:    StatusPending,
+		total:     total,
+		createdAt: time.Now(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.removeExpired()
+	m.jobs[job.ID] = &job
+	m.mu.Unlock()
+
+	go job.run(ctx, work)
+
+	return &job, nil
+}
+
+// Get Gets a job by its id
+func (m *Manager) Get(id string) (*Job, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[id]
+	return job, exists
+}
+
+// List Gets all the jobs sorted by the time they were created
+func (m *Manager) List() []*Job {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Job, 0)
+	for _, job := range m.jobs {
+		result = append(result, job)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].createdAt.Before(result[j].createdAt)
+	})
+
+	return result
+}
+
+// Cancel Cancels a job, returns false if the job does not exist
+func (m *Manager) Cancel(id string) (*Job, bool) {
+	job, exists := m.Get(id)
+	if !exists {
+		return nil, false
+	}
+
+	job.Cancel()
+	return job, true
+}
+
+// removeExpired removes the jobs that finished before the retention period, the lock needs to be held
+func (m *Manager) removeExpired() {
+	for id, job := range m.jobs {
+		progress := job.Progress()
+		if progress.Status.IsFinished() && time.Since(progress.FinishedAt) > m.Retention {
+			delete(m.jobs, id)
+		}
+	}
+}
@@ -1,22 +1,40 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/cjlapao/common-go/helper"
 	"github.com/cjlapao/common-go/log"
 	"github.com/cjlapao/common-go/version"
+	"github.com/cjlapao/servicebuscli-go/bridge"
+	"github.com/cjlapao/servicebuscli-go/connector"
 	"github.com/cjlapao/servicebuscli-go/controller"
 	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/filter"
 	"github.com/cjlapao/servicebuscli-go/help"
+	"github.com/cjlapao/servicebuscli-go/metrics"
+	"github.com/cjlapao/servicebuscli-go/render"
+	"github.com/cjlapao/servicebuscli-go/schema"
 	"github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/cjlapao/servicebuscli-go/shell"
 	"github.com/cjlapao/servicebuscli-go/startup"
+	"github.com/cjlapao/servicebuscli-go/templating"
+	"github.com/cjlapao/servicebuscli-go/top"
+	"github.com/cjlapao/servicebuscli-go/transform"
+	"github.com/cjlapao/servicebuscli-go/watch"
 )
 
 var logger = log.Get()
@@ -45,6 +63,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if registry, err := schema.DefaultRegistry(); err != nil {
+		logger.Error("Could not load the schema registry, " + err.Error())
+		os.Exit(1)
+	} else if registry != nil {
+		logger.LogHighlight("Validating messages with %v schemas from %v", log.Info, fmt.Sprint(len(registry.Schemas)), registry.Dir)
+	}
+
 	switch module {
 	case "api":
 		controller.RestApiModuleProcessor()
@@ -64,6 +89,13 @@ func main() {
 			subscription := helper.GetFlagValue("subscription", "")
 			wiretap := helper.GetFlagSwitch("wiretap", false)
 			peek := helper.GetFlagSwitch("peek", false)
+			tail := helper.GetFlagSwitch("tail", false)
+			if tail && !peek {
+				logger.Error("--tail only follows the new messages of --peek, add --peek to browse them")
+				os.Exit(1)
+			}
+			pipeline := getTransformFlag()
+			renderer := getRendererFromFlags()
 			if len(topics) == 0 {
 				logger.Error("Missing topic name mandatory argument --topic")
 				help.PrintTopicSubscribeCommandHelper()
@@ -75,35 +107,91 @@ func main() {
 				os.Exit(0)
 			}
 
+			if wiretap {
+				subscription = "wiretap"
+			}
+			limits := getSubscribeLimitsFromFlags()
+			receive := getReceiveOptionsFromFlags(peek)
+			settle := getSettleModeFromFlags(peek, receive)
+
+			ctx, cancel := context.WithCancel(context.Background())
 			signalChan := make(chan os.Signal, 1)
 			signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-signalChan
+				cancel()
+			}()
 
 			var wg sync.WaitGroup
-			wg.Add(len(topics))
+			var failed int32
 			var topicSbClients []*servicebus.ServiceBusCli
 			for _, topic := range topics {
-				go func(topicName string) {
-					sbcli := servicebus.NewCli(connStr)
-					sbcli.UseWiretap = wiretap
-					sbcli.Peek = peek
-
-					if sbcli.UseWiretap {
-						subscription = "wiretap"
-					}
+				sbcli := servicebus.NewCli(connStr)
+				sbcli.UseWiretap = wiretap
+				sbcli.Peek = peek
+				sbcli.Tail = tail
+				sbcli.Transform = pipeline
+				sbcli.Renderer = renderer
+				sbcli.Limits = limits
+				sbcli.Receive = receive
+				sbcli.Settle = settle
+				topicSbClients = append(topicSbClients, sbcli)
 
-					topicSbClients = append(topicSbClients, sbcli)
-					sbcli.SubscribeToTopic(topicName, subscription)
+				wg.Add(1)
+				go func(sbcli *servicebus.ServiceBusCli, topicName string) {
 					defer wg.Done()
-				}(topic)
+					if err := sbcli.SubscribeToTopic(topicName, subscription); err != nil {
+						atomic.StoreInt32(&failed, 1)
+						cancel()
+					}
+				}(sbcli, topic)
 			}
 			logger.LogHighlight("Use %v to close connection", log.Info, "ctrl+c")
-			<-signalChan
+			reason := limits.Wait(ctx)
 			for _, topicCli := range topicSbClients {
 				topicCli.CloseTopicListener <- true
 			}
 			wg.Wait()
-			logger.Info("Bye!!!")
-			os.Exit(0)
+			renderer.Close()
+			os.Exit(finishSubscribe(limits, reason, atomic.LoadInt32(&failed) == 1))
+		case "search":
+			if helpArg {
+				help.PrintTopicSearchCommandHelper()
+				os.Exit(0)
+			}
+			topic := helper.GetFlagValue("topic", "")
+			subscription := helper.GetFlagValue("subscription", "")
+			if topic == "" || subscription == "" {
+				logger.Error("Missing topic or subscription name mandatory arguments --topic and --subscription")
+				help.PrintTopicSearchCommandHelper()
+				os.Exit(0)
+			}
+			options, err := getSearchOptionsFromFlags()
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			signalChan := make(chan os.Signal, 1)
+			signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-signalChan
+				cancel()
+			}()
+
+			logger.LogHighlight("Use %v to stop the search and print the messages found", log.Info, "ctrl+c")
+			sbcli := servicebus.NewCli(connStr)
+			messages, err := sbcli.SearchSubscriptionMessages(ctx, topic, subscription, *options)
+			for i := range messages {
+				envelope := entities.MessageEnvelope{}
+				envelope.FromServiceBus(&messages[i])
+				printEnvelope(envelope)
+			}
+			if err != nil && ctx.Err() == nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
 		case "list":
 			sbcli := servicebus.NewCli(connStr)
 			topics, err := sbcli.ListTopics()
@@ -318,7 +406,15 @@ func main() {
 			}
 
 			sbcli := servicebus.NewCli(connStr)
-			sbcli.SendTopicMessage(topic, sbMessage)
+			err := sendTemplatedMessage(sbcli, sbMessage, func() (servicebus.MessageSender, error) {
+				return sbcli.GetTopicSender(topic)
+			}, func(message entities.MessageRequest) error {
+				return sbcli.SendTopicMessage(topic, message)
+			})
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
 		default:
 			logger.LogHighlight("Invalid command argument %v, please choose a valid argument", log.Info, command)
 			help.PrintTopicMainCommandHelper()
@@ -338,35 +434,99 @@ func main() {
 			}
 			queues := helper.GetFlagArrayValue("queue")
 			peek := helper.GetFlagSwitch("peek", false)
+			tail := helper.GetFlagSwitch("tail", false)
+			if tail && !peek {
+				logger.Error("--tail only follows the new messages of --peek, add --peek to browse them")
+				os.Exit(1)
+			}
+			pipeline := getTransformFlag()
+			renderer := getRendererFromFlags()
 			if len(queues) == 0 {
 				logger.Error("Missing queue name mandatory argument --queue")
 				help.PrintQueueSubscribeCommandHelper()
 				os.Exit(0)
 			}
 
+			limits := getSubscribeLimitsFromFlags()
+			receive := getReceiveOptionsFromFlags(peek)
+			settle := getSettleModeFromFlags(peek, receive)
+
+			ctx, cancel := context.WithCancel(context.Background())
 			signalChan := make(chan os.Signal, 1)
-			signal.Notify(signalChan, os.Interrupt, os.Kill)
+			signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-signalChan
+				cancel()
+			}()
 
 			var wg sync.WaitGroup
-			wg.Add(len(queues))
+			var failed int32
 			var queueSbClients []*servicebus.ServiceBusCli
 			for _, queue := range queues {
-				go func(queueName string) {
-					sbcli := servicebus.NewCli(connStr)
-					sbcli.Peek = peek
-					queueSbClients = append(queueSbClients, sbcli)
-					sbcli.SubscribeToQueue(queueName)
+				sbcli := servicebus.NewCli(connStr)
+				sbcli.Peek = peek
+				sbcli.Tail = tail
+				sbcli.Transform = pipeline
+				sbcli.Renderer = renderer
+				sbcli.Limits = limits
+				sbcli.Receive = receive
+				sbcli.Settle = settle
+				queueSbClients = append(queueSbClients, sbcli)
+
+				wg.Add(1)
+				go func(sbcli *servicebus.ServiceBusCli, queueName string) {
 					defer wg.Done()
-				}(queue)
+					if err := sbcli.SubscribeToQueue(queueName); err != nil {
+						atomic.StoreInt32(&failed, 1)
+						cancel()
+					}
+				}(sbcli, queue)
 			}
 			logger.LogHighlight("Use %v to close connection", log.Info, "ctrl+c")
-			<-signalChan
+			reason := limits.Wait(ctx)
 			for _, queueCli := range queueSbClients {
 				queueCli.CloseQueueListener <- true
 			}
 			wg.Wait()
-			logger.Info("Bye!!!")
-			os.Exit(0)
+			renderer.Close()
+			os.Exit(finishSubscribe(limits, reason, atomic.LoadInt32(&failed) == 1))
+		case "search":
+			if helpArg {
+				help.PrintQueueSearchCommandHelper()
+				os.Exit(0)
+			}
+			queue := helper.GetFlagValue("queue", "")
+			if queue == "" {
+				logger.Error("Missing queue name mandatory argument --queue")
+				help.PrintQueueSearchCommandHelper()
+				os.Exit(0)
+			}
+			options, err := getSearchOptionsFromFlags()
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			signalChan := make(chan os.Signal, 1)
+			signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-signalChan
+				cancel()
+			}()
+
+			logger.LogHighlight("Use %v to stop the search and print the messages found", log.Info, "ctrl+c")
+			sbcli := servicebus.NewCli(connStr)
+			messages, err := sbcli.SearchQueueMessages(ctx, queue, *options)
+			for i := range messages {
+				envelope := entities.MessageEnvelope{}
+				envelope.FromServiceBus(&messages[i])
+				printEnvelope(envelope)
+			}
+			if err != nil && ctx.Err() == nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
 		case "list":
 			sbcli := servicebus.NewCli(connStr)
 			queues, err := sbcli.ListQueues()
@@ -509,13 +669,633 @@ func main() {
 			}
 
 			sbcli := servicebus.NewCli(connStr)
-			sbcli.SendQueueMessage(queue, sbMessage)
+			err := sendTemplatedMessage(sbcli, sbMessage, func() (servicebus.MessageSender, error) {
+				return sbcli.GetQueueSender(queue)
+			}, func(message entities.MessageRequest) error {
+				return sbcli.SendQueueMessage(queue, message)
+			})
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
 
 		default:
 			logger.LogHighlight("Invalid command argument %v, please choose a valid argument", log.Error, command)
 			help.PrintQueueMainCommandHelper()
 		}
 		os.Exit(0)
+	case "load":
+		if helpArg {
+			help.PrintLoadCommandHelper()
+			os.Exit(0)
+		}
+		queue := helper.GetFlagValue("queue", "")
+		topic := helper.GetFlagValue("topic", "")
+		if (queue == "" && topic == "") || (queue != "" && topic != "") {
+			logger.LogHighlight("Missing target, use %v or %v", log.Error, "--queue", "--topic")
+			help.PrintLoadCommandHelper()
+			os.Exit(0)
+		}
+
+		template, err := getMessageRequestFromFlags()
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintLoadCommandHelper()
+			os.Exit(1)
+		}
+
+		load := entities.LoadRequest{
+			Template: *template,
+		}
+		if dataFile := helper.GetFlagValue("data-file", ""); dataFile != "" {
+			load.Rows, err = templating.LoadRows(dataFile)
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+		load.MessagesPerSecond, _ = strconv.ParseFloat(helper.GetFlagValue("rate", "0"), 64)
+		load.TotalMessages, _ = strconv.Atoi(helper.GetFlagValue("count", "0"))
+		load.Concurrency, _ = strconv.Atoi(helper.GetFlagValue("concurrency", "1"))
+		load.DurationInSeconds = getSecondsFlag("duration")
+		load.RampUpInSeconds = getSecondsFlag("ramp-up")
+		load.RampDownInSeconds = getSecondsFlag("ramp-down")
+		if err := load.Validate(); err != nil {
+			logger.Error(err.Error())
+			help.PrintLoadCommandHelper()
+			os.Exit(1)
+		}
+
+		sbcli := servicebus.NewCli(connStr)
+		target := "queue " + queue
+		newSender := func() (servicebus.MessageSender, error) {
+			return sbcli.GetQueueSender(queue)
+		}
+		if topic != "" {
+			target = "topic " + topic
+			newSender = func() (servicebus.MessageSender, error) {
+				return sbcli.GetTopicSender(topic)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			logger.Info("Stopping the load, waiting for the messages in flight")
+			cancel()
+		}()
+
+		var sent, failed int64
+		progressDone := make(chan bool)
+		go func() {
+			ticker := time.NewTicker(5 * time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					logger.LogHighlight("Sent %v messages, %v failed", log.Info, fmt.Sprint(atomic.LoadInt64(&sent)), fmt.Sprint(atomic.LoadInt64(&failed)))
+				case <-progressDone:
+					return
+				}
+			}
+		}()
+
+		logger.LogHighlight("Sending load to %v, use %v to stop it", log.Info, target, "ctrl+c")
+		report, err := sbcli.RunLoad(ctx, newSender, load, func(succeeded int, failures int) {
+			atomic.AddInt64(&sent, int64(succeeded))
+			atomic.AddInt64(&failed, int64(failures))
+		})
+		close(progressDone)
+		cancel()
+		if report != nil {
+			printLoadReport(report)
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "probe":
+		if helpArg {
+			help.PrintProbeCommandHelper()
+			os.Exit(0)
+		}
+
+		probe := entities.ProbeRequest{
+			Topic:            helper.GetFlagValue("topic", ""),
+			Queue:            helper.GetFlagValue("queue", ""),
+			Subscription:     helper.GetFlagValue("subscription", ""),
+			ReceiveTopic:     helper.GetFlagValue("receive-topic", ""),
+			ReceiveQueue:     helper.GetFlagValue("receive-queue", ""),
+			IntervalInMilli:  int(getDurationFlag("interval").Milliseconds()),
+			TimeoutInSeconds: getSecondsFlag("timeout"),
+			SloInMilli:       int(getDurationFlag("slo").Milliseconds()),
+		}
+		probe.TotalMessages, _ = strconv.Atoi(helper.GetFlagValue("count", "0"))
+		if err := probe.Validate(); err != nil {
+			logger.Error(err.Error())
+			help.PrintProbeCommandHelper()
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		logger.LogHighlight("Use %v to stop the probe", log.Info, "ctrl+c")
+		sbcli := servicebus.NewCli(connStr)
+		lastPrinted := time.Now()
+		report, err := sbcli.RunProbe(ctx, probe, func(report entities.ProbeReport) {
+			if time.Since(lastPrinted) >= 10*time.Second {
+				lastPrinted = time.Now()
+				printProbeReport(&report)
+			}
+		})
+		cancel()
+		if report != nil {
+			printProbeReport(report)
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "exporter":
+		if helpArg {
+			help.PrintExporterCommandHelper()
+			os.Exit(0)
+		}
+		port := helper.GetFlagValue("port", "9090")
+
+		registry := metrics.NewRegistry()
+		sbcli := servicebus.NewCli(connStr)
+		exporter := servicebus.NewExporter(registry, func() *servicebus.ServiceBusCli {
+			return sbcli
+		})
+		if interval := getDurationFlag("interval"); interval > 0 {
+			exporter.Interval = interval
+		}
+		go exporter.Run(context.Background())
+
+		http.Handle("/metrics", registry.Handler())
+		logger.LogHighlight("Exporting metrics on %v polling every %v", log.Info, "http://localhost:"+port+"/metrics", exporter.Interval.String())
+		if err := http.ListenAndServe(":"+port, nil); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	case "watch":
+		if helpArg {
+			help.PrintWatchCommandHelper()
+			os.Exit(0)
+		}
+		rulesFile := helper.GetFlagValue("rules", "")
+		if rulesFile == "" {
+			logger.LogHighlight("Missing %v flag", log.Error, "--rules")
+			help.PrintWatchCommandHelper()
+			os.Exit(1)
+		}
+		rules, err := watch.LoadRules(rulesFile)
+		if err != nil {
+			logger.LogHighlight("Could not load the rules from %v, %v", log.Error, rulesFile, err.Error())
+			os.Exit(1)
+		}
+
+		sbcli := servicebus.NewCli(connStr)
+		watcher := watch.NewWatcher(sbcli, rules)
+		if helper.GetFlagSwitch("once", false) {
+			watcher.Poll(context.Background())
+			os.Exit(0)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		logger.LogHighlight("Watching %v rules every %v, use %v to stop", log.Info, strconv.Itoa(len(rules.Rules)), rules.Interval.String(), "ctrl+c")
+		watcher.Run(ctx)
+		os.Exit(0)
+	case "top":
+		if helpArg {
+			help.PrintTopCommandHelper()
+			os.Exit(0)
+		}
+
+		dashboard := top.New(servicebus.NewCli(connStr))
+		if interval := getDurationFlag("interval"); interval > 0 {
+			dashboard.Interval = interval
+		}
+
+		// ctrl+c arrives as a key while the terminal is in raw mode, this only handles being terminated
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		if err := dashboard.Run(ctx); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "bridge":
+		if helpArg {
+			help.PrintBridgeCommandHelper()
+			os.Exit(0)
+		}
+		from, err := servicebus.ParseEndpoint(helper.GetFlagValue("from", ""))
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintBridgeCommandHelper()
+			os.Exit(1)
+		}
+
+		sbcli := servicebus.NewCli(connStr)
+		httpBridge, err := bridge.NewBridge(sbcli, from, helper.GetFlagValue("to-http", ""))
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintBridgeCommandHelper()
+			os.Exit(1)
+		}
+		for _, header := range helper.GetFlagArrayValue("header") {
+			key, value := helper.MapFlagValue(header)
+			if key != "" {
+				httpBridge.Headers[key] = value
+			}
+		}
+		httpBridge.Body = helper.GetFlagValue("body", bridge.BodyData)
+		if templateFile := helper.GetFlagValue("template", ""); templateFile != "" {
+			content, err := ioutil.ReadFile(templateFile)
+			if err == nil {
+				err = httpBridge.SetTemplate(string(content))
+			}
+			if err != nil {
+				logger.LogHighlight("Could not load the template from %v, %v", log.Error, templateFile, err.Error())
+				os.Exit(1)
+			}
+		}
+		if retries := helper.GetFlagValue("retries", ""); retries != "" {
+			httpBridge.Retries, _ = strconv.Atoi(retries)
+		}
+		httpBridge.Backoff = getDurationFlag("backoff")
+		httpBridge.Timeout = getDurationFlag("timeout")
+		httpBridge.DeadLetter = helper.GetFlagSwitch("dead-letter", false)
+		httpBridge.Transform = getTransformFlag()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		logger.LogHighlight("Bridging %v to %v, use %v to stop", log.Info, from.String(), httpBridge.URL, "ctrl+c")
+		err = httpBridge.Run(ctx)
+		logger.LogHighlight("Delivered %v messages, %v failed", log.Info, fmt.Sprint(httpBridge.Delivered()), fmt.Sprint(httpBridge.Failed()))
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "sink":
+		if helpArg {
+			help.PrintSinkCommandHelper()
+			os.Exit(0)
+		}
+		from, err := servicebus.ParseEndpoint(helper.GetFlagValue("from", ""))
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintSinkCommandHelper()
+			os.Exit(1)
+		}
+
+		sink, err := connector.NewSink(servicebus.NewCli(connStr), from, helper.GetFlagValue("to-dir", ""))
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintSinkCommandHelper()
+			os.Exit(1)
+		}
+		sink.Format = helper.GetFlagValue("format", connector.FormatFiles)
+		sink.MaxSegmentSize, _ = strconv.ParseInt(helper.GetFlagValue("max-size", "0"), 10, 64)
+		sink.MaxSegmentAge = getDurationFlag("max-age")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		logger.LogHighlight("Writing the messages of %v to %v, use %v to stop", log.Info, from.String(), sink.Dir, "ctrl+c")
+		err = sink.Run(ctx)
+		logger.LogHighlight("Wrote %v messages, %v failed", log.Info, fmt.Sprint(sink.Written()), fmt.Sprint(sink.Failed()))
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "source":
+		if helpArg {
+			help.PrintSourceCommandHelper()
+			os.Exit(0)
+		}
+		to, err := servicebus.ParseEndpoint(helper.GetFlagValue("to", ""))
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintSourceCommandHelper()
+			os.Exit(1)
+		}
+
+		source, err := connector.NewSource(servicebus.NewCli(connStr), helper.GetFlagValue("from-dir", ""), to)
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintSourceCommandHelper()
+			os.Exit(1)
+		}
+		source.DoneDir = helper.GetFlagValue("done-dir", source.DoneDir)
+		source.FailedDir = helper.GetFlagValue("failed-dir", source.FailedDir)
+		source.Format = helper.GetFlagValue("format", connector.FormatBody)
+		source.Label = helper.GetFlagValue("label", "")
+		source.ContentType = helper.GetFlagValue("contentType", "")
+		source.PollInterval = getDurationFlag("interval")
+		source.Transform = getTransformFlag()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		logger.LogHighlight("Sending the files of %v to %v, use %v to stop", log.Info, source.Dir, to.String(), "ctrl+c")
+		err = source.Run(ctx)
+		logger.LogHighlight("Sent %v files, %v failed", log.Info, fmt.Sprint(source.Sent()), fmt.Sprint(source.Failed()))
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "expect":
+		if helpArg {
+			help.PrintExpectCommandHelper()
+			os.Exit(0)
+		}
+		from, err := getExpectEndpointFromFlags()
+		if err == nil && !from.CanReceive() {
+			err = errors.New("can not receive messages from " + from.String() + ", topics need a subscription")
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintExpectCommandHelper()
+			os.Exit(1)
+		}
+		where, err := filter.Parse(helper.GetFlagValue("where", ""))
+		if err != nil {
+			logger.Error("invalid where expression, " + err.Error())
+			os.Exit(1)
+		}
+		within := getDurationFlag("within")
+		if within <= 0 {
+			within = 30 * time.Second
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		sbcli := servicebus.NewCli(connStr)
+		msg, err := sbcli.ExpectMessage(ctx, from, servicebus.ExpectOptions{
+			Where:      where,
+			Within:     within,
+			Consume:    helper.GetFlagSwitch("consume", false),
+			DeadLetter: helper.GetFlagSwitch("dead-letter", false),
+		})
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		if msg == nil {
+			logger.LogHighlight("No message matching the expectation arrived in %v within %v", log.Error, from.String(), within.String())
+			os.Exit(1)
+		}
+
+		envelope := entities.MessageEnvelope{}
+		envelope.FromServiceBus(msg)
+		content, _ := json.MarshalIndent(envelope, "", "  ")
+		fmt.Println(string(content))
+		os.Exit(0)
+	case "move":
+		if helpArg {
+			help.PrintMoveCommandHelper()
+			os.Exit(0)
+		}
+		from, err := servicebus.ParseEndpoint(helper.GetFlagValue("from", ""))
+		if err == nil && !from.CanReceive() {
+			err = errors.New("can not receive messages from " + from.String() + ", topics need a subscription")
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintMoveCommandHelper()
+			os.Exit(1)
+		}
+		to, err := servicebus.ParseEndpoint(helper.GetFlagValue("to", ""))
+		if err == nil && !to.CanSend() {
+			err = errors.New("can not send messages to " + to.String() + ", send them to its topic")
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintMoveCommandHelper()
+			os.Exit(1)
+		}
+		where, err := filter.Parse(helper.GetFlagValue("where", ""))
+		if err != nil {
+			logger.Error("invalid where expression, " + err.Error())
+			os.Exit(1)
+		}
+		count, err := strconv.Atoi(helper.GetFlagValue("count", "0"))
+		if err != nil || count < 0 {
+			logger.Error("invalid count, it needs to be a positive number")
+			os.Exit(1)
+		}
+		options := servicebus.MoveOptions{
+			DeadLetter: helper.GetFlagSwitch("dead-letter", false),
+			Count:      count,
+			Where:      where,
+			Copy:       helper.GetFlagSwitch("copy", false),
+			Transform:  getTransformFlag(),
+			KeepID:     helper.GetFlagSwitch("keep-id", false),
+		}
+		if !options.DeadLetter && from.FeedsBack(to) {
+			logger.Error("can not move or copy the messages of " + from.String() + " to " + to.String() + ", they would be received again")
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		sbcli := servicebus.NewCli(connStr)
+		if helper.GetFlagSwitch("dry-run", false) {
+			messages, err := sbcli.SearchEndpointMessages(ctx, from, servicebus.SearchOptions{
+				Where:      options.Where,
+				DeadLetter: options.DeadLetter,
+				MaxResults: options.Count,
+			})
+			for _, msg := range messages {
+				envelope := entities.MessageEnvelope{}
+				envelope.FromServiceBus(&msg)
+				logger.LogHighlight("Message %v (%v) %v", log.Info, fmt.Sprint(envelope.SequenceNumber), envelope.ID, envelope.Label)
+				if options.Transform != nil {
+					transformed, err := options.Transform.Apply(&msg)
+					if err != nil {
+						logger.Error(err.Error())
+						continue
+					}
+					fmt.Println(string(transformed.Data))
+				}
+			}
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+			logger.LogHighlight("Dry run, %v messages would be sent to %v", log.Info, fmt.Sprint(len(messages)), to.String())
+			os.Exit(0)
+		}
+
+		moved, failed := 0, 0
+		err = sbcli.MoveMessages(ctx, from, to, options, func(succeeded int, failures int) {
+			moved += succeeded
+			failed += failures
+			if (succeeded > 0 || failures > 0) && (moved+failed)%1000 == 0 {
+				logger.LogHighlight("Sent %v messages, %v failed", log.Info, fmt.Sprint(moved), fmt.Sprint(failed))
+			}
+		})
+		logger.LogHighlight("Sent %v messages to %v, %v failed", log.Info, fmt.Sprint(moved), to.String(), fmt.Sprint(failed))
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "message":
+		action := GetCommandArgument()
+		if helpArg || action == "" {
+			help.PrintMessageCommandHelper()
+			os.Exit(0)
+		}
+		from, err := servicebus.ParseEndpoint(helper.GetFlagValue("from", ""))
+		if err != nil {
+			logger.Error(err.Error())
+			help.PrintMessageCommandHelper()
+			os.Exit(1)
+		}
+
+		operation := servicebus.MessageOperation{
+			Action:      action,
+			MessageIDs:  helper.GetFlagArrayValue("id"),
+			DeadLetter:  helper.GetFlagSwitch("dead-letter", false),
+			Reason:      helper.GetFlagValue("reason", ""),
+			Description: helper.GetFlagValue("description", ""),
+			KeepID:      helper.GetFlagSwitch("keep-id", false),
+			LockAhead:   helper.GetFlagSwitch("lock-ahead", false),
+		}
+		for _, value := range helper.GetFlagArrayValue("seq") {
+			sequenceNumber, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				logger.LogHighlight("Invalid sequence number %v, it needs to be a number", log.Error, value)
+				os.Exit(1)
+			}
+			operation.SequenceNumbers = append(operation.SequenceNumbers, sequenceNumber)
+		}
+		if to := helper.GetFlagValue("to", ""); to != "" {
+			operation.To, err = servicebus.ParseEndpoint(to)
+			if err != nil {
+				logger.Error(err.Error())
+				os.Exit(1)
+			}
+		}
+		if err := operation.Validate(); err != nil {
+			logger.Error(err.Error())
+			help.PrintMessageCommandHelper()
+			os.Exit(1)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		results, err := servicebus.NewCli(connStr).RunMessageOperation(ctx, from, operation)
+		failed := false
+		for _, result := range results {
+			switch result.Status {
+			case servicebus.MessageStatusFailed, servicebus.MessageStatusNotFound:
+				failed = true
+				logger.LogHighlight("Message %v (%v) %v, %v", log.Error, fmt.Sprint(result.SequenceNumber), result.MessageID, result.Status, result.Error)
+			default:
+				if result.Abandoned > 0 {
+					logger.LogHighlight("Message %v (%v) %v, %v messages in front of it were abandoned", log.Info, fmt.Sprint(result.SequenceNumber), result.MessageID, result.Status, fmt.Sprint(result.Abandoned))
+				} else {
+					logger.LogHighlight("Message %v (%v) %v", log.Info, fmt.Sprint(result.SequenceNumber), result.MessageID, result.Status)
+				}
+			}
+			if result.Status == servicebus.MessageStatusReceived && result.Message != nil {
+				printEnvelope(*result.Message)
+			}
+		}
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		if failed {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	case "shell":
+		if helpArg {
+			help.PrintShellCommandHelper()
+			os.Exit(0)
+		}
+
+		// the shell handles ctrl+c itself to cancel the running command
+		ctx, cancel := context.WithCancel(context.Background())
+		signalChan := make(chan os.Signal, 1)
+		signal.Notify(signalChan, syscall.SIGTERM)
+		go func() {
+			<-signalChan
+			cancel()
+		}()
+
+		if err := shell.New(servicebus.NewCli(connStr)).Run(ctx); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		logger.Info("Bye!!!")
+		os.Exit(0)
 	default:
 
 		help.PrintMainCommandHelper()
@@ -556,3 +1336,334 @@ func GetSubCommandArgument() string {
 
 	return args[0]
 }
+
+// getMessageRequestFromFlags Gets the message from the --file flag or builds it from the --body, --label,
+// --correlationID, --contentType and --property flags
+func getMessageRequestFromFlags() (*entities.MessageRequest, error) {
+	body := helper.GetFlagValue("body", "")
+	label := helper.GetFlagValue("label", "ServiceBus.Tools")
+	filePath := helper.GetFlagValue("file", "")
+	correlationID := helper.GetFlagValue("correlationID", "")
+	contentType := helper.GetFlagValue("contentType", "")
+	propertiesFlags := helper.GetFlagArrayValue("property")
+
+	sbMessage := entities.MessageRequest{}
+	if filePath != "" {
+		err := sbMessage.FromFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &sbMessage, nil
+	}
+
+	if body == "" {
+		return nil, errors.New("missing message body, use --body with a json body or --file with a message object")
+	}
+
+	var message map[string]interface{}
+	err := json.Unmarshal([]byte(body), &message)
+	if err != nil {
+		return nil, err
+	}
+
+	sbMessage.Data = message
+	sbMessage.Label = label
+	sbMessage.ContentType = contentType
+	sbMessage.CorrelationID = correlationID
+	if len(propertiesFlags) > 0 {
+		sbMessage.UserProperties = make(map[string]interface{})
+		for _, property := range propertiesFlags {
+			key, value := helper.MapFlagValue(property)
+			if key != "" && value != "" {
+				sbMessage.UserProperties[key] = value
+			}
+		}
+	}
+
+	return &sbMessage, nil
+}
+
+// sendTemplatedMessage Sends the message as it is unless the --template or --data-file flags are set, the template
+// is rendered once with --template and a message is sent for every row of the file using a single sender with
+// --data-file
+func sendTemplatedMessage(sbcli *servicebus.ServiceBusCli, message entities.MessageRequest, newSender servicebus.SenderFactory, send func(entities.MessageRequest) error) error {
+	dataFile := helper.GetFlagValue("data-file", "")
+	if dataFile == "" {
+		if !helper.GetFlagSwitch("template", false) {
+			return send(message)
+		}
+		template, err := templating.New(message, nil)
+		if err != nil {
+			return err
+		}
+		rendered, err := template.Render(0)
+		if err != nil {
+			return err
+		}
+		return send(*rendered)
+	}
+
+	rows, err := templating.LoadRows(dataFile)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return errors.New("data file " + dataFile + " has no rows")
+	}
+
+	sender, err := newSender()
+	if err != nil {
+		return err
+	}
+	defer sender.Close(context.Background())
+
+	bulk := entities.BulkTemplateMessageRequest{
+		TotalMessages: len(rows),
+		BatchOf:       1,
+		Template:      message,
+		Rows:          rows,
+	}
+	sent, failed := 0, 0
+	err = sbcli.SendBulkTemplateMessages(context.Background(), sender, bulk, func(succeeded int, failures int) {
+		sent += succeeded
+		failed += failures
+	})
+	logger.LogHighlight("Sent %v messages from %v, %v failed", log.Info, fmt.Sprint(sent), dataFile, fmt.Sprint(failed))
+
+	return err
+}
+
+// getSecondsFlag Gets a flag with a duration like 90s or 5m or a number of seconds
+func getSecondsFlag(flag string) int {
+	return int(getDurationFlag(flag).Seconds())
+}
+
+// getDurationFlag Gets a flag with a duration like 500ms, 90s or 5m or a number of seconds
+func getDurationFlag(flag string) time.Duration {
+	value := helper.GetFlagValue(flag, "")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		logger.LogHighlight("Invalid duration %v for %v, use a number of seconds or a duration like 90s or 5m", log.Error, value, "--"+flag)
+		return 0
+	}
+
+	return duration
+}
+
+// getTransformFlag Loads the transform pipeline of the --transform flag, exiting when it is not valid
+func getTransformFlag() *transform.Pipeline {
+	filePath := helper.GetFlagValue("transform", "")
+	if filePath == "" {
+		return nil
+	}
+
+	pipeline, err := transform.Load(filePath)
+	if err != nil {
+		logger.LogHighlight("Could not load the transform pipeline from %v, %v", log.Error, filePath, err.Error())
+		os.Exit(1)
+	}
+	return pipeline
+}
+
+// getRendererFromFlags Creates the renderer of the subscribe commands from the --format, --fields, --extract,
+// --template and --output flags, exiting when they are not valid
+func getRendererFromFlags() *render.Renderer {
+	renderer := render.NewRenderer()
+	err := renderer.SetFormat(helper.GetFlagValue("format", render.FormatRaw))
+	if err == nil {
+		if fields := helper.GetFlagValue("fields", ""); fields != "" {
+			err = renderer.SetFields(strings.Split(fields, ","))
+		}
+	}
+	if err == nil {
+		if path := helper.GetFlagValue("extract", ""); path != "" {
+			err = renderer.SetExtract(path)
+		}
+	}
+	if err == nil {
+		if templateFile := helper.GetFlagValue("template", ""); templateFile != "" {
+			var content []byte
+			content, err = ioutil.ReadFile(templateFile)
+			if err == nil {
+				err = renderer.SetTemplate(string(content))
+			}
+		}
+	}
+	if err == nil && renderer.Format == render.FormatTemplate && helper.GetFlagValue("template", "") == "" {
+		err = errors.New("the template format needs a --template file")
+	}
+	if err == nil {
+		if output := helper.GetFlagValue("output", ""); output != "" {
+			err = renderer.OpenOutput(output)
+		}
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	return renderer
+}
+
+// getSubscribeLimitsFromFlags Reads the --max-messages, --timeout and --idle-timeout flags of the subscribe
+// commands, exiting when they are not valid
+func getSubscribeLimitsFromFlags() *servicebus.SubscribeLimits {
+	maxMessages, err := strconv.Atoi(helper.GetFlagValue("max-messages", "0"))
+	if err != nil || maxMessages < 0 {
+		logger.Error("invalid max-messages, it needs to be a positive number")
+		os.Exit(1)
+	}
+
+	return servicebus.NewSubscribeLimits(maxMessages, getDurationFlag("timeout"), getDurationFlag("idle-timeout"))
+}
+
+// getReceiveOptionsFromFlags Reads the --prefetch, --concurrency and --receive-mode flags of the subscribe
+// commands, exiting when they are not valid. Peeking needs the messages to stay locked so it can not receive and
+// delete them
+func getReceiveOptionsFromFlags(peek bool) servicebus.ReceiveOptions {
+	options := servicebus.ReceiveOptions{}
+	var err error
+	if options.PrefetchCount, err = strconv.Atoi(helper.GetFlagValue("prefetch", "0")); err != nil {
+		err = errors.New("invalid prefetch, it needs to be a number")
+	}
+	if err == nil {
+		if options.MaxConcurrency, err = strconv.Atoi(helper.GetFlagValue("concurrency", "0")); err != nil {
+			err = errors.New("invalid concurrency, it needs to be a number")
+		}
+	}
+	if err == nil {
+		options.ReceiveAndDelete, err = servicebus.ParseReceiveMode(helper.GetFlagValue("receive-mode", ""))
+	}
+	if err == nil {
+		err = options.Validate()
+	}
+	if err == nil && peek && options.ReceiveAndDelete {
+		err = errors.New("peeking can not receive and delete the messages, use the peeklock receive mode")
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	return options
+}
+
+// getSettleModeFromFlags Reads the --settle flag of the subscribe commands, exiting when it is not valid. Peeked and
+// received and deleted messages are never settled so they only take the default mode
+func getSettleModeFromFlags(peek bool, receive servicebus.ReceiveOptions) string {
+	mode, err := servicebus.ParseSettleMode(helper.GetFlagValue("settle", ""))
+	if err == nil && mode != servicebus.SettleComplete {
+		if peek {
+			err = errors.New("peeked messages are not settled, remove --peek to settle them")
+		} else if receive.ReceiveAndDelete {
+			err = errors.New("received and deleted messages are already settled, use the peeklock receive mode")
+		}
+	}
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	return mode
+}
+
+// finishSubscribe Logs why a subscribe command stopped and gets its exit code, it fails when an entity could not
+// be subscribed to or the maximum number of messages was not received
+func finishSubscribe(limits *servicebus.SubscribeLimits, reason string, failed bool) int {
+	logger.LogHighlight("Received %v messages, stopped by %v", log.Info, fmt.Sprint(limits.Received()), reason)
+	if failed {
+		logger.Error("Could not subscribe to all the entities")
+		return 1
+	}
+	if limits.MaxMessages > 0 && !limits.Reached() {
+		logger.LogHighlight("Expected %v messages but only %v were received", log.Error, fmt.Sprint(limits.MaxMessages), fmt.Sprint(limits.Received()))
+		return 1
+	}
+
+	logger.Info("Bye!!!")
+	return 0
+}
+
+// getExpectEndpointFromFlags Reads where the expect command waits for the message, --queue name and
+// --subscription topic/name are shorter ways of writing --from
+func getExpectEndpointFromFlags() (*servicebus.Endpoint, error) {
+	if queue := helper.GetFlagValue("queue", ""); queue != "" {
+		return &servicebus.Endpoint{Queue: queue}, nil
+	}
+	if subscription := helper.GetFlagValue("subscription", ""); subscription != "" {
+		parts := strings.SplitN(subscription, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.New("invalid subscription " + subscription + ", use topic/subscription")
+		}
+		return &servicebus.Endpoint{Topic: parts[0], Subscription: parts[1]}, nil
+	}
+
+	return servicebus.ParseEndpoint(helper.GetFlagValue("from", ""))
+}
+
+// getSearchOptionsFromFlags Reads the where expression, dead letter switch and maximum matches of a search
+func getSearchOptionsFromFlags() (*servicebus.SearchOptions, error) {
+	where, err := filter.Parse(helper.GetFlagValue("where", ""))
+	if err != nil {
+		return nil, errors.New("invalid where expression, " + err.Error())
+	}
+
+	maxResults := 0
+	if value := helper.GetFlagValue("max", ""); value != "" {
+		maxResults, err = strconv.Atoi(value)
+		if err != nil || maxResults < 0 {
+			return nil, errors.New("invalid max " + value + ", it needs to be a positive number")
+		}
+	}
+
+	return &servicebus.SearchOptions{
+		Where:      where,
+		DeadLetter: helper.GetFlagSwitch("dead-letter", false),
+		MaxResults: maxResults,
+	}, nil
+}
+
+// printEnvelope Prints a message as an indented json envelope
+func printEnvelope(envelope entities.MessageEnvelope) {
+	content, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		logger.Error(err.Error())
+		return
+	}
+	fmt.Println(string(content))
+}
+
+// printLoadReport Prints the throughput and latency of a load
+func printLoadReport(report *entities.LoadReport) {
+	logger.Info("")
+	logger.Info("Load Report:")
+	logger.LogHighlight("  Sent:        %v messages (%v failed)", log.Info, fmt.Sprint(report.Sent), fmt.Sprint(report.Failed))
+	logger.LogHighlight("  Duration:    %v", log.Info, fmt.Sprintf("%.2fs", report.DurationInSeconds))
+	logger.LogHighlight("  Concurrency: %v senders", log.Info, fmt.Sprint(report.Concurrency))
+	if report.TargetMessagesPerSecond > 0 {
+		logger.LogHighlight("  Throughput:  %v msg/s (target %v msg/s)", log.Info, fmt.Sprintf("%.2f", report.MessagesPerSecond), fmt.Sprintf("%.2f", report.TargetMessagesPerSecond))
+	} else {
+		logger.LogHighlight("  Throughput:  %v msg/s", log.Info, fmt.Sprintf("%.2f", report.MessagesPerSecond))
+	}
+	logger.LogHighlight("  Latency:     min %v, mean %v, max %v", log.Info, fmt.Sprintf("%.2fms", report.Latency.Min), fmt.Sprintf("%.2fms", report.Latency.Mean), fmt.Sprintf("%.2fms", report.Latency.Max))
+	logger.LogHighlight("               p50 %v, p95 %v, p99 %v", log.Info, fmt.Sprintf("%.2fms", report.Latency.P50), fmt.Sprintf("%.2fms", report.Latency.P95), fmt.Sprintf("%.2fms", report.Latency.P99))
+}
+
+// printProbeReport Prints the delivery state and latency of a probe
+func printProbeReport(report *entities.ProbeReport) {
+	logger.Info("")
+	logger.LogHighlight("Probe %v -> %v:", log.Info, report.Target, report.Source)
+	logger.LogHighlight("  Sent:        %v messages, %v received, %v pending", log.Info, fmt.Sprint(report.Sent), fmt.Sprint(report.Received), fmt.Sprint(report.Pending))
+	logger.LogHighlight("  Lost:        %v (%v arrived late)", log.Info, fmt.Sprint(report.Lost), fmt.Sprint(report.Late))
+	logger.LogHighlight("  Out of order: %v, duplicates: %v", log.Info, fmt.Sprint(report.OutOfOrder), fmt.Sprint(report.Duplicates))
+	if report.SloInMilli > 0 {
+		logger.LogHighlight("  Over SLO:    %v messages slower than %v", log.Info, fmt.Sprint(report.OverSlo), fmt.Sprint(report.SloInMilli)+"ms")
+	}
+	logger.LogHighlight("  Latency:     last %v, min %v, mean %v, max %v", log.Info, fmt.Sprintf("%.2fms", report.LastInMilli), fmt.Sprintf("%.2fms", report.Latency.Min), fmt.Sprintf("%.2fms", report.Latency.Mean), fmt.Sprintf("%.2fms", report.Latency.Max))
+	logger.LogHighlight("               p50 %v, p95 %v, p99 %v", log.Info, fmt.Sprintf("%.2fms", report.Latency.P50), fmt.Sprintf("%.2fms", report.Latency.P95), fmt.Sprintf("%.2fms", report.Latency.P99))
+}
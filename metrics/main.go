@@ -0,0 +1,325 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry Keeps the metrics exposed to prometheus
+type Registry struct {
+	mu       sync.RWMutex
+	families []*family
+}
+
+// NewRegistry Creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make([]*family, 0),
+	}
+}
+
+// NewGaugeVec Registers a gauge with the label names, every combination of label values is a different series
+func (r *Registry) NewGaugeVec(name string, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{family: r.register(name, help, "gauge", labelNames)}
+}
+
+// NewCounterVec Registers a counter with the label names, every combination of label values is a different series
+func (r *Registry) NewCounterVec(name string, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{family: r.register(name, help, "counter", labelNames)}
+}
+
+// DefaultBuckets are the upper bounds in seconds used by histograms created without buckets
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewHistogramVec Registers a histogram with the bucket upper bounds and the label names, the buckets are sorted
+// and the +Inf bucket is always added
+func (r *Registry) NewHistogramVec(name string, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	f := r.register(name, help, "histogram", labelNames)
+	f.buckets = sorted
+	return &HistogramVec{family: f}
+}
+
+// WriteTo Writes all the metrics in the prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	families := make([]*family, len(r.families))
+	copy(families, r.families)
+	r.mu.RUnlock()
+
+	writer := &countingWriter{writer: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(writer)
+	}
+	if writer.err != nil {
+		return writer.count, writer.err
+	}
+
+	return writer.count, writer.writer.Flush()
+}
+
+// Handler Returns a http handler serving the metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.WriteHeader(http.StatusOK)
+		r.WriteTo(w)
+	})
+}
+
+func (r *Registry) register(name string, help string, metricType string, labelNames []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := &family{
+		name:       name,
+		help:       help,
+		metricType: metricType,
+		labelNames: labelNames,
+		series:     make(map[string]*series),
+	}
+	r.families = append(r.families, f)
+
+	return f
+}
+
+// family is a metric with all its series
+type family struct {
+	name       string
+	help       string
+	metricType string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// only used by histograms, the value is the sum of the observations
+	bucketCounts []uint64
+	count        uint64
+}
+
+func (f *family) get(labelValues []string) *series {
+	// missing label values are exposed as empty
+	values := make([]string, len(f.labelNames))
+	copy(values, labelValues)
+
+	key := strings.Join(values, "\xff")
+	s, exists := f.series[key]
+	if !exists {
+		s = &series{labelValues: values}
+		if f.buckets != nil {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+func (f *family) write(w *countingWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.writeString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+	w.writeString("# TYPE " + f.name + " " + f.metricType + "\n")
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.buckets == nil {
+			w.writeString(f.name + formatLabels(f.labelNames, s.labelValues) + " " + formatValue(s.value) + "\n")
+			continue
+		}
+
+		// bucket counts are cumulative in the exposition format
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.bucketCounts[i]
+			w.writeString(f.name + "_bucket" + formatBucketLabels(f.labelNames, s.labelValues, formatValue(bound)) + " " + strconv.FormatUint(cumulative, 10) + "\n")
+		}
+		w.writeString(f.name + "_bucket" + formatBucketLabels(f.labelNames, s.labelValues, "+Inf") + " " + strconv.FormatUint(s.count, 10) + "\n")
+		w.writeString(f.name + "_sum" + formatLabels(f.labelNames, s.labelValues) + " " + formatValue(s.value) + "\n")
+		w.writeString(f.name + "_count" + formatLabels(f.labelNames, s.labelValues) + " " + strconv.FormatUint(s.count, 10) + "\n")
+	}
+}
+
+// GaugeVec Metric that can go up and down
+type GaugeVec struct {
+	family *family
+}
+
+// Set Sets the value of the series with the label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+
+	g.family.get(labelValues).value = value
+}
+
+// Add Adds to the series with the label values, negative values subtract
+func (g *GaugeVec) Add(value float64, labelValues ...string) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+
+	g.family.get(labelValues).value += value
+}
+
+// Inc Adds one to the series with the label values
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec Subtracts one from the series with the label values
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+// Sample Value of a series with its label values
+type Sample struct {
+	Value       float64
+	LabelValues []string
+}
+
+// Replace Replaces all the series with the samples in one go, series missing from the samples are removed
+// so the metrics of entities that no longer exist go away without a scrape ever seeing half of them
+func (g *GaugeVec) Replace(samples []Sample) {
+	g.family.mu.Lock()
+	defer g.family.mu.Unlock()
+
+	g.family.series = make(map[string]*series)
+	for _, sample := range samples {
+		g.family.get(sample.LabelValues).value = sample.Value
+	}
+}
+
+// CounterVec Metric that only goes up
+type CounterVec struct {
+	family *family
+}
+
+// Add Adds to the series with the label values, negative values are ignored
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+
+	c.family.get(labelValues).value += value
+}
+
+// Inc Adds one to the series with the label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// HistogramVec Metric that counts observations in buckets, usually request durations
+type HistogramVec struct {
+	family *family
+}
+
+// Observe Adds an observation to the series with the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.family.mu.Lock()
+	defer h.family.mu.Unlock()
+
+	s := h.family.get(labelValues)
+	s.value += value
+	s.count++
+	for i, bound := range h.family.buckets {
+		if value <= bound {
+			s.bucketCounts[i]++
+			break
+		}
+	}
+}
+
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=\"" + escapeLabelValue(values[i]) + "\""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatBucketLabels(names []string, values []string, bound string) string {
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+"=\""+escapeLabelValue(values[i])+"\"")
+	}
+	pairs = append(pairs, "le=\""+bound+"\"")
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+var helpEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n")
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// countingWriter keeps the first error so the metrics can be written without checking every write
+type countingWriter struct {
+	writer *bufio.Writer
+	count  int64
+	err    error
+}
+
+func (w *countingWriter) writeString(value string) {
+	if w.err != nil {
+		return
+	}
+
+	n, err := w.writer.WriteString(value)
+	w.count += int64(n)
+	w.err = err
+}
@@ -0,0 +1,173 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Version is the OpenAPI specification version the documents are generated for
+const Version = "3.0.3"
+
+// Document OpenAPI 3 document root
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	types map[reflect.Type]*Schema
+}
+
+// Info Document information
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag Operation group
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components Reusable document objects
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem Operations available on a single path, keyed by the lowercase http method
+type PathItem map[string]*Operation
+
+// Operation Single api operation on a path
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter Path or query parameter of an operation
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody Body accepted by an operation
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response Response returned by an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType Schema of a request or response content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// NewDocument Creates a new empty document
+func NewDocument(title string, description string, version string) *Document {
+	result := Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Tags:  make([]Tag, 0),
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+		types: make(map[reflect.Type]*Schema),
+	}
+
+	return &result
+}
+
+// AddTag Adds a tag to the document if it does not exist yet
+func (d *Document) AddTag(name string, description string) {
+	for _, tag := range d.Tags {
+		if tag.Name == name {
+			return
+		}
+	}
+
+	d.Tags = append(d.Tags, Tag{Name: name, Description: description})
+}
+
+// AddOperation Adds an operation to a path, any {name} segments in the path are added as required path parameters
+func (d *Document) AddOperation(method string, path string, operation *Operation) {
+	pathItem, exists := d.Paths[path]
+	if !exists {
+		item := make(PathItem)
+		pathItem = &item
+		d.Paths[path] = pathItem
+	}
+
+	parameters := make([]Parameter, 0)
+	for _, name := range PathParameters(path) {
+		parameters = append(parameters, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	operation.Parameters = append(parameters, operation.Parameters...)
+
+	(*pathItem)[strings.ToLower(method)] = operation
+}
+
+// Operation Gets the operation for a method and path, returns nil if it was not documented
+func (d *Document) Operation(method string, path string) *Operation {
+	pathItem, exists := d.Paths[path]
+	if !exists {
+		return nil
+	}
+
+	return (*pathItem)[strings.ToLower(method)]
+}
+
+// RequestSchema Gets the json schema of the body accepted by an operation, returns nil if it does not accept one
+func (o *Operation) RequestSchema() *Schema {
+	if o == nil || o.RequestBody == nil {
+		return nil
+	}
+
+	content, exists := o.RequestBody.Content["application/json"]
+	if !exists {
+		return nil
+	}
+
+	return content.Schema
+}
+
+// PathParameters Gets the names of the {name} segments of a path in order
+func PathParameters(path string) []string {
+	result := make([]string, 0)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.Trim(segment, "{}")
+			if index := strings.Index(name, ":"); index >= 0 {
+				name = name[:index]
+			}
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,171 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const componentsPrefix = "#/components/schemas/"
+
+// Schema OpenAPI schema object, only the subset of json schema used by the api is supported
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// SetSchema Sets the schema used for a type, this is needed for types with custom json marshalling
+func (d *Document) SetSchema(value interface{}, schema *Schema) {
+	d.types[reflect.TypeOf(value)] = schema
+}
+
+// SchemaOf Gets the schema of a value, structs are added to the document components and referenced by name
+//
+// Struct fields use their json tag name and can be annotated with an openapi tag, for example:
+//
+//	Name string `json:"name" openapi:"required,minLength=1"`
+//
+// the supported options are required, minLength=n and minimum=n
+func (d *Document) SchemaOf(value interface{}) *Schema {
+	if value == nil {
+		return &Schema{}
+	}
+
+	return d.schemaOfType(reflect.TypeOf(value))
+}
+
+// Resolve Gets the component schema referenced by a schema, schemas without a reference are returned as they are
+func (d *Document) Resolve(schema *Schema) *Schema {
+	if schema == nil || schema.Ref == "" {
+		return schema
+	}
+
+	resolved, exists := d.Components.Schemas[strings.TrimPrefix(schema.Ref, componentsPrefix)]
+	if !exists {
+		return &Schema{}
+	}
+
+	return resolved
+}
+
+func (d *Document) schemaOfType(t reflect.Type) *Schema {
+	if schema, exists := d.types[t]; exists {
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := d.schemaOfType(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		nullable := *schema
+		nullable.Nullable = true
+		return &nullable
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOfType(t.Elem())}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		return d.componentOf(t)
+	}
+
+	// interfaces and anything we do not know about accept any value
+	return &Schema{}
+}
+
+// componentOf adds a struct to the components and returns a reference to it
+func (d *Document) componentOf(t reflect.Type) *Schema {
+	reference := &Schema{Ref: componentsPrefix + t.Name()}
+	if _, exists := d.Components.Schemas[t.Name()]; exists {
+		return reference
+	}
+
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	// registering it before walking the fields allows recursive types
+	d.Components.Schemas[t.Name()] = schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		property := d.schemaOfType(field.Type)
+		for _, option := range strings.Split(field.Tag.Get("openapi"), ",") {
+			key, value := option, ""
+			if index := strings.Index(option, "="); index >= 0 {
+				key, value = option[:index], option[index+1:]
+			}
+
+			switch key {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "minLength":
+				if length, err := strconv.Atoi(value); err == nil {
+					property = withOption(property, func(s *Schema) { s.MinLength = &length })
+				}
+			case "minimum":
+				if minimum, err := strconv.ParseFloat(value, 64); err == nil {
+					property = withOption(property, func(s *Schema) { s.Minimum = &minimum })
+				}
+			}
+		}
+
+		schema.Properties[name] = property
+	}
+
+	return reference
+}
+
+// withOption returns a copy of the schema with the option applied so shared type schemas are not changed
+func withOption(schema *Schema, apply func(s *Schema)) *Schema {
+	result := *schema
+	apply(&result)
+	return &result
+}
@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/schema"
+)
+
+// ValidationError Field level error found validating a value against a schema, it is the same error the message
+// body schemas return
+type ValidationError = schema.ValidationError
+
+// Validate Validates a decoded json value against a schema returning all the errors found
+//
+// Property names are matched case insensitively and unknown properties are allowed to mirror
+// how encoding/json decodes the bodies into the entities, null values are treated as missing
+func (d *Document) Validate(schema *Schema, value interface{}) []ValidationError {
+	result := make([]ValidationError, 0)
+	d.validate(schema, value, "", &result)
+	return result
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, field string, result *[]ValidationError) {
+	schema = d.Resolve(schema)
+	if schema == nil || value == nil {
+		return
+	}
+
+	fail := func(message string) {
+		name := field
+		if name == "" {
+			name = "body"
+		}
+		*result = append(*result, ValidationError{Field: name, Message: message})
+	}
+
+	switch schema.Type {
+	case "object":
+		properties, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		d.validateObject(schema, properties, field, result)
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		for index, item := range items {
+			d.validate(schema.Items, item, field+"["+strconv.Itoa(index)+"]", result)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if schema.MinLength != nil && len(text) < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				fail("cannot be empty")
+			} else {
+				fail("must be at least " + strconv.Itoa(*schema.MinLength) + " characters long")
+			}
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				fail("must be a RFC3339 date time")
+			}
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok && schema.Type == "integer" {
+			fail("must be an integer")
+			return
+		}
+		if !ok {
+			fail("must be a number")
+			return
+		}
+		if schema.Type == "integer" {
+			if number != math.Trunc(number) {
+				fail("must be an integer")
+				return
+			}
+			if schema.Format == "int32" && (number > math.MaxInt32 || number < math.MinInt32) {
+				fail("must be a 32 bit integer")
+				return
+			}
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			fail("must be greater than or equal to " + strconv.FormatFloat(*schema.Minimum, 'f', -1, 64))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		allowed := make([]string, 0)
+		for _, option := range schema.Enum {
+			if option == value {
+				return
+			}
+			if text, ok := option.(string); ok {
+				allowed = append(allowed, text)
+			}
+		}
+		fail("must be one of " + strings.Join(allowed, ", "))
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, properties map[string]interface{}, field string, result *[]ValidationError) {
+	prefix := field
+	if prefix != "" {
+		prefix += "."
+	}
+
+	for _, name := range schema.Required {
+		if findProperty(properties, name) == nil {
+			*result = append(*result, ValidationError{Field: prefix + name, Message: "is required"})
+		}
+	}
+
+	// sorting the keys keeps the errors in a stable order
+	keys := make([]string, 0)
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propertySchema := schema.AdditionalProperties
+		name := key
+		for propertyName, candidate := range schema.Properties {
+			if strings.EqualFold(propertyName, key) {
+				propertySchema = candidate
+				name = propertyName
+				break
+			}
+		}
+		if propertySchema == nil {
+			continue
+		}
+		d.validate(propertySchema, properties[key], prefix+name, result)
+	}
+}
+
+// findProperty gets the value of a property ignoring the case of its name like encoding/json does
+func findProperty(properties map[string]interface{}, name string) interface{} {
+	if value, exists := properties[name]; exists {
+		return value
+	}
+	for key, value := range properties {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// colors of the json values, they are dropped when the output is not a terminal
+var (
+	keyColor     = color.New(color.FgHiBlue).SprintFunc()
+	stringColor  = color.New(color.FgGreen).SprintFunc()
+	numberColor  = color.New(color.FgHiYellow).SprintFunc()
+	literalColor = color.New(color.FgHiMagenta).SprintFunc()
+)
+
+// field is a key of an object written in a fixed order
+type field struct {
+	key   string
+	value interface{}
+}
+
+// writeJSON writes a value as colored json, on several lines when indent is set or on a single line otherwise.
+// A []field is written as an object keeping the order of its fields, the keys of maps are sorted
+func writeJSON(buffer *bytes.Buffer, value interface{}, indent bool, depth int) {
+	newLine := func(depth int) {
+		if indent {
+			buffer.WriteString("\n" + strings.Repeat("  ", depth))
+		}
+	}
+	separator := ":"
+	if indent {
+		separator = ": "
+	}
+
+	switch typed := value.(type) {
+	case []field:
+		if len(typed) == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		buffer.WriteString("{")
+		for i, item := range typed {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			newLine(depth + 1)
+			buffer.WriteString(keyColor(quote(item.key)) + separator)
+			writeJSON(buffer, item.value, indent, depth+1)
+		}
+		newLine(depth)
+		buffer.WriteString("}")
+	case map[string]interface{}:
+		keys := make([]string, 0)
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]field, 0)
+		for _, key := range keys {
+			fields = append(fields, field{key: key, value: typed[key]})
+		}
+		writeJSON(buffer, fields, indent, depth)
+	case []interface{}:
+		if len(typed) == 0 {
+			buffer.WriteString("[]")
+			return
+		}
+		buffer.WriteString("[")
+		for i, item := range typed {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			newLine(depth + 1)
+			writeJSON(buffer, item, indent, depth+1)
+		}
+		newLine(depth)
+		buffer.WriteString("]")
+	case string:
+		buffer.WriteString(stringColor(quote(typed)))
+	case bool, nil:
+		content, _ := json.Marshal(typed)
+		buffer.WriteString(literalColor(string(content)))
+	default:
+		content, err := json.Marshal(typed)
+		if err != nil {
+			buffer.WriteString(literalColor("null"))
+			return
+		}
+		if len(content) > 0 && content[0] == '"' {
+			buffer.WriteString(stringColor(string(content)))
+			return
+		}
+		buffer.WriteString(numberColor(string(content)))
+	}
+}
+
+// quote encodes a string as json without escaping the html characters
+func quote(value string) string {
+	var result bytes.Buffer
+	encoder := json.NewEncoder(&result)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimRight(result.String(), "\n")
+}
@@ -0,0 +1,281 @@
+package render
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/templating"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+var logger = log.Get()
+
+// Formats of the rendered messages
+const (
+	// FormatRaw prints the user properties as json and the body as it is
+	FormatRaw = "raw"
+	// FormatJSON prints the envelope of the message as colored json on several lines
+	FormatJSON = "json"
+	// FormatCompact prints the envelope of the message as colored json on a single line
+	FormatCompact = "compact"
+	// FormatTemplate prints the result of a go template, the template gets the envelope of the message
+	FormatTemplate = "template"
+)
+
+// Fields of the envelope that can be picked, in the order they are printed by default
+var Fields = []string{"id", "label", "correlationId", "contentType", "sequenceNumber", "deliveryCount", "enqueuedTime", "userProperties", "body"}
+
+// Renderer Prints the received messages in a format, and optionally appends them to a ndjson file in the
+// format written by the file sink. Messages are printed one at a time so the ones received concurrently are
+// not mixed up
+type Renderer struct {
+	Format string
+	Fields []string
+
+	extract  transform.Path
+	template *template.Template
+	output   *os.File
+	mutex    sync.Mutex
+}
+
+// NewRenderer Creates a renderer printing the messages in the raw format
+func NewRenderer() *Renderer {
+	return &Renderer{
+		Format: FormatRaw,
+		Fields: Fields,
+	}
+}
+
+// SetFormat Sets the format checking it is one of the known ones
+func (r *Renderer) SetFormat(format string) error {
+	switch format {
+	case FormatRaw, FormatJSON, FormatCompact, FormatTemplate:
+		r.Format = format
+		return nil
+	}
+	return fmt.Errorf("invalid format %v, use %v, %v, %v or %v", format, FormatRaw, FormatJSON, FormatCompact, FormatTemplate)
+}
+
+// SetFields Picks the fields of the envelope printed by the json and compact formats
+func (r *Renderer) SetFields(fields []string) error {
+	picked := make([]string, 0)
+	for _, name := range fields {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, field := range Fields {
+			if strings.EqualFold(field, name) {
+				picked = append(picked, field)
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid field %v, use %v", name, strings.Join(Fields, ", "))
+		}
+	}
+	if len(picked) == 0 {
+		return errors.New("no fields were picked")
+	}
+
+	r.Fields = picked
+	return nil
+}
+
+// SetExtract Prints only the value in a path of the json body instead of the whole body, the path can be like
+// .customer.id or $.customer.id
+func (r *Renderer) SetExtract(path string) error {
+	parsed, err := transform.ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	r.extract = parsed
+	return nil
+}
+
+// SetTemplate Sets the template of the template format, it gets the envelope of the message and the json and
+// hexdump functions
+func (r *Renderer) SetTemplate(text string) error {
+	funcs := templating.FormatFuncs()
+	funcs["hexdump"] = func(value string) string {
+		return hex.Dump([]byte(value))
+	}
+	parsed, err := template.New("message").Funcs(funcs).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	r.template = parsed
+	r.Format = FormatTemplate
+	return nil
+}
+
+// OpenOutput Appends the messages to a ndjson file as well, the file can be sent again with the file source
+func (r *Renderer) OpenOutput(filePath string) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	r.output = file
+	return nil
+}
+
+// Close Closes the ndjson file
+func (r *Renderer) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.output == nil {
+		return nil
+	}
+	err := r.output.Close()
+	r.output = nil
+	return err
+}
+
+// Print Prints a message, the header is only called by the raw and json formats to describe where the message
+// was received
+func (r *Renderer) Print(msg *servicebus.Message, header func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	envelope := entities.MessageEnvelope{}
+	envelope.FromServiceBus(msg)
+	if r.output != nil {
+		r.writeOutput(envelope)
+	}
+
+	binary := isBinary(msg.Data)
+	if r.extract != nil {
+		envelope.Body, _ = r.extract.Get(envelope.Body)
+	} else if binary && r.Format != FormatRaw {
+		envelope.Body = hex.EncodeToString(msg.Data)
+	}
+
+	switch r.Format {
+	case FormatJSON, FormatCompact:
+		if r.Format == FormatJSON && header != nil {
+			header()
+		}
+		var buffer bytes.Buffer
+		writeJSON(&buffer, r.fields(envelope), r.Format == FormatJSON, 0)
+		fmt.Println(buffer.String())
+	case FormatTemplate:
+		var buffer bytes.Buffer
+		if r.template == nil {
+			logger.Error("The template format needs a template")
+			return
+		}
+		if err := r.template.Execute(&buffer, envelope); err != nil {
+			logger.LogHighlight("Could not render message %v, %v", log.Error, msg.ID, err.Error())
+			return
+		}
+		fmt.Println(buffer.String())
+	default:
+		if header != nil {
+			header()
+		}
+		logger.Info("User Properties:")
+		jsonString, _ := json.MarshalIndent(msg.UserProperties, "", "  ")
+		fmt.Println(string(jsonString))
+		logger.Info("Message Body:")
+		switch {
+		case r.extract != nil:
+			fmt.Println(plain(envelope.Body))
+		case binary:
+			fmt.Print(hex.Dump(msg.Data))
+		default:
+			fmt.Println(string(msg.Data))
+		}
+	}
+}
+
+// fields gets the picked fields of the envelope in order, empty properties are left out
+func (r *Renderer) fields(envelope entities.MessageEnvelope) []field {
+	result := make([]field, 0)
+	for _, name := range r.Fields {
+		var value interface{}
+		switch name {
+		case "id":
+			value = envelope.ID
+		case "label":
+			value = envelope.Label
+		case "correlationId":
+			value = envelope.CorrelationID
+		case "contentType":
+			value = envelope.ContentType
+		case "sequenceNumber":
+			if envelope.SequenceNumber != 0 {
+				value = envelope.SequenceNumber
+			}
+		case "deliveryCount":
+			value = envelope.DeliveryCount
+		case "enqueuedTime":
+			if envelope.EnqueuedTime != nil {
+				value = envelope.EnqueuedTime.Format(time.RFC3339Nano)
+			}
+		case "userProperties":
+			if len(envelope.UserProperties) > 0 {
+				value = envelope.UserProperties
+			}
+		case "body":
+			result = append(result, field{key: name, value: envelope.Body})
+			continue
+		}
+		if value != nil && value != "" {
+			result = append(result, field{key: name, value: value})
+		}
+	}
+	return result
+}
+
+// writeOutput appends the envelope to the ndjson file, failures are only logged so the messages keep printing
+func (r *Renderer) writeOutput(envelope entities.MessageEnvelope) {
+	content, err := json.Marshal(envelope)
+	if err == nil {
+		_, err = r.output.Write(append(content, '\n'))
+	}
+	if err != nil {
+		logger.LogHighlight("Could not write message %v to %v, %v", log.Error, envelope.ID, r.output.Name(), err.Error())
+	}
+}
+
+// isBinary checks if a body is not text, text bodies are valid utf8 without control characters besides the
+// white spaces
+func isBinary(data []byte) bool {
+	if !utf8.Valid(data) {
+		return true
+	}
+	for _, character := range string(data) {
+		if unicode.IsControl(character) && !unicode.IsSpace(character) {
+			return true
+		}
+	}
+	return false
+}
+
+// plain gets a value as text, strings are printed without quotes like jq -r
+func plain(value interface{}) string {
+	if text, isText := value.(string); isText {
+		return text
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
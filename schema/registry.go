@@ -0,0 +1,195 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/helper"
+	"gopkg.in/yaml.v2"
+)
+
+// RegistryFile is the index of the schemas in the registry folder
+const RegistryFile = "registry.yaml"
+
+// Registry Schemas of the message bodies of the queues and topics, loaded from a folder with the json schema files
+// and a registry.yaml index, for example:
+//
+//	schemas:
+//	  - queue: orders
+//	    label: OrderCreated
+//	    schema: order-created.json
+//	  - topic: payments
+//	    contentType: application/json
+//	    schema: payment.json
+//
+// The first entry matching the queue or topic, label and content type of a message is used, entries without a
+// label or content type match any of them. Messages received from a subscription use the schemas of its topic
+type Registry struct {
+	Dir     string  `yaml:"-"`
+	Schemas []Entry `yaml:"schemas"`
+}
+
+// Entry Schema used for the messages of a queue or topic
+type Entry struct {
+	Queue       string `yaml:"queue"`
+	Topic       string `yaml:"topic"`
+	Label       string `yaml:"label"`
+	ContentType string `yaml:"contentType"`
+	File        string `yaml:"schema"`
+
+	schema *Schema
+}
+
+// Error Message body that does not match the schema of its queue or topic
+type Error struct {
+	Target string
+	File   string
+	Errors []ValidationError
+}
+
+// Error Describes the validation errors
+func (e *Error) Error() string {
+	details := make([]string, 0)
+	for _, validationError := range e.Errors {
+		details = append(details, validationError.Field+" "+validationError.Message)
+	}
+
+	return fmt.Sprintf("message does not match the schema %v of %v, %v", e.File, e.Target, strings.Join(details, ", "))
+}
+
+var defaultRegistry struct {
+	once     sync.Once
+	registry *Registry
+	err      error
+}
+
+// DefaultRegistry Gets the registry of the folder in the --schemas flag or the SERVICEBUS_SCHEMA_REGISTRY
+// environment variable, it is loaded once and is nil if neither is set
+func DefaultRegistry() (*Registry, error) {
+	defaultRegistry.once.Do(func() {
+		dir := helper.GetFlagValue("schemas", os.Getenv("SERVICEBUS_SCHEMA_REGISTRY"))
+		if dir != "" {
+			defaultRegistry.registry, defaultRegistry.err = LoadRegistry(dir)
+		}
+	})
+
+	return defaultRegistry.registry, defaultRegistry.err
+}
+
+// LoadRegistry Reads the index of a registry folder and the schemas it references
+func LoadRegistry(dir string) (*Registry, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, RegistryFile))
+	if err != nil {
+		return nil, err
+	}
+
+	registry := Registry{}
+	if err := yaml.UnmarshalStrict(content, &registry); err != nil {
+		return nil, err
+	}
+	registry.Dir = dir
+
+	if err := registry.Validate(); err != nil {
+		return nil, err
+	}
+	return &registry, nil
+}
+
+// Validate Checks the entries and loads their schemas
+func (r *Registry) Validate() error {
+	if len(r.Schemas) == 0 {
+		return errors.New("the registry has no schemas")
+	}
+
+	for i := range r.Schemas {
+		entry := &r.Schemas[i]
+		if (entry.Queue == "") == (entry.Topic == "") {
+			return fmt.Errorf("schema %v needs either a queue or a topic", i+1)
+		}
+		if entry.File == "" {
+			return fmt.Errorf("schema %v for %v needs the schema file", i+1, entry.Target())
+		}
+
+		schema, err := Load(filepath.Join(r.Dir, entry.File))
+		if err != nil {
+			return fmt.Errorf("could not load the schema %v for %v, %v", entry.File, entry.Target(), err.Error())
+		}
+		entry.schema = schema
+	}
+
+	return nil
+}
+
+// Target Gets the description of the queue or topic of the entry
+func (e *Entry) Target() string {
+	if e.Queue != "" {
+		return "queue " + e.Queue
+	}
+	return "topic " + e.Topic
+}
+
+// Schema Gets the loaded schema of the entry
+func (e *Entry) Schema() *Schema {
+	return e.schema
+}
+
+// Find Gets the first entry of the queue or topic matching the label and content type, it returns nil if there
+// is none
+func (r *Registry) Find(queue string, topic string, label string, contentType string) *Entry {
+	if r == nil {
+		return nil
+	}
+
+	for i := range r.Schemas {
+		entry := &r.Schemas[i]
+		if (queue == "" || entry.Queue != queue) && (topic == "" || entry.Topic != topic) {
+			continue
+		}
+		if entry.Label != "" && entry.Label != label {
+			continue
+		}
+		if entry.ContentType != "" && !sameMediaType(entry.ContentType, contentType) {
+			continue
+		}
+		return entry
+	}
+
+	return nil
+}
+
+// Check Validates the body of a message sent to or received from a queue or topic, it returns nil if the message
+// matches its schema or there is no schema for it
+func (r *Registry) Check(queue string, topic string, msg *servicebus.Message) *Error {
+	entry := r.Find(queue, topic, msg.Label, msg.ContentType)
+	if entry == nil {
+		return nil
+	}
+
+	validationErrors := entry.schema.ValidateJSON(msg.Data)
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return &Error{
+		Target: entry.Target(),
+		File:   entry.File,
+		Errors: validationErrors,
+	}
+}
+
+// sameMediaType compares two content types ignoring their parameters, such as the charset
+func sameMediaType(a string, b string) bool {
+	mediaA, _, errA := mime.ParseMediaType(a)
+	mediaB, _, errB := mime.ParseMediaType(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+
+	return mediaA == mediaB
+}
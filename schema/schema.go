@@ -0,0 +1,285 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema Json schema of a message body, the validation keywords of draft 7 are supported with references limited
+// to the definitions of the same file, for example #/definitions/address or #/$defs/address. Schemas with keywords
+// that are not supported are rejected when they are loaded, so a payload is never accepted because a keyword was
+// ignored
+type Schema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 Types                  `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Const                json.RawMessage        `json:"const"`
+	Format               string                 `json:"format"`
+	Pattern              string                 `json:"pattern"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum"`
+	MultipleOf           *float64               `json:"multipleOf"`
+	Properties           map[string]*Schema     `json:"properties"`
+	PatternProperties    map[string]*Schema     `json:"patternProperties"`
+	PropertyNames        *Schema                `json:"propertyNames"`
+	Required             []string               `json:"required"`
+	Dependencies         map[string]*Dependency `json:"dependencies"`
+	AdditionalProperties *Schema                `json:"additionalProperties"`
+	MinProperties        *int                   `json:"minProperties"`
+	MaxProperties        *int                   `json:"maxProperties"`
+	Items                *Items                 `json:"items"`
+	AdditionalItems      *Schema                `json:"additionalItems"`
+	Contains             *Schema                `json:"contains"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	UniqueItems          bool                   `json:"uniqueItems"`
+	If                   *Schema                `json:"if"`
+	Then                 *Schema                `json:"then"`
+	Else                 *Schema                `json:"else"`
+	AllOf                []*Schema              `json:"allOf"`
+	AnyOf                []*Schema              `json:"anyOf"`
+	OneOf                []*Schema              `json:"oneOf"`
+	Not                  *Schema                `json:"not"`
+	Definitions          map[string]*Schema     `json:"definitions"`
+	Defs                 map[string]*Schema     `json:"$defs"`
+
+	// never is set for the false schema, which no value matches
+	never            bool
+	pattern          *regexp.Regexp
+	propertyPatterns map[string]*regexp.Regexp
+}
+
+// annotations are the keywords that do not change the validation, they are allowed but ignored
+var annotations = map[string]bool{
+	"$schema":          true,
+	"$id":              true,
+	"$comment":         true,
+	"title":            true,
+	"description":      true,
+	"default":          true,
+	"examples":         true,
+	"readOnly":         true,
+	"writeOnly":        true,
+	"contentEncoding":  true,
+	"contentMediaType": true,
+}
+
+// keywords are the json names of the fields of a schema
+var keywords = func() map[string]bool {
+	result := make(map[string]bool)
+	fields := reflect.TypeOf(Schema{})
+	for i := 0; i < fields.NumField(); i++ {
+		if name := strings.Split(fields.Field(i).Tag.Get("json"), ",")[0]; name != "" {
+			result[name] = true
+		}
+	}
+	return result
+}()
+
+// Items Schema of the items of an array, a single schema for every item or a list with the schema of every
+// position, the items past the list use additionalItems
+type Items struct {
+	Schema    *Schema
+	Positions []*Schema
+}
+
+// UnmarshalJSON Reads a single schema or a list of them
+func (i *Items) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, &i.Positions)
+	}
+	return json.Unmarshal(data, &i.Schema)
+}
+
+// Dependency Properties required or schema the object has to match when a property is present
+type Dependency struct {
+	Properties []string
+	Schema     *Schema
+}
+
+// UnmarshalJSON Reads a list of property names or a schema
+func (d *Dependency) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, &d.Properties)
+	}
+	return json.Unmarshal(data, &d.Schema)
+}
+
+// Types Types allowed by a schema, written as a single type or a list of them
+type Types []string
+
+// UnmarshalJSON Reads a single type or a list of types
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("type must be a string or a list of strings")
+	}
+	*t = list
+	return nil
+}
+
+// UnmarshalJSON Reads a schema, true and false are the schemas any and no value matches
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{never: true}
+		return nil
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return err
+	}
+	unsupported := make([]string, 0)
+	for keyword := range present {
+		if !keywords[keyword] && !annotations[keyword] {
+			unsupported = append(unsupported, keyword)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return fmt.Errorf("the schema keywords %v are not supported", strings.Join(unsupported, ", "))
+	}
+
+	// the alias type does not have this method so the fields are decoded as usual
+	type schemaFields Schema
+	fields := schemaFields{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+	*s = Schema(fields)
+	return nil
+}
+
+// Load Reads a schema from a json file
+func Load(filePath string) (*Schema, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(content)
+}
+
+// Parse Reads a schema and checks its patterns and references
+func Parse(content []byte) (*Schema, error) {
+	schema := Schema{}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, err
+	}
+
+	if err := schema.compile(&schema, "#"); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// compile compiles the patterns and checks the references of the schema and its subschemas
+func (s *Schema) compile(root *Schema, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	if s.Ref != "" && root.resolve(s.Ref) == nil {
+		return fmt.Errorf("%v references %v, only the definitions of the same file can be referenced", path, s.Ref)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%v has an invalid pattern, %v", path, err.Error())
+		}
+		s.pattern = pattern
+	}
+	for expression := range s.PatternProperties {
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return fmt.Errorf("%v has an invalid pattern property %v, %v", path, expression, err.Error())
+		}
+		if s.propertyPatterns == nil {
+			s.propertyPatterns = make(map[string]*regexp.Regexp)
+		}
+		s.propertyPatterns[expression] = pattern
+	}
+
+	children := map[string]*Schema{
+		path + "/additionalProperties": s.AdditionalProperties,
+		path + "/propertyNames":        s.PropertyNames,
+		path + "/additionalItems":      s.AdditionalItems,
+		path + "/contains":             s.Contains,
+		path + "/if":                   s.If,
+		path + "/then":                 s.Then,
+		path + "/else":                 s.Else,
+		path + "/not":                  s.Not,
+	}
+	if s.Items != nil {
+		children[path+"/items"] = s.Items.Schema
+		for index, child := range s.Items.Positions {
+			children[fmt.Sprintf("%v/items/%v", path, index)] = child
+		}
+	}
+	for name, child := range s.Properties {
+		children[path+"/properties/"+name] = child
+	}
+	for expression, child := range s.PatternProperties {
+		children[path+"/patternProperties/"+expression] = child
+	}
+	for name, dependency := range s.Dependencies {
+		if dependency != nil {
+			children[path+"/dependencies/"+name] = dependency.Schema
+		}
+	}
+	for name, child := range s.Definitions {
+		children[path+"/definitions/"+name] = child
+	}
+	for name, child := range s.Defs {
+		children[path+"/$defs/"+name] = child
+	}
+	for keyword, list := range map[string][]*Schema{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf} {
+		for index, child := range list {
+			children[fmt.Sprintf("%v/%v/%v", path, keyword, index)] = child
+		}
+	}
+
+	for childPath, child := range children {
+		if err := child.compile(root, childPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve gets the schema of a reference, it returns nil if it can not be found
+func (s *Schema) resolve(ref string) *Schema {
+	if ref == "#" {
+		return s
+	}
+
+	for prefix, definitions := range map[string]map[string]*Schema{"#/definitions/": s.Definitions, "#/$defs/": s.Defs} {
+		if strings.HasPrefix(ref, prefix) {
+			return definitions[strings.TrimPrefix(ref, prefix)]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidationError Field level error found validating a message body against a schema
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidateJSON Validates a json message body returning all the errors found, a body that is not json is an
+// error of the whole body
+func (s *Schema) ValidateJSON(data []byte) []ValidationError {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return []ValidationError{{Field: "body", Message: "must be valid json"}}
+	}
+
+	return s.Validate(value)
+}
+
+// Validate Validates a decoded json value, numbers can be decoded as json numbers or floats
+func (s *Schema) Validate(value interface{}) []ValidationError {
+	result := make([]ValidationError, 0)
+	s.validate(s, value, "", &result)
+	return result
+}
+
+func (s *Schema) validate(root *Schema, value interface{}, field string, result *[]ValidationError) {
+	if s == nil {
+		return
+	}
+
+	fail := func(message string) {
+		name := field
+		if name == "" {
+			name = "body"
+		}
+		*result = append(*result, ValidationError{Field: name, Message: message})
+	}
+
+	if s.never {
+		fail("is not allowed")
+		return
+	}
+	if s.Ref != "" {
+		root.resolve(s.Ref).validate(root, value, field, result)
+	}
+
+	if len(s.Type) > 0 && !s.Type.allow(value) {
+		fail("must be " + s.Type.String())
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, option := range s.Enum {
+			if equal(option, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of " + list(s.Enum))
+		}
+	}
+	if len(s.Const) > 0 {
+		var constant interface{}
+		decoder := json.NewDecoder(bytes.NewReader(s.Const))
+		decoder.UseNumber()
+		if decoder.Decode(&constant) == nil && !equal(constant, value) {
+			fail("must be " + string(s.Const))
+		}
+	}
+
+	switch typed := value.(type) {
+	case string:
+		s.validateString(typed, fail)
+	case json.Number, float64:
+		s.validateNumber(number(typed), fail)
+	case map[string]interface{}:
+		s.validateObject(root, typed, field, result, fail)
+	case []interface{}:
+		s.validateArray(root, typed, field, result, fail)
+	}
+
+	if s.If != nil {
+		if s.If.matches(root, value, field) {
+			s.Then.validate(root, value, field, result)
+		} else {
+			s.Else.validate(root, value, field, result)
+		}
+	}
+	for _, child := range s.AllOf {
+		child.validate(root, value, field, result)
+	}
+	if len(s.AnyOf) > 0 {
+		matches := 0
+		for _, child := range s.AnyOf {
+			if child.matches(root, value, field) {
+				matches++
+				break
+			}
+		}
+		if matches == 0 {
+			fail("must match at least one of the anyOf schemas")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, child := range s.OneOf {
+			if child.matches(root, value, field) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			fail("must match exactly one of the oneOf schemas, it matches " + strconv.Itoa(matches))
+		}
+	}
+	if s.Not != nil && s.Not.matches(root, value, field) {
+		fail("must not match the not schema")
+	}
+}
+
+// matches returns true if the value has no errors against the schema
+func (s *Schema) matches(root *Schema, value interface{}, field string) bool {
+	errors := make([]ValidationError, 0)
+	s.validate(root, value, field, &errors)
+	return len(errors) == 0
+}
+
+func (s *Schema) validateString(text string, fail func(string)) {
+	length := utf8.RuneCountInString(text)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			fail("cannot be empty")
+		} else {
+			fail("must be at least " + strconv.Itoa(*s.MinLength) + " characters long")
+		}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		fail("must be at most " + strconv.Itoa(*s.MaxLength) + " characters long")
+	}
+	if s.pattern != nil && !s.pattern.MatchString(text) {
+		fail("must match the pattern " + s.Pattern)
+	}
+
+	valid := true
+	switch s.Format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, text)
+		valid = err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", text)
+		valid = err == nil
+	case "email":
+		address, err := mail.ParseAddress(text)
+		valid = err == nil && address.Address == text
+	case "uri":
+		parsed, err := url.Parse(text)
+		valid = err == nil && parsed.IsAbs()
+	case "uuid":
+		valid = uuidPattern.MatchString(text)
+	}
+	if !valid {
+		fail("must be a valid " + s.Format)
+	}
+}
+
+func (s *Schema) validateNumber(value float64, fail func(string)) {
+	if s.Minimum != nil && value < *s.Minimum {
+		fail("must be greater than or equal to " + format(*s.Minimum))
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		fail("must be less than or equal to " + format(*s.Maximum))
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		fail("must be greater than " + format(*s.ExclusiveMinimum))
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		fail("must be less than " + format(*s.ExclusiveMaximum))
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		quotient := value / *s.MultipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			fail("must be a multiple of " + format(*s.MultipleOf))
+		}
+	}
+}
+
+func (s *Schema) validateObject(root *Schema, properties map[string]interface{}, field string, result *[]ValidationError, fail func(string)) {
+	prefix := field
+	if prefix != "" {
+		prefix += "."
+	}
+
+	for _, name := range s.Required {
+		if _, exists := properties[name]; !exists {
+			*result = append(*result, ValidationError{Field: prefix + name, Message: "is required"})
+		}
+	}
+	for _, name := range sortedKeys(s.Dependencies) {
+		dependency := s.Dependencies[name]
+		if _, exists := properties[name]; !exists || dependency == nil {
+			continue
+		}
+		for _, required := range dependency.Properties {
+			if _, exists := properties[required]; !exists {
+				*result = append(*result, ValidationError{Field: prefix + required, Message: "is required when " + name + " is present"})
+			}
+		}
+		dependency.Schema.validate(root, properties, field, result)
+	}
+	if s.MinProperties != nil && len(properties) < *s.MinProperties {
+		fail("must have at least " + strconv.Itoa(*s.MinProperties) + " properties")
+	}
+	if s.MaxProperties != nil && len(properties) > *s.MaxProperties {
+		fail("must have at most " + strconv.Itoa(*s.MaxProperties) + " properties")
+	}
+
+	// sorting the keys keeps the errors in a stable order
+	keys := make([]string, 0)
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if s.PropertyNames != nil && !s.PropertyNames.matches(root, key, prefix+key) {
+			*result = append(*result, ValidationError{Field: prefix + key, Message: "is not an allowed property name"})
+		}
+
+		propertySchema, exists := s.Properties[key]
+		for _, expression := range sortedKeys(s.PatternProperties) {
+			if s.propertyPatterns[expression].MatchString(key) {
+				exists = true
+				s.PatternProperties[expression].validate(root, properties[key], prefix+key, result)
+			}
+		}
+		if !exists {
+			propertySchema = s.AdditionalProperties
+		}
+		if propertySchema != nil && propertySchema.never && !exists {
+			*result = append(*result, ValidationError{Field: prefix + key, Message: "is not an allowed property"})
+			continue
+		}
+		propertySchema.validate(root, properties[key], prefix+key, result)
+	}
+}
+
+func (s *Schema) validateArray(root *Schema, items []interface{}, field string, result *[]ValidationError, fail func(string)) {
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		fail("must have at least " + strconv.Itoa(*s.MinItems) + " items")
+	}
+	if s.MaxItems != nil && len(items) > *s.MaxItems {
+		fail("must have at most " + strconv.Itoa(*s.MaxItems) + " items")
+	}
+	if s.UniqueItems {
+	unique:
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if equal(items[i], items[j]) {
+					fail("must have unique items, items " + strconv.Itoa(i) + " and " + strconv.Itoa(j) + " are equal")
+					break unique
+				}
+			}
+		}
+	}
+
+	if s.Contains != nil {
+		found := false
+		for index, item := range items {
+			if s.Contains.matches(root, item, field+"["+strconv.Itoa(index)+"]") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must contain an item matching the contains schema")
+		}
+	}
+
+	if s.Items == nil {
+		return
+	}
+	for index, item := range items {
+		itemSchema := s.Items.Schema
+		if s.Items.Positions != nil {
+			itemSchema = s.AdditionalItems
+			if index < len(s.Items.Positions) {
+				itemSchema = s.Items.Positions[index]
+			} else if itemSchema != nil && itemSchema.never {
+				fail("must have at most " + strconv.Itoa(len(s.Items.Positions)) + " items")
+				return
+			}
+		}
+		itemSchema.validate(root, item, field+"["+strconv.Itoa(index)+"]", result)
+	}
+}
+
+// sortedKeys gets the keys of a map sorted, so the errors are in a stable order
+func sortedKeys(values interface{}) []string {
+	keys := make([]string, 0)
+	for _, key := range reflect.ValueOf(values).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// allow returns true if the value is of one of the types
+func (t Types) allow(value interface{}) bool {
+	for _, name := range t {
+		switch typed := value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case json.Number, float64:
+			if name == "number" || (name == "integer" && number(typed) == math.Trunc(number(typed))) {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String Describes the types for the validation messages
+func (t Types) String() string {
+	names := make([]string, 0)
+	for _, name := range t {
+		switch name {
+		case "array", "integer", "object":
+			names = append(names, "an "+name)
+		case "null":
+			names = append(names, name)
+		default:
+			names = append(names, "a "+name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// number gets the value of a json number or float
+func number(value interface{}) float64 {
+	if typed, ok := value.(json.Number); ok {
+		result, _ := typed.Float64()
+		return result
+	}
+	result, _ := value.(float64)
+	return result
+}
+
+// equal compares two decoded json values, numbers are equal if they have the same value
+func equal(a interface{}, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// normalize converts the numbers of a decoded json value to floats
+func normalize(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		return number(typed)
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range typed {
+			result[key] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0)
+		for _, item := range typed {
+			result = append(result, normalize(item))
+		}
+		return result
+	}
+	return value
+}
+
+// list writes the enum options for the validation messages
+func list(options []interface{}) string {
+	result := make([]string, 0)
+	for _, option := range options {
+		content, _ := json.Marshal(option)
+		result = append(result, string(content))
+	}
+	return strings.Join(result, ", ")
+}
+
+func format(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,158 @@
+package servicebus
+
+import (
+	"context"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+)
+
+// browsePollInterval is the wait between peeks once a browse reached the newest message
+const browsePollInterval = time.Second
+
+// browse follows a queue or subscription by sequence number with the peek iterator, calling handle with every new
+// message without ever locking it, until the context is cancelled or handle fails. Every peek starts after the last
+// message seen, the messages already in the backlog are skipped when starting from the tail
+func browse(ctx context.Context, entity peeker, fromTail bool, handle func(msg *servicebus.Message) error) error {
+	last := int64(-1)
+	skip := fromTail
+	for {
+		peekOptions := []servicebus.PeekOption{servicebus.PeekWithPageSize(searchPageSize)}
+		if last >= 0 {
+			peekOptions = append(peekOptions, servicebus.PeekFromSequenceNumber(last))
+		}
+
+		iterator, err := entity.Peek(ctx, peekOptions...)
+		observeOperation(OperationReceive, err)
+		if err != nil {
+			return err
+		}
+
+		for {
+			msg, err := iterator.Next(ctx)
+			if _, noMessages := err.(servicebus.ErrNoMessages); noMessages {
+				break
+			}
+			if err != nil {
+				observeOperation(OperationReceive, err)
+				return err
+			}
+
+			number := sequenceNumber(msg)
+			if number <= last {
+				continue
+			}
+			last = number
+			if skip {
+				continue
+			}
+			if err := handle(msg); err != nil {
+				return err
+			}
+		}
+		skip = false
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(browsePollInterval):
+		}
+	}
+}
+
+// peekBatch peeks up to qty messages from the oldest one, it stops early when there are no more messages as they can
+// be received by someone else after they were counted
+func peekBatch(ctx context.Context, entity peeker, qty int) ([]servicebus.Message, error) {
+	messages := make([]servicebus.Message, 0)
+	iterator, err := entity.Peek(ctx)
+	observeOperation(OperationReceive, err)
+	if err != nil {
+		return messages, err
+	}
+
+	for len(messages) < qty {
+		msg, err := iterator.Next(ctx)
+		if _, noMessages := err.(servicebus.ErrNoMessages); noMessages {
+			break
+		}
+		if err != nil {
+			observeOperation(OperationReceive, err)
+			return messages, err
+		}
+		messages = append(messages, *msg)
+	}
+
+	return messages, nil
+}
+
+// receiveAndAbandon receives up to qty messages abandoning each one straight away, it stops early when no message
+// arrives within the idle timeout as they can be received by someone else after they were counted
+func receiveAndAbandon(ctx context.Context, receiver servicebus.ReceiveOner, qty int) ([]servicebus.Message, error) {
+	messages := make([]servicebus.Message, 0)
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		messages = append(messages, *msg)
+		return msg.Abandon(msgCtx)
+	}
+
+	for len(messages) < qty {
+		receiveCtx, cancel := context.WithTimeout(ctx, drainIdleTimeout)
+		err := receiveOne(receiveCtx, receiver, handler)
+		idle := receiveCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if idle {
+			break
+		}
+		if err != nil {
+			return messages, err
+		}
+	}
+
+	return messages, nil
+}
+
+// browseUntilClosed browses a queue or subscription for a peeking subscribe command until its listener is closed,
+// printing the new messages. Browsed messages are never locked, so their delivery count stays the same, and the
+// ones past the maximum number of messages are not printed
+func (s *ServiceBusCli) browseUntilClosed(ctx context.Context, entity peeker, closeListener chan bool, printMessage func(msg *servicebus.Message)) error {
+	browseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer trackListener()()
+
+	browsed := make(chan error, 1)
+	go func() {
+		browsed <- browse(browseCtx, entity, s.Tail, func(msg *servicebus.Message) error {
+			if s.Limits.take() {
+				printMessage(msg)
+				s.Limits.done()
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-browsed:
+		return err
+	case <-closeListener:
+		cancel()
+		<-browsed
+		return nil
+	}
+}
+
+// BrowseEndpointMessages Follows the queue or subscription of the endpoint calling the handler for every message
+// arriving until the context is cancelled, the messages already in the backlog are skipped when starting from the
+// tail. The messages are peeked so they are never locked or removed
+func (s *ServiceBusCli) BrowseEndpointMessages(ctx context.Context, endpoint *Endpoint, fromTail bool, handler func(msg *servicebus.Message) error) error {
+	entity, err := s.getEndpointPeeker(endpoint, false)
+	if err != nil {
+		return err
+	}
+	defer entity.Close(context.Background())
+	defer trackListener()()
+
+	logger.LogHighlight("Browsing the messages of %v in service bus %v", log.Info, endpoint.String(), s.Namespace.Name)
+	err = browse(ctx, entity, fromTail, handler)
+	logger.LogHighlight("Stopped browsing the messages of %v in service bus %v", log.Info, endpoint.String(), s.Namespace.Name)
+	return err
+}
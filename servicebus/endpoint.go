@@ -0,0 +1,102 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+)
+
+// Endpoint Queue, topic or topic subscription referenced in the command line as queue:orders, topic:orders or
+// topic:orders/sub:audit
+type Endpoint struct {
+	Queue        string
+	Topic        string
+	Subscription string
+}
+
+// ParseEndpoint Parses an endpoint reference, subscriptions can be written as sub: or subscription:
+func ParseEndpoint(value string) (*Endpoint, error) {
+	endpoint := Endpoint{}
+	for _, part := range strings.Split(value, "/") {
+		kind, name := "", ""
+		if index := strings.Index(part, ":"); index > 0 {
+			kind, name = strings.ToLower(part[:index]), strings.TrimSpace(part[index+1:])
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid endpoint %v, use queue:name, topic:name or topic:name/sub:name", value)
+		}
+
+		switch {
+		case kind == "queue" && endpoint.Queue == "" && endpoint.Topic == "":
+			endpoint.Queue = name
+		case kind == "topic" && endpoint.Queue == "" && endpoint.Topic == "":
+			endpoint.Topic = name
+		case (kind == "sub" || kind == "subscription") && endpoint.Topic != "" && endpoint.Subscription == "":
+			endpoint.Subscription = name
+		default:
+			return nil, fmt.Errorf("invalid endpoint %v, use queue:name, topic:name or topic:name/sub:name", value)
+		}
+	}
+
+	return &endpoint, nil
+}
+
+// CanReceive Returns true if messages can be received from the endpoint, topics need a subscription
+func (e *Endpoint) CanReceive() bool {
+	return e.Queue != "" || e.Subscription != ""
+}
+
+// CanSend Returns true if messages can be sent to the endpoint, subscriptions can not be sent to
+func (e *Endpoint) CanSend() bool {
+	return e.Subscription == ""
+}
+
+// FeedsBack Returns true if the messages sent to the other endpoint come back to the queue or subscription of this
+// one, a queue sending to itself or a subscription sending to its own topic. Entity names are not case sensitive
+func (e *Endpoint) FeedsBack(to *Endpoint) bool {
+	if e.Queue != "" {
+		return strings.EqualFold(e.Queue, to.Queue)
+	}
+	return e.Topic != "" && strings.EqualFold(e.Topic, to.Topic)
+}
+
+// String Gets the description of the endpoint
+func (e *Endpoint) String() string {
+	switch {
+	case e.Queue != "":
+		return "queue " + e.Queue
+	case e.Subscription != "":
+		return "subscription " + e.Subscription + " on topic " + e.Topic
+	default:
+		return "topic " + e.Topic
+	}
+}
+
+// StreamEndpointMessages Listens to the queue or subscription of the endpoint calling the handler for every message
+// until the context is cancelled, the handler is responsible for settling the messages
+func (s *ServiceBusCli) StreamEndpointMessages(ctx context.Context, endpoint *Endpoint, handler servicebus.HandlerFunc) error {
+	if !endpoint.CanReceive() {
+		return errors.New("can not receive messages from " + endpoint.String() + ", topics need a subscription")
+	}
+
+	if endpoint.Queue != "" {
+		return s.StreamQueueMessages(ctx, endpoint.Queue, handler)
+	}
+	return s.StreamSubscriptionMessages(ctx, endpoint.Topic, endpoint.Subscription, handler)
+}
+
+// GetEndpointSender Gets a sender for the queue or topic of the endpoint, the connection is kept open until the
+// sender is closed
+func (s *ServiceBusCli) GetEndpointSender(endpoint *Endpoint) (MessageSender, error) {
+	if !endpoint.CanSend() {
+		return nil, errors.New("can not send messages to " + endpoint.String() + ", send them to its topic")
+	}
+
+	if endpoint.Queue != "" {
+		return s.GetQueueSender(endpoint.Queue)
+	}
+	return s.GetTopicSender(endpoint.Topic)
+}
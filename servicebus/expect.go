@@ -0,0 +1,122 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/filter"
+)
+
+// errExpectMatched stops browsing once the expected message was found
+var errExpectMatched = errors.New("the expected message was found")
+
+// ExpectOptions Options of waiting for a message in a queue or subscription
+type ExpectOptions struct {
+	// Where is the expression the message needs to match, any message matches an empty expression
+	Where *filter.Expression
+	// Within is how long to wait for the message
+	Within time.Duration
+	// Consume receives and completes the matching message, otherwise the backlog is peeked and left as it is
+	Consume bool
+	// DeadLetter waits for the message in the dead letter queue instead of the active messages
+	DeadLetter bool
+}
+
+// ExpectMessage Waits for a message matching the expression in a queue or subscription, returning nil when none
+// arrived in time.
+//
+// Messages already in the backlog count as arrived. The backlog is browsed from the last message seen, consuming
+// completes the match by its sequence number locking and abandoning the messages in front of it, which increases
+// their delivery count
+func (s *ServiceBusCli) ExpectMessage(ctx context.Context, endpoint *Endpoint, options ExpectOptions) (*servicebus.Message, error) {
+	if !endpoint.CanReceive() {
+		return nil, errors.New("can not receive messages from " + endpoint.String() + ", topics need a subscription")
+	}
+	if options.Within <= 0 {
+		return nil, errors.New("the time to wait for the message needs to be positive")
+	}
+
+	expectCtx, cancel := context.WithTimeout(ctx, options.Within)
+	defer cancel()
+
+	logger.LogHighlight("Expecting a message in %v of service bus %v within %v", log.Info, endpoint.String(), s.Namespace.Name, options.Within.String())
+	var msg *servicebus.Message
+	var err error
+	if options.Consume {
+		msg, err = s.expectReceived(expectCtx, endpoint, options)
+	} else {
+		msg, err = s.expectPeeked(expectCtx, endpoint, options)
+	}
+
+	// running out of time is the expected way of not finding the message, only the caller cancelling is an error
+	if err != nil && expectCtx.Err() != nil && ctx.Err() == nil {
+		return nil, nil
+	}
+	return msg, err
+}
+
+// expectPeeked browses the backlog until a message matches
+func (s *ServiceBusCli) expectPeeked(ctx context.Context, endpoint *Endpoint, options ExpectOptions) (*servicebus.Message, error) {
+	entity, err := s.getEndpointPeeker(endpoint, options.DeadLetter)
+	if err != nil {
+		return nil, err
+	}
+	defer entity.Close(context.Background())
+
+	var match *servicebus.Message
+	err = browse(ctx, entity, false, func(msg *servicebus.Message) error {
+		if options.Where.Match(msg) {
+			match = msg
+			return errExpectMatched
+		}
+		return nil
+	})
+	if match != nil {
+		return match, nil
+	}
+	return nil, err
+}
+
+// expectReceived browses the backlog until a message matches and completes it by its sequence number, see
+// RunMessageOperation. Nothing is locked while waiting, only the messages in front of the match are locked once it
+// was found to reach it and abandoned straight after. A match that could not be completed, usually because another
+// receiver took it, is skipped and the browsing goes on
+func (s *ServiceBusCli) expectReceived(ctx context.Context, endpoint *Endpoint, options ExpectOptions) (*servicebus.Message, error) {
+	entity, err := s.getEndpointPeeker(endpoint, options.DeadLetter)
+	if err != nil {
+		return nil, err
+	}
+	defer entity.Close(context.Background())
+
+	var match *servicebus.Message
+	err = browse(ctx, entity, false, func(msg *servicebus.Message) error {
+		if !options.Where.Match(msg) {
+			return nil
+		}
+
+		results, err := s.RunMessageOperation(ctx, endpoint, MessageOperation{
+			Action:          MessageActionComplete,
+			SequenceNumbers: []int64{sequenceNumber(msg)},
+			DeadLetter:      options.DeadLetter,
+			LockAhead:       true,
+		})
+		if err != nil {
+			return err
+		}
+		if len(results) == 1 && results[0].Status == MessageStatusCompleted {
+			match = msg
+			return errExpectMatched
+		}
+		if len(results) == 1 {
+			logger.LogHighlight("Could not complete the matching message %v, %v", log.Warning, msg.ID, results[0].Error)
+		}
+		return nil
+	})
+	if match != nil {
+		return match, nil
+	}
+	return nil, err
+}
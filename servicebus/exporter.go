@@ -0,0 +1,201 @@
+package servicebus
+
+import (
+	"context"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/servicebuscli-go/metrics"
+)
+
+// DefaultExporterInterval is how often the exporter polls the service bus if no interval is set
+const DefaultExporterInterval = 30 * time.Second
+
+// Exporter Polls the message counts of the queues, topics and subscriptions and exposes them as prometheus gauges
+type Exporter struct {
+	Interval time.Duration
+
+	cli                        func() *ServiceBusCli
+	activeMessages             *metrics.GaugeVec
+	deadLetterMessages         *metrics.GaugeVec
+	scheduledMessages          *metrics.GaugeVec
+	transferMessages           *metrics.GaugeVec
+	transferDeadLetterMessages *metrics.GaugeVec
+	sizeInBytes                *metrics.GaugeVec
+	scrapes                    *metrics.CounterVec
+	scrapeErrors               *metrics.CounterVec
+	scrapeDuration             *metrics.GaugeVec
+	lastScrape                 *metrics.GaugeVec
+
+	// listings are the samples of the last successful listing of the queues, the topics and the subscriptions of
+	// every topic, a listing that fails keeps these so a transient error does not drop its series
+	listings          map[string]exporterSamples
+	listingsNamespace string
+}
+
+// exporterSamples are the values collected in a poll before replacing the gauges
+type exporterSamples struct {
+	activeMessages             []metrics.Sample
+	deadLetterMessages         []metrics.Sample
+	scheduledMessages          []metrics.Sample
+	transferMessages           []metrics.Sample
+	transferDeadLetterMessages []metrics.Sample
+	sizeInBytes                []metrics.Sample
+}
+
+// NewExporter Creates an exporter registering its metrics, cli returns the client to poll with so it can change
+// while the exporter is running
+func NewExporter(registry *metrics.Registry, cli func() *ServiceBusCli) *Exporter {
+	entityLabels := []string{"namespace", "entity_type", "entity", "subscription"}
+
+	return &Exporter{
+		Interval:                   DefaultExporterInterval,
+		cli:                        cli,
+		activeMessages:             registry.NewGaugeVec("servicebus_active_messages", "Number of active messages in the entity", entityLabels...),
+		deadLetterMessages:         registry.NewGaugeVec("servicebus_dead_letter_messages", "Number of dead letter messages in the entity", entityLabels...),
+		scheduledMessages:          registry.NewGaugeVec("servicebus_scheduled_messages", "Number of scheduled messages in the entity", entityLabels...),
+		transferMessages:           registry.NewGaugeVec("servicebus_transfer_messages", "Number of messages being transferred to another entity", entityLabels...),
+		transferDeadLetterMessages: registry.NewGaugeVec("servicebus_transfer_dead_letter_messages", "Number of messages that failed to transfer to another entity", entityLabels...),
+		sizeInBytes:                registry.NewGaugeVec("servicebus_size_bytes", "Size of the entity in bytes, only available for queues and topics", entityLabels...),
+		scrapes:                    registry.NewCounterVec("servicebus_exporter_scrapes_total", "Number of times the exporter polled the service bus", "namespace"),
+		scrapeErrors:               registry.NewCounterVec("servicebus_exporter_scrape_errors_total", "Number of errors polling the service bus by operation", "namespace", "operation"),
+		scrapeDuration:             registry.NewGaugeVec("servicebus_exporter_scrape_duration_seconds", "Time the last poll of the service bus took", "namespace"),
+		lastScrape:                 registry.NewGaugeVec("servicebus_exporter_last_scrape_timestamp_seconds", "Unix time of the last poll of the service bus", "namespace"),
+	}
+}
+
+// Run Polls the service bus straight away and then every interval until the context is cancelled
+func (e *Exporter) Run(ctx context.Context) {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = DefaultExporterInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.Poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll Gets the counts of every entity in the namespace and updates the gauges, the entities that could not be
+// listed keep the samples of their last successful poll and the error is counted
+func (e *Exporter) Poll() {
+	cli := e.cli()
+	if cli == nil || cli.Namespace == nil {
+		return
+	}
+
+	namespace := cli.Namespace.Name
+	start := time.Now()
+	if e.listingsNamespace != namespace {
+		e.listings = nil
+		e.listingsNamespace = namespace
+	}
+	listings := make(map[string]exporterSamples)
+
+	queues, err := cli.ListQueues()
+	if err != nil {
+		logger.Error(err.Error())
+		e.scrapeErrors.Inc(namespace, "ListQueues")
+		listings["queues"] = e.listings["queues"]
+	} else {
+		samples := exporterSamples{}
+		for _, queue := range queues {
+			labels := []string{namespace, "queue", queue.Name, ""}
+			samples.add(queue.CountDetails, labels)
+			if queue.SizeInBytes != nil {
+				samples.sizeInBytes = append(samples.sizeInBytes, metrics.Sample{Value: float64(*queue.SizeInBytes), LabelValues: labels})
+			}
+		}
+		listings["queues"] = samples
+	}
+
+	topics, err := cli.ListTopics()
+	if err != nil {
+		logger.Error(err.Error())
+		e.scrapeErrors.Inc(namespace, "ListTopics")
+		// without the topics we do not know which subscriptions to list, so all of them keep their samples
+		for key, samples := range e.listings {
+			if key != "queues" {
+				listings[key] = samples
+			}
+		}
+	} else {
+		samples := exporterSamples{}
+		for _, topic := range topics {
+			labels := []string{namespace, "topic", topic.Name, ""}
+			samples.add(topic.CountDetails, labels)
+			if topic.SizeInBytes != nil {
+				samples.sizeInBytes = append(samples.sizeInBytes, metrics.Sample{Value: float64(*topic.SizeInBytes), LabelValues: labels})
+			}
+
+			key := "subscriptions/" + topic.Name
+			subscriptions, err := cli.ListSubscriptions(topic.Name)
+			if err != nil {
+				logger.Error(err.Error())
+				e.scrapeErrors.Inc(namespace, "ListSubscriptions")
+				listings[key] = e.listings[key]
+				continue
+			}
+			subscriptionSamples := exporterSamples{}
+			for _, subscription := range subscriptions {
+				subscriptionSamples.add(subscription.CountDetails, []string{namespace, "subscription", topic.Name, subscription.Name})
+			}
+			listings[key] = subscriptionSamples
+		}
+		listings["topics"] = samples
+	}
+
+	e.listings = listings
+	samples := exporterSamples{}
+	for _, listing := range listings {
+		samples.merge(listing)
+	}
+
+	e.activeMessages.Replace(samples.activeMessages)
+	e.deadLetterMessages.Replace(samples.deadLetterMessages)
+	e.scheduledMessages.Replace(samples.scheduledMessages)
+	e.transferMessages.Replace(samples.transferMessages)
+	e.transferDeadLetterMessages.Replace(samples.transferDeadLetterMessages)
+	e.sizeInBytes.Replace(samples.sizeInBytes)
+
+	e.scrapes.Inc(namespace)
+	e.scrapeDuration.Set(time.Since(start).Seconds(), namespace)
+	e.lastScrape.Set(float64(time.Now().Unix()), namespace)
+}
+
+func (s *exporterSamples) add(details *servicebus.CountDetails, labels []string) {
+	if details == nil {
+		return
+	}
+
+	s.activeMessages = appendCount(s.activeMessages, details.ActiveMessageCount, labels)
+	s.deadLetterMessages = appendCount(s.deadLetterMessages, details.DeadLetterMessageCount, labels)
+	s.scheduledMessages = appendCount(s.scheduledMessages, details.ScheduledMessageCount, labels)
+	s.transferMessages = appendCount(s.transferMessages, details.TransferMessageCount, labels)
+	s.transferDeadLetterMessages = appendCount(s.transferDeadLetterMessages, details.TransferDeadLetterMessageCount, labels)
+}
+
+func (s *exporterSamples) merge(other exporterSamples) {
+	s.activeMessages = append(s.activeMessages, other.activeMessages...)
+	s.deadLetterMessages = append(s.deadLetterMessages, other.deadLetterMessages...)
+	s.scheduledMessages = append(s.scheduledMessages, other.scheduledMessages...)
+	s.transferMessages = append(s.transferMessages, other.transferMessages...)
+	s.transferDeadLetterMessages = append(s.transferDeadLetterMessages, other.transferDeadLetterMessages...)
+	s.sizeInBytes = append(s.sizeInBytes, other.sizeInBytes...)
+}
+
+func appendCount(samples []metrics.Sample, count *int32, labels []string) []metrics.Sample {
+	if count == nil {
+		return samples
+	}
+
+	return append(samples, metrics.Sample{Value: float64(*count), LabelValues: labels})
+}
@@ -0,0 +1,84 @@
+package servicebus
+
+import (
+	"context"
+	"sync"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/servicebuscli-go/metrics"
+)
+
+// Types of the service bus operations counted by the instrumentation
+const (
+	OperationSend    = "send"
+	OperationReceive = "receive"
+	OperationManage  = "manage"
+)
+
+var (
+	instrumentationMutex sync.RWMutex
+	operations           *metrics.CounterVec
+	operationErrors      *metrics.CounterVec
+	activeListeners      *metrics.GaugeVec
+)
+
+// Instrument Registers the service bus operation metrics in the registry, the calls to the service bus are only
+// counted after this and it should only be called once
+func Instrument(registry *metrics.Registry) {
+	instrumentationMutex.Lock()
+	defer instrumentationMutex.Unlock()
+
+	operations = registry.NewCounterVec("servicebus_operations_total", "Number of calls to the service bus by operation type", "operation")
+	operationErrors = registry.NewCounterVec("servicebus_operation_errors_total", "Number of calls to the service bus that failed by operation type", "operation")
+	activeListeners = registry.NewGaugeVec("servicebus_active_listeners", "Number of listeners currently receiving messages")
+
+	// exposing the series from the start so rates work from the first call
+	for _, operation := range []string{OperationSend, OperationReceive, OperationManage} {
+		operations.Add(0, operation)
+		operationErrors.Add(0, operation)
+	}
+	activeListeners.Set(0)
+}
+
+// observeOperation counts a call to the service bus and its error if it failed
+func observeOperation(operation string, err error) {
+	instrumentationMutex.RLock()
+	defer instrumentationMutex.RUnlock()
+
+	if operations == nil {
+		return
+	}
+
+	operations.Inc(operation)
+	if err != nil {
+		operationErrors.Inc(operation)
+	}
+}
+
+// trackListener counts a listener as active until the returned function is called
+func trackListener() func() {
+	instrumentationMutex.RLock()
+	gauge := activeListeners
+	instrumentationMutex.RUnlock()
+
+	if gauge == nil {
+		return func() {}
+	}
+
+	gauge.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(func() { gauge.Dec() })
+	}
+}
+
+// receiveOne receives a message counting the call, receives that stop because the context ended are not errors
+func receiveOne(ctx context.Context, receiver servicebus.ReceiveOner, handler servicebus.Handler) error {
+	err := receiver.ReceiveOne(ctx, handler)
+	if ctx.Err() != nil {
+		return err
+	}
+
+	observeOperation(OperationReceive, err)
+	return err
+}
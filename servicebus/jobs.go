@@ -0,0 +1,399 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/v3/uuid"
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/templating"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+const (
+	// bulkSendChunkSize is the number of messages sent per operation in long running sends so progress
+	// can be reported and the operation cancelled without having every message in memory
+	bulkSendChunkSize = 500
+	// maxConsecutiveFailures is the number of consecutive failed operations before a long running operation gives up
+	maxConsecutiveFailures = 3
+	// drainIdleTimeout is how long we wait for a message before considering an entity empty
+	drainIdleTimeout   = 10 * time.Second
+	drainPrefetchCount = 100
+	// prefetchDrainTimeout is how long a message prefetched past the ones needed is waited for before closing a
+	// receiver
+	prefetchDrainTimeout = 500 * time.Millisecond
+)
+
+// ProgressFunc Reports the number of messages processed successfully and with errors since the last call
+type ProgressFunc func(succeeded int, failed int)
+
+// SendBulkTemplateMessages Sends the messages rendered from the template in a number of batches waiting between them if
+// requested, each batch is sent in chunks so the progress is reported while sending and the context can cancel it at any time
+func (s *ServiceBusCli) SendBulkTemplateMessages(ctx context.Context, sender MessageSender, bulk entities.BulkTemplateMessageRequest, progress ProgressFunc) error {
+	if bulk.TotalMessages < 1 {
+		bulk.TotalMessages = 1
+	}
+	if bulk.BatchOf < 1 || bulk.BatchOf > bulk.TotalMessages {
+		bulk.BatchOf = 1
+	}
+
+	template, err := templating.New(bulk.Template, bulk.Rows)
+	if err != nil {
+		return err
+	}
+
+	batchSize := bulk.TotalMessages / bulk.BatchOf
+	index := 0
+	consecutiveFailures := 0
+	for batch := 0; batch < bulk.BatchOf; batch++ {
+		if batch > 0 && bulk.WaitBetweenBatchesInMilli > 0 {
+			logger.Info("Waiting " + fmt.Sprint(bulk.WaitBetweenBatchesInMilli) + "ms for next batch")
+			select {
+			case <-time.After(time.Duration(bulk.WaitBetweenBatchesInMilli) * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// the last batch also sends the remaining messages of the division
+		count := batchSize
+		if batch == bulk.BatchOf-1 {
+			count = bulk.TotalMessages - batchSize*(bulk.BatchOf-1)
+		}
+
+		for sent := 0; sent < count; sent += bulkSendChunkSize {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			chunkSize := bulkSendChunkSize
+			if count-sent < chunkSize {
+				chunkSize = count - sent
+			}
+
+			messages := make([]*servicebus.Message, 0)
+			for i := 0; i < chunkSize; i++ {
+				message, err := template.Render(index)
+				if err != nil {
+					return err
+				}
+				sbMessage, err := message.ToServiceBus()
+				if err != nil {
+					return err
+				}
+				messages = append(messages, sbMessage)
+				index++
+			}
+
+			if err := SendMessageBatch(ctx, sender, messages...); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// every chunk is rendered from the same template so sending more would not match the schema either
+				if isSchemaError(err) {
+					progress(0, chunkSize)
+					return err
+				}
+				logger.Error(err.Error())
+				progress(0, chunkSize)
+				consecutiveFailures++
+				if consecutiveFailures >= maxConsecutiveFailures {
+					return err
+				}
+				continue
+			}
+
+			consecutiveFailures = 0
+			progress(chunkSize, 0)
+		}
+	}
+
+	return nil
+}
+
+// PurgeQueue Removes all the messages from a queue or from its dead letter queue
+func (s *ServiceBusCli) PurgeQueue(ctx context.Context, queueName string, deadLetter bool, progress ProgressFunc) error {
+	logger.LogHighlight("Purging messages from queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
+	queue, err := s.GetQueue(queueName)
+	if queue == nil || err != nil {
+		logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, queueName, s.Namespace.Name)
+		return errors.New("Could not find queue " + queueName + " in service bus " + s.Namespace.Name)
+	}
+
+	options := []servicebus.ReceiverOption{
+		servicebus.ReceiverWithReceiveMode(servicebus.ReceiveAndDeleteMode),
+		servicebus.ReceiverWithPrefetchCount(drainPrefetchCount),
+	}
+
+	var receiver servicebus.ReceiveOner
+	if deadLetter {
+		receiver, err = queue.NewDeadLetterReceiver(ctx, options...)
+	} else {
+		receiver, err = queue.NewReceiver(ctx, options...)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = drainReceiver(ctx, receiver, progress)
+	logger.LogHighlight("Finished purging messages from queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
+	return err
+}
+
+// PurgeSubscription Removes all the messages from a topic subscription or from its dead letter queue
+func (s *ServiceBusCli) PurgeSubscription(ctx context.Context, topicName string, subscriptionName string, deadLetter bool, progress ProgressFunc) error {
+	logger.LogHighlight("Purging messages from subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+	subscription, err := s.getSubscriptionClient(topicName, subscriptionName)
+	if err != nil {
+		return err
+	}
+
+	options := []servicebus.ReceiverOption{
+		servicebus.ReceiverWithReceiveMode(servicebus.ReceiveAndDeleteMode),
+		servicebus.ReceiverWithPrefetchCount(drainPrefetchCount),
+	}
+
+	var receiver servicebus.ReceiveOner
+	if deadLetter {
+		receiver, err = subscription.NewDeadLetterReceiver(ctx, options...)
+	} else {
+		receiver, err = subscription.NewReceiver(ctx, options...)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = drainReceiver(ctx, receiver, progress)
+	logger.LogHighlight("Finished purging messages from subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+	return err
+}
+
+// ResubmitOptions Options of resubmitting the dead letter messages of a queue or subscription
+type ResubmitOptions struct {
+	// Max limits the number of messages to resubmit, 0 resubmits all of them
+	Max int
+	// FanOut allows sending the dead letters of a subscription to its topic, every subscription of the topic with
+	// matching rules receives them again and not only the one they came from
+	FanOut bool
+	// Transform changes the messages before they are sent, a message that can not be transformed is left in the
+	// dead letter queue
+	Transform *transform.Pipeline
+}
+
+// prepare copies a dead letter message with a new id and transforms it
+func (o ResubmitOptions) prepare(msg *servicebus.Message) (*servicebus.Message, error) {
+	resubmitted, err := newResubmittedMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return o.Transform.Apply(resubmitted)
+}
+
+// ResubmitQueueDeadLetters Sends the dead letter messages of a queue back to it, the dead letter messages are only removed
+// once they were sent successfully
+func (s *ServiceBusCli) ResubmitQueueDeadLetters(ctx context.Context, queueName string, options ResubmitOptions, progress ProgressFunc) error {
+	logger.LogHighlight("Resubmitting dead letter messages of queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
+	queue, err := s.GetQueue(queueName)
+	if queue == nil || err != nil {
+		logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, queueName, s.Namespace.Name)
+		return errors.New("Could not find queue " + queueName + " in service bus " + s.Namespace.Name)
+	}
+	defer queue.Close(context.Background())
+
+	receiver, err := queue.NewDeadLetterReceiver(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = relayMessages(ctx, receiver, s.checkedSender(queue, queueName, ""), options.Max, options.prepare, progress)
+	logger.LogHighlight("Finished resubmitting dead letter messages of queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
+	return err
+}
+
+// ResubmitSubscriptionDeadLetters Sends the dead letter messages of a topic subscription back to the topic, messages can
+// not be sent to a single subscription so every subscription with matching rules will receive them, which needs
+// FanOut to be set
+func (s *ServiceBusCli) ResubmitSubscriptionDeadLetters(ctx context.Context, topicName string, subscriptionName string, options ResubmitOptions, progress ProgressFunc) error {
+	if !options.FanOut {
+		return errors.New("the dead letters of subscription " + subscriptionName + " are resubmitted to topic " + topicName + " and every subscription with matching rules receives them, allow the fan out to resubmit them")
+	}
+
+	logger.LogHighlight("Resubmitting dead letter messages of subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+	subscription, err := s.getSubscriptionClient(topicName, subscriptionName)
+	if err != nil {
+		return err
+	}
+	defer subscription.Topic.Close(context.Background())
+
+	receiver, err := subscription.NewDeadLetterReceiver(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = relayMessages(ctx, receiver, s.checkedSender(subscription.Topic, "", topicName), options.Max, options.prepare, progress)
+	logger.LogHighlight("Finished resubmitting dead letter messages of subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+	return err
+}
+
+// getSubscriptionClient gets a subscription client checking both the topic and the subscription exist
+func (s *ServiceBusCli) getSubscriptionClient(topicName string, subscriptionName string) (*servicebus.Subscription, error) {
+	topic := s.GetTopic(topicName)
+	if topic == nil {
+		logger.LogHighlight("Could not find topic %v in service bus %v", log.Error, topicName, s.Namespace.Name)
+		return nil, errors.New("Could not find topic " + topicName + " in service bus " + s.Namespace.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
+	_, err := topic.NewSubscriptionManager().Get(ctx, subscriptionName)
+	observeOperation(OperationManage, err)
+	if err != nil {
+		logger.LogHighlight("Subscription %v was not found on %v in service bus %v", log.Error, subscriptionName, topicName, s.Namespace.Name)
+		return nil, errors.New("Subscription " + subscriptionName + " was not found on topic " + topicName + " in service bus " + s.Namespace.Name)
+	}
+
+	return topic.NewSubscription(subscriptionName)
+}
+
+// drainReceiver receives messages until none arrives for the idle timeout or the context is cancelled,
+// the receiver needs to be in receive and delete mode as the messages are not settled
+func drainReceiver(ctx context.Context, receiver servicebus.ReceiveOner, progress ProgressFunc) error {
+	defer receiver.Close(context.Background())
+
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		progress(1, 0)
+		return nil
+	}
+
+	for {
+		idleCtx, cancel := context.WithTimeout(ctx, drainIdleTimeout)
+		err := receiveOne(idleCtx, receiver, handler)
+		idleErr := idleCtx.Err()
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if idleErr == context.DeadlineExceeded {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// relayMessages sends the messages received to the sender completing them only after they are sent, prepare creates
+// the message that is sent from the received one
+func relayMessages(ctx context.Context, receiver servicebus.ReceiveOner, sender MessageSender, max int, prepare func(msg *servicebus.Message) (*servicebus.Message, error), progress ProgressFunc) error {
+	defer receiver.Close(context.Background())
+
+	consecutiveFailures := 0
+	var sendErr error
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		prepared, err := prepare(msg)
+		if err == nil {
+			err = SendMessageBatch(msgCtx, sender, prepared)
+		}
+		if err != nil {
+			sendErr = err
+			consecutiveFailures++
+			progress(0, 1)
+			return msg.Abandon(msgCtx)
+		}
+
+		consecutiveFailures = 0
+		progress(1, 0)
+		return msg.Complete(msgCtx)
+	}
+
+	for count := 0; max <= 0 || count < max; count++ {
+		idleCtx, cancel := context.WithTimeout(ctx, drainIdleTimeout)
+		err := receiveOne(idleCtx, receiver, handler)
+		idleErr := idleCtx.Err()
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if idleErr == context.DeadlineExceeded {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// abandoned messages are received again straight away so we need to give up at some point
+		if consecutiveFailures >= maxConsecutiveFailures {
+			return sendErr
+		}
+	}
+
+	abandonPrefetched(ctx, receiver, drainPrefetchCount)
+	return nil
+}
+
+// abandonPrefetched abandons the messages a peek lock receiver prefetched past the ones that were needed, so they are
+// not left locked until their lock expires once the receiver is closed. It stops at the limit, when no message is
+// ready or when an abandoned message is received again
+func abandonPrefetched(ctx context.Context, receiver servicebus.ReceiveOner, limit int) {
+	seen := make(map[int64]bool)
+	again := false
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		number := sequenceNumber(msg)
+		again = seen[number]
+		seen[number] = true
+		return msg.Abandon(msgCtx)
+	}
+
+	for abandoned := 0; abandoned < limit && !again; abandoned++ {
+		drainCtx, cancel := context.WithTimeout(ctx, prefetchDrainTimeout)
+		err := receiveOne(drainCtx, receiver, handler)
+		cancel()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// newResubmittedMessage copies a dead letter message without the dead letter properties and with a new id,
+// the new id avoids the message being dropped by the duplicate detection of the entity
+func newResubmittedMessage(msg *servicebus.Message) (*servicebus.Message, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	result := copyMessage(msg)
+	result.ID = id.String()
+	return result, nil
+}
+
+// copyMessage copies the body and properties of a received message to send it again, the dead letter properties
+// are not copied as they belong to the dead letter queue the message is leaving
+func copyMessage(msg *servicebus.Message) *servicebus.Message {
+	userProperties := make(map[string]interface{})
+	for key, value := range msg.UserProperties {
+		if key == "DeadLetterReason" || key == "DeadLetterErrorDescription" {
+			continue
+		}
+		userProperties[key] = value
+	}
+
+	return &servicebus.Message{
+		ID:             msg.ID,
+		Data:           msg.Data,
+		ContentType:    msg.ContentType,
+		CorrelationID:  msg.CorrelationID,
+		Label:          msg.Label,
+		ReplyTo:        msg.ReplyTo,
+		ReplyToGroupID: msg.ReplyToGroupID,
+		To:             msg.To,
+		SessionID:      msg.SessionID,
+		TTL:            msg.TTL,
+		UserProperties: userProperties,
+	}
+}
@@ -0,0 +1,119 @@
+package servicebus
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Reasons a bounded subscription stopped
+const (
+	// StopMaxMessages the maximum number of messages was received
+	StopMaxMessages = "max-messages"
+	// StopTimeout the subscription ran for its timeout
+	StopTimeout = "timeout"
+	// StopIdle no message was received during the idle timeout
+	StopIdle = "idle"
+	// StopCancelled the context was cancelled, usually by ctrl+c
+	StopCancelled = "cancelled"
+)
+
+// SubscribeLimits Bounds of the subscriptions of a subscribe command, shared by all the entities it listens to.
+// A zero value means no bound, messages received once the maximum was reached are abandoned so they are left
+// for the next receiver
+type SubscribeLimits struct {
+	MaxMessages int
+	Timeout     time.Duration
+	IdleTimeout time.Duration
+
+	taken   int64
+	handled int64
+	notify  chan bool
+}
+
+// NewSubscribeLimits Creates the bounds of a subscribe command
+func NewSubscribeLimits(maxMessages int, timeout time.Duration, idleTimeout time.Duration) *SubscribeLimits {
+	return &SubscribeLimits{
+		MaxMessages: maxMessages,
+		Timeout:     timeout,
+		IdleTimeout: idleTimeout,
+		notify:      make(chan bool, 1),
+	}
+}
+
+// Received Gets the number of messages handled
+func (l *SubscribeLimits) Received() int {
+	return int(atomic.LoadInt64(&l.handled))
+}
+
+// Reached Checks if the maximum number of messages was handled, it is never reached without a maximum
+func (l *SubscribeLimits) Reached() bool {
+	return l.MaxMessages > 0 && l.Received() >= l.MaxMessages
+}
+
+// Wait Blocks until the maximum number of messages is handled, the timeout or the idle timeout expire or the
+// context is cancelled, returning the reason it stopped
+func (l *SubscribeLimits) Wait(ctx context.Context) string {
+	var timeout <-chan time.Time
+	if l.Timeout > 0 {
+		timer := time.NewTimer(l.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if l.IdleTimeout > 0 {
+		idleTimer = time.NewTimer(l.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	for {
+		if l.Reached() {
+			return StopMaxMessages
+		}
+
+		select {
+		case <-ctx.Done():
+			return StopCancelled
+		case <-timeout:
+			return StopTimeout
+		case <-idle:
+			return StopIdle
+		case <-l.notify:
+			if idleTimer != nil {
+				if !idleTimer.Stop() {
+					<-idleTimer.C
+				}
+				idleTimer.Reset(l.IdleTimeout)
+			}
+		}
+	}
+}
+
+// take reserves a message before it is handled, it is false once the maximum was reserved
+func (l *SubscribeLimits) take() bool {
+	if l == nil {
+		return true
+	}
+
+	if atomic.AddInt64(&l.taken, 1) > int64(l.MaxMessages) && l.MaxMessages > 0 {
+		atomic.AddInt64(&l.taken, -1)
+		return false
+	}
+	return true
+}
+
+// done counts a handled message waking up Wait
+func (l *SubscribeLimits) done() {
+	if l == nil {
+		return
+	}
+
+	atomic.AddInt64(&l.handled, 1)
+	select {
+	case l.notify <- true:
+	default:
+	}
+}
@@ -0,0 +1,235 @@
+package servicebus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/templating"
+)
+
+// loadTickInterval is how often the rate of a load is recalculated and the messages for it released
+const loadTickInterval = 10 * time.Millisecond
+
+// SenderFactory Creates a new sender, each call needs to return a sender with its own connection
+type SenderFactory func() (MessageSender, error)
+
+// RunLoad Sends the messages rendered from the template at the requested rate, ramping it up and down, until the duration ends
+// or the total of messages is sent. The messages are spread across concurrent senders and the time each send
+// takes is reported at the end, the report is also returned when the load fails or is cancelled
+func (s *ServiceBusCli) RunLoad(ctx context.Context, newSender SenderFactory, request entities.LoadRequest, progress ProgressFunc) (*entities.LoadReport, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	template, err := templating.New(request.Template, request.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	senders := make([]MessageSender, 0)
+	defer func() {
+		for _, sender := range senders {
+			sender.Close(context.Background())
+		}
+	}()
+	for i := 0; i < request.Concurrency; i++ {
+		sender, err := newSender()
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, sender)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	tokens := make(chan struct{}, request.Concurrency)
+	go releaseLoadTokens(ctx, request, start, tokens)
+
+	var index int64 = -1
+	render := func() (*entities.MessageRequest, error) {
+		return template.Render(int(atomic.AddInt64(&index, 1)))
+	}
+
+	var mu sync.Mutex
+	var runErr error
+	var wg sync.WaitGroup
+	results := make([]loadWorkerResult, len(senders))
+	for i, sender := range senders {
+		wg.Add(1)
+		go func(i int, sender MessageSender) {
+			defer wg.Done()
+			result, err := runLoadWorker(ctx, sender, render, tokens, progress)
+			results[i] = result
+			if err != nil {
+				mu.Lock()
+				if runErr == nil {
+					runErr = err
+				}
+				mu.Unlock()
+				// one sender failing means the entity is not accepting messages so we stop the others too
+				cancel()
+			}
+		}(i, sender)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := entities.LoadReport{
+		Concurrency:             request.Concurrency,
+		DurationInSeconds:       elapsed.Seconds(),
+		TargetMessagesPerSecond: request.MessagesPerSecond,
+	}
+	latencies := make([]time.Duration, 0)
+	for _, result := range results {
+		report.Sent += result.sent
+		report.Failed += result.failed
+		latencies = append(latencies, result.latencies...)
+	}
+	if elapsed > 0 {
+		report.MessagesPerSecond = float64(report.Sent) / elapsed.Seconds()
+	}
+	report.Latency = entities.NewLatencyReport(latencies)
+
+	return &report, runErr
+}
+
+type loadWorkerResult struct {
+	sent      int
+	failed    int
+	latencies []time.Duration
+}
+
+// runLoadWorker sends a message for every token released until the tokens channel is closed
+func runLoadWorker(ctx context.Context, sender MessageSender, render func() (*entities.MessageRequest, error), tokens <-chan struct{}, progress ProgressFunc) (loadWorkerResult, error) {
+	result := loadWorkerResult{
+		latencies: make([]time.Duration, 0),
+	}
+
+	consecutiveFailures := 0
+	for range tokens {
+		message, err := render()
+		if err != nil {
+			return result, err
+		}
+		sbMessage, err := message.ToServiceBus()
+		if err != nil {
+			return result, err
+		}
+
+		sentAt := time.Now()
+		err = SendMessageBatch(ctx, sender, sbMessage)
+		latency := time.Since(sentAt)
+		if err != nil {
+			// messages in flight when the load is cancelled are not failures
+			if ctx.Err() != nil {
+				return result, nil
+			}
+			logger.Error(err.Error())
+			result.failed++
+			progress(0, 1)
+			consecutiveFailures++
+			if consecutiveFailures >= maxConsecutiveFailures {
+				return result, err
+			}
+			continue
+		}
+
+		consecutiveFailures = 0
+		result.sent++
+		result.latencies = append(result.latencies, latency)
+		progress(1, 0)
+	}
+
+	return result, nil
+}
+
+// releaseLoadTokens releases a token for every message that needs to be sent following the rate of the load,
+// the channel is closed when the load ends. Tokens not released on time because the senders were busy are
+// released as soon as possible so the latency of a slow entity is not hidden by sending less messages
+func releaseLoadTokens(ctx context.Context, request entities.LoadRequest, start time.Time, tokens chan<- struct{}) {
+	defer close(tokens)
+
+	var deadline <-chan time.Time
+	if request.DurationInSeconds > 0 {
+		timer := time.NewTimer(request.Duration())
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	released := 0
+	finished := func() bool {
+		return request.TotalMessages > 0 && released >= request.TotalMessages
+	}
+
+	release := func() bool {
+		select {
+		case tokens <- struct{}{}:
+			released++
+			return true
+		case <-deadline:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if request.MessagesPerSecond <= 0 {
+		for !finished() {
+			if !release() {
+				return
+			}
+		}
+		return
+	}
+
+	ticker := time.NewTicker(loadTickInterval)
+	defer ticker.Stop()
+
+	credit := 0.0
+	last := start
+	for !finished() {
+		select {
+		case <-deadline:
+			return
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			credit += loadRate(request, now.Sub(start)) * now.Sub(last).Seconds()
+			last = now
+			for credit >= 1 && !finished() {
+				if !release() {
+					return
+				}
+				credit--
+			}
+		}
+	}
+}
+
+// loadRate gets the messages per second a load should be sending at after running for the elapsed time
+func loadRate(request entities.LoadRequest, elapsed time.Duration) float64 {
+	rate := request.MessagesPerSecond
+
+	rampUp := request.RampUp()
+	if rampUp > 0 && elapsed < rampUp {
+		rate = request.MessagesPerSecond * elapsed.Seconds() / rampUp.Seconds()
+	}
+
+	rampDown := request.RampDown()
+	if rampDown > 0 {
+		remaining := request.Duration() - elapsed
+		if remaining < rampDown {
+			rate = request.MessagesPerSecond * remaining.Seconds() / rampDown.Seconds()
+		}
+	}
+
+	if rate < 0 {
+		return 0
+	}
+	return rate
+}
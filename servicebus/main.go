@@ -1,10 +1,15 @@
 package servicebus
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	servicebus "github.com/Azure/azure-service-bus-go"
 	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/render"
+	"github.com/cjlapao/servicebuscli-go/schema"
+	"github.com/cjlapao/servicebuscli-go/transform"
 )
 
 var logger = log.Get()
@@ -21,10 +26,19 @@ type ServiceBusCli struct {
 	ActiveQueueListenerHandle *servicebus.ListenerHandle
 	ActiveTopicListenerHandle *servicebus.ListenerHandle
 	Peek                      bool
+	Tail                      bool
 	UseWiretap                bool
 	DeleteWiretap             bool
 	CloseTopicListener        chan bool
 	CloseQueueListener        chan bool
+	Schemas                   *schema.Registry
+	Transform                 *transform.Pipeline
+	Renderer                  *render.Renderer
+	Limits                    *SubscribeLimits
+	Receive                   ReceiveOptions
+	Settle                    string
+
+	locks *lockRenewer
 }
 
 // NewCli creates a new ServiceBusCli
@@ -34,10 +48,14 @@ func NewCli(connectionString string) *ServiceBusCli {
 		UseWiretap:       false,
 		DeleteWiretap:    false,
 		ConnectionString: connectionString,
+		Renderer:         render.NewRenderer(),
+		Settle:           SettleComplete,
 	}
 
 	cli.CloseTopicListener = make(chan bool, 1)
 	cli.CloseQueueListener = make(chan bool, 1)
+	// an invalid registry is reported when the module starts, the cli validates nothing without it
+	cli.Schemas, _ = schema.DefaultRegistry()
 	cli.GetNamespace()
 
 	return &cli
@@ -58,3 +76,13 @@ func (s *ServiceBusCli) GetNamespace() (*servicebus.Namespace, error) {
 
 	return s.Namespace, nil
 }
+
+// Ping Checks the service bus namespace can be reached by listing its queues
+func (s *ServiceBusCli) Ping(ctx context.Context) error {
+	if s.Namespace == nil {
+		return errors.New("there is no service bus namespace, check the connection string")
+	}
+
+	_, err := s.Namespace.NewQueueManager().List(ctx)
+	return err
+}
@@ -0,0 +1,473 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+// Actions of the operations on messages picked by their sequence number or id
+const (
+	MessageActionComplete   = "complete"
+	MessageActionDeadLetter = "deadletter"
+	MessageActionMove       = "move"
+	MessageActionDefer      = "defer"
+	MessageActionReceive    = "receive"
+)
+
+// Status of the messages after an operation
+const (
+	MessageStatusCompleted    = "completed"
+	MessageStatusDeadLettered = "deadlettered"
+	MessageStatusMoved        = "moved"
+	MessageStatusDeferred     = "deferred"
+	MessageStatusReceived     = "received"
+	MessageStatusFailed       = "failed"
+	MessageStatusNotFound     = "not found"
+)
+
+const (
+	// defaultDeadLetterReason is the reason of the messages dead lettered without one
+	defaultDeadLetterReason = "ManuallyDeadLettered"
+	// messageStateAnnotation is the annotation with the state of a peeked message, deferred messages can only be
+	// received by their sequence number
+	messageStateAnnotation = "x-opt-message-state"
+	deferredMessageState   = 1
+	// maxScanLength is how far from the head of the entity a picked message can be, every message in front of it
+	// is held locked while receiving it
+	maxScanLength = 1000
+)
+
+// MessageOperation Operation on messages of a queue or subscription picked by their sequence number or id
+type MessageOperation struct {
+	// Action is what is done to the messages, complete, deadletter, move, defer or receive
+	Action          string
+	SequenceNumbers []int64
+	MessageIDs      []string
+	// DeadLetter picks the messages from the dead letter queue instead of the active messages
+	DeadLetter bool
+	// Reason and Description are set on the dead lettered messages
+	Reason      string
+	Description string
+	// To is the queue or topic the messages are moved to
+	To *Endpoint
+	// Transform changes the moved messages before they are sent
+	Transform *transform.Pipeline
+	// KeepID sends the moved messages with their id instead of a new one
+	KeepID bool
+	// LockAhead allows receiving the active messages in front of the picked ones to reach them, they are abandoned
+	// at the end which increases their delivery count. Without it picked messages with others in front fail
+	LockAhead bool
+}
+
+// Validate Checks the action has what it needs and at least one message is picked
+func (o *MessageOperation) Validate() error {
+	switch o.Action {
+	case MessageActionComplete, MessageActionDefer, MessageActionReceive:
+	case MessageActionDeadLetter:
+		if o.DeadLetter {
+			return errors.New("messages of a dead letter queue can not be dead lettered again")
+		}
+	case MessageActionMove:
+		if o.To == nil {
+			return errors.New("move needs the queue or topic to move the messages to")
+		}
+		if !o.To.CanSend() {
+			return errors.New("can not move messages to " + o.To.String() + ", move them to its topic")
+		}
+	default:
+		return fmt.Errorf("invalid action %v, use complete, deadletter, move, defer or receive", o.Action)
+	}
+
+	if len(o.SequenceNumbers) == 0 && len(o.MessageIDs) == 0 {
+		return errors.New("at least one sequence number or message id is needed")
+	}
+	return nil
+}
+
+// messageEntity is the queue or subscription the messages are picked from
+type messageEntity interface {
+	peeker
+	lockRenewable
+	ReceiveOne(ctx context.Context, handler servicebus.Handler) error
+	ReceiveDeferred(ctx context.Context, handler servicebus.Handler, sequenceNumbers ...int64) error
+}
+
+// messageTarget is a message picked by the operation that was found peeking the entity
+type messageTarget struct {
+	deferred bool
+	done     bool
+	result   entities.MessageOperationResult
+}
+
+// QueueMessagesOperation Completes, dead letters, moves, defers or receives the messages of a queue picked by
+// their sequence number or id, see RunMessageOperation
+func (s *ServiceBusCli) QueueMessagesOperation(ctx context.Context, queueName string, operation MessageOperation) ([]entities.MessageOperationResult, error) {
+	return s.RunMessageOperation(ctx, &Endpoint{Queue: queueName}, operation)
+}
+
+// SubscriptionMessagesOperation Completes, dead letters, moves, defers or receives the messages of a topic
+// subscription picked by their sequence number or id, see RunMessageOperation
+func (s *ServiceBusCli) SubscriptionMessagesOperation(ctx context.Context, topicName string, subscriptionName string, operation MessageOperation) ([]entities.MessageOperationResult, error) {
+	return s.RunMessageOperation(ctx, &Endpoint{Topic: topicName, Subscription: subscriptionName}, operation)
+}
+
+// RunMessageOperation Completes, dead letters, moves, defers or receives the messages of a queue or subscription
+// picked by their sequence number or id, every picked message gets a result even if it was not found.
+//
+// The messages are located peeking the entity. Deferred messages are received by their sequence number, the
+// others are received in peek lock from the head of the entity. Active messages with others in front fail without
+// receiving anything unless LockAhead is set, then the messages received before them are held with their locks
+// renewed and abandoned at the end, which increases their delivery count, the result of every message has how many
+// were abandoned to reach it. Messages further than maxScanLength from the head always fail. Moved messages keep
+// their properties and are only completed once they were sent. Receive only works with deferred messages and
+// completes them, returning their body
+func (s *ServiceBusCli) RunMessageOperation(ctx context.Context, endpoint *Endpoint, operation MessageOperation) ([]entities.MessageOperationResult, error) {
+	if err := operation.Validate(); err != nil {
+		return nil, err
+	}
+	if !endpoint.CanReceive() {
+		return nil, errors.New("can not receive messages from " + endpoint.String() + ", topics need a subscription")
+	}
+
+	suffix := ""
+	if operation.DeadLetter {
+		suffix = deadLetterQueuePath
+	}
+
+	var entity messageEntity
+	if endpoint.Queue != "" {
+		if queue, err := s.GetQueue(endpoint.Queue); queue == nil || err != nil {
+			logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, endpoint.Queue, s.Namespace.Name)
+			return nil, errors.New("Could not find queue " + endpoint.Queue + " in service bus " + s.Namespace.Name)
+		}
+		queue, err := s.Namespace.NewQueue(endpoint.Queue + suffix)
+		if err != nil {
+			return nil, err
+		}
+		entity = queue
+	} else {
+		subscription, err := s.getSubscriptionClient(endpoint.Topic, endpoint.Subscription)
+		if err != nil {
+			return nil, err
+		}
+		defer subscription.Topic.Close(context.Background())
+		picked, err := subscription.Topic.NewSubscription(endpoint.Subscription + suffix)
+		if err != nil {
+			return nil, err
+		}
+		entity = picked
+	}
+	defer entity.Close(context.Background())
+
+	var sender MessageSender
+	if operation.Action == MessageActionMove {
+		moveSender, err := s.GetEndpointSender(operation.To)
+		if err != nil {
+			return nil, err
+		}
+		defer moveSender.Close(context.Background())
+		sender = moveSender
+	}
+
+	logger.LogHighlight("Running %v on the picked messages of %v in service bus %v", log.Info, operation.Action, endpoint.String(), s.Namespace.Name)
+	targets, order, scanLength, err := findMessageTargets(ctx, entity, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := func(msgCtx context.Context, msg *servicebus.Message) error {
+		target := targets[sequenceNumber(msg)]
+		if target == nil || target.done {
+			return nil
+		}
+		target.done = true
+		target.result.Message = &entities.MessageEnvelope{}
+		target.result.Message.FromServiceBus(msg)
+		target.result.MessageID = msg.ID
+
+		status, err := applyMessageAction(msgCtx, msg, operation, sender)
+		target.result.Status = status
+		if err != nil {
+			target.result.Status = MessageStatusFailed
+			target.result.Error = err.Error()
+		}
+		return nil
+	}
+
+	deferred := make([]int64, 0)
+	for _, number := range order {
+		if targets[number].deferred {
+			deferred = append(deferred, number)
+		}
+	}
+	if len(deferred) > 0 {
+		err := entity.ReceiveDeferred(ctx, servicebus.HandlerFunc(handler), deferred...)
+		observeOperation(OperationReceive, err)
+		if err != nil {
+			failTargets(targets, deferred, err.Error())
+		}
+	}
+
+	abandoned, err := scanMessageTargets(ctx, entity, targets, order, scanLength, handler)
+	if err != nil {
+		failTargets(targets, order, err.Error())
+	}
+
+	results := make([]entities.MessageOperationResult, 0)
+	failed := 0
+	for _, number := range order {
+		target := targets[number]
+		if !target.done {
+			target.result.Status = MessageStatusNotFound
+			target.result.Error = "the message was not received, it may be locked by another receiver"
+		}
+		if target.result.Status == MessageStatusFailed || target.result.Status == MessageStatusNotFound {
+			failed++
+		}
+		results = append(results, target.result)
+	}
+	for _, number := range operation.SequenceNumbers {
+		if targets[number] == nil {
+			failed++
+			results = append(results, entities.MessageOperationResult{SequenceNumber: number, Action: operation.Action, Status: MessageStatusNotFound})
+		}
+	}
+	for _, id := range operation.MessageIDs {
+		found := false
+		for _, target := range targets {
+			found = found || target.result.MessageID == id
+		}
+		if !found {
+			failed++
+			results = append(results, entities.MessageOperationResult{MessageID: id, Action: operation.Action, Status: MessageStatusNotFound})
+		}
+	}
+
+	logger.LogHighlight("Finished %v on %v messages of %v, %v failed or were not found and %v messages in front of them were abandoned", log.Info, operation.Action, fmt.Sprint(len(results)), endpoint.String(), fmt.Sprint(failed), fmt.Sprint(abandoned))
+	return results, ctx.Err()
+}
+
+// findMessageTargets peeks the entity for the picked messages, it returns them by sequence number in the order they
+// were found and the number of active messages that need to be received to reach the last of them. Active messages
+// that can not be handled are failed here so nothing is received for them, those further than maxScanLength from the
+// head, those with other messages in front without LockAhead and those that are not deferred when receiving
+func findMessageTargets(ctx context.Context, entity messageEntity, operation MessageOperation) (map[int64]*messageTarget, []int64, int, error) {
+	numbers := make(map[int64]bool)
+	for _, number := range operation.SequenceNumbers {
+		numbers[number] = true
+	}
+	ids := make(map[string]bool)
+	for _, id := range operation.MessageIDs {
+		ids[id] = true
+	}
+
+	targets := make(map[int64]*messageTarget)
+	order := make([]int64, 0)
+	iterator, err := entity.Peek(ctx, servicebus.PeekWithPageSize(searchPageSize))
+	observeOperation(OperationReceive, err)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// ahead counts the active messages that would be held and abandoned, the ones that are not picked and the picked
+	// ones that failed
+	active, ahead, scanLength := 0, 0, 0
+	// the whole backlog is peeked when picking by id as more than one message can have the same id
+	for len(ids) > 0 || len(order) < len(numbers) {
+		msg, err := iterator.Next(ctx)
+		if _, noMessages := err.(servicebus.ErrNoMessages); noMessages {
+			break
+		}
+		if err != nil {
+			observeOperation(OperationReceive, err)
+			return nil, nil, 0, err
+		}
+
+		deferred := isDeferred(msg)
+		if !deferred {
+			active++
+		}
+		number := sequenceNumber(msg)
+		if !numbers[number] && !ids[msg.ID] {
+			if !deferred {
+				ahead++
+			}
+			continue
+		}
+
+		target := &messageTarget{
+			deferred: deferred,
+			result: entities.MessageOperationResult{
+				SequenceNumber: number,
+				MessageID:      msg.ID,
+				Action:         operation.Action,
+			},
+		}
+		targets[number] = target
+		order = append(order, number)
+		if deferred {
+			continue
+		}
+
+		switch {
+		case operation.Action == MessageActionReceive:
+			target.result.Error = "the message is not deferred, only deferred messages can be received"
+		case active > maxScanLength:
+			target.result.Error = fmt.Sprintf("the message is %v messages from the head of the entity, only the first %v can be picked", active, maxScanLength)
+		case ahead > 0 && !operation.LockAhead:
+			target.result.Error = fmt.Sprintf("the message has %v messages in front of it, set lock ahead to lock and abandon them to reach it, which increases their delivery count", ahead)
+		default:
+			scanLength = active
+			continue
+		}
+		target.done = true
+		target.result.Status = MessageStatusFailed
+		ahead++
+	}
+
+	return targets, order, scanLength, nil
+}
+
+// scanMessageTargets receives the active messages from the head of the entity until every picked message was handled,
+// the messages in front of them are held with their locks renewed so they are not received again and abandoned at
+// the end, it returns how many were abandoned and sets on the result of every handled message how many were in front of it
+func scanMessageTargets(ctx context.Context, entity messageEntity, targets map[int64]*messageTarget, order []int64, scanLength int, handler servicebus.HandlerFunc) (int, error) {
+	pending := 0
+	for _, number := range order {
+		if !targets[number].done && !targets[number].deferred {
+			pending++
+		}
+	}
+	if pending == 0 {
+		return 0, nil
+	}
+
+	locks := newLockRenewer(entity)
+	defer locks.abandonHeld()
+	renewCtx, stopRenewing := context.WithCancel(ctx)
+	defer stopRenewing()
+	go locks.run(renewCtx)
+
+	held := 0
+	var scanHandler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		target := targets[sequenceNumber(msg)]
+		if target == nil || target.done || target.deferred {
+			held++
+			locks.hold(msg)
+			return nil
+		}
+
+		pending--
+		target.result.Abandoned = held
+		return handler(msgCtx, msg)
+	}
+
+	for received := 0; pending > 0 && received < scanLength; received++ {
+		idleCtx, cancel := context.WithTimeout(ctx, drainIdleTimeout)
+		err := receiveOne(idleCtx, entity, scanHandler)
+		idleErr := idleCtx.Err()
+		cancel()
+
+		if ctx.Err() != nil {
+			return held, ctx.Err()
+		}
+		if idleErr == context.DeadlineExceeded {
+			return held, nil
+		}
+		if err != nil {
+			return held, err
+		}
+	}
+
+	return held, nil
+}
+
+// applyMessageAction settles a picked message, moved messages are sent before being completed and abandoned if the
+// send fails
+func applyMessageAction(ctx context.Context, msg *servicebus.Message, operation MessageOperation, sender MessageSender) (string, error) {
+	settleCtx, cancel := context.WithTimeout(ctx, SettleTimeout)
+	defer cancel()
+
+	switch operation.Action {
+	case MessageActionComplete:
+		return MessageStatusCompleted, msg.Complete(settleCtx)
+	case MessageActionReceive:
+		return MessageStatusReceived, msg.Complete(settleCtx)
+	case MessageActionDefer:
+		return MessageStatusDeferred, msg.Defer(settleCtx)
+	case MessageActionDeadLetter:
+		reason := operation.Reason
+		if reason == "" {
+			reason = defaultDeadLetterReason
+		}
+		description := operation.Description
+		if description == "" {
+			description = reason
+		}
+		// deferred messages are settled through the management link, which only keeps the description
+		info := map[string]string{
+			"DeadLetterReason":           reason,
+			"DeadLetterErrorDescription": description,
+		}
+		return MessageStatusDeadLettered, msg.DeadLetterWithInfo(settleCtx, errors.New(description), servicebus.ErrorInternalError, info)
+	case MessageActionMove:
+		moved, err := movedMessage(msg, operation.KeepID)
+		if err == nil {
+			moved, err = operation.Transform.Apply(moved)
+		}
+		if err == nil {
+			err = SendMessageBatch(settleCtx, sender, moved)
+		}
+		if err != nil {
+			if abandonErr := msg.Abandon(settleCtx); abandonErr != nil {
+				logger.Error("Could not abandon the message " + msg.ID + ", " + abandonErr.Error())
+			}
+			return MessageStatusFailed, err
+		}
+		return MessageStatusMoved, msg.Complete(settleCtx)
+	}
+
+	return MessageStatusFailed, errors.New("invalid action " + operation.Action)
+}
+
+// failTargets marks the picked messages that were not handled yet as failed
+func failTargets(targets map[int64]*messageTarget, numbers []int64, message string) {
+	for _, number := range numbers {
+		if target := targets[number]; target != nil && !target.done {
+			target.done = true
+			target.result.Status = MessageStatusFailed
+			target.result.Error = message
+		}
+	}
+}
+
+// sequenceNumber gets the sequence number of a received or peeked message
+func sequenceNumber(msg *servicebus.Message) int64 {
+	if msg.SystemProperties == nil || msg.SystemProperties.SequenceNumber == nil {
+		return -1
+	}
+	return *msg.SystemProperties.SequenceNumber
+}
+
+// isDeferred returns true if a peeked message was deferred
+func isDeferred(msg *servicebus.Message) bool {
+	if msg.SystemProperties == nil {
+		return false
+	}
+
+	switch state := msg.SystemProperties.Annotations[messageStateAnnotation].(type) {
+	case int32:
+		return state == deferredMessageState
+	case int64:
+		return state == deferredMessageState
+	case int:
+		return state == deferredMessageState
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/filter"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+// copyBatchSize is the number of copies sent per operation while copying messages
+const copyBatchSize = 100
+
+// MoveOptions Options of moving or copying the messages of a queue or subscription to a queue or topic
+type MoveOptions struct {
+	// DeadLetter takes the messages from the dead letter queue instead of the active messages
+	DeadLetter bool
+	// Count is the maximum number of messages moved, zero moves all of them
+	Count int
+	// Where is the expression the messages need to match, every message matches an empty expression
+	Where *filter.Expression
+	// Copy sends copies of the messages leaving them in the source
+	Copy bool
+	// Transform changes the messages before they are sent, a message that can not be transformed is left in the
+	// source
+	Transform *transform.Pipeline
+	// KeepID sends the messages with their id instead of a new one, a destination with duplicate detection drops
+	// the messages it already received with the same id
+	KeepID bool
+}
+
+// MoveMessages Moves or copies the messages of a queue or subscription, or of their dead letter queue, to a queue or
+// topic keeping their properties and time to live, they get a new id unless KeepID is set.
+//
+// Moved messages are received in peek lock and only completed once they were sent, the dead letter properties are
+// not kept. With an expression the messages that do not match are held locked while moving and abandoned at the end,
+// which increases their delivery count. Copies are peeked so the source is left untouched, the copy stops at the
+// first batch that could not be sent
+func (s *ServiceBusCli) MoveMessages(ctx context.Context, from *Endpoint, to *Endpoint, options MoveOptions, progress ProgressFunc) error {
+	if !from.CanReceive() {
+		return errors.New("can not receive messages from " + from.String() + ", topics need a subscription")
+	}
+	if !to.CanSend() {
+		return errors.New("can not send messages to " + to.String() + ", send them to its topic")
+	}
+	// the messages sent would be received again, only the dead letter queue can be sent back to its entity
+	if !options.DeadLetter && from.FeedsBack(to) {
+		return errors.New("can not move or copy the messages of " + from.String() + " to " + to.String() + ", they would be received again")
+	}
+
+	if !options.Copy && !options.Where.IsEmpty() {
+		return s.moveMatchingMessages(ctx, from, to, options, progress)
+	}
+
+	sender, err := s.GetEndpointSender(to)
+	if err != nil {
+		return err
+	}
+	defer sender.Close(context.Background())
+
+	if options.Copy {
+		entity, err := s.getEndpointPeeker(from, options.DeadLetter)
+		if err != nil {
+			return err
+		}
+
+		logger.LogHighlight("Copying the messages of %v to %v in service bus %v", log.Info, from.String(), to.String(), s.Namespace.Name)
+		return copyMessages(ctx, entity, sender, options, progress)
+	}
+
+	// prefetching past the count would lock messages that are not moved
+	prefetch := drainPrefetchCount
+	if options.Count > 0 && options.Count < prefetch {
+		prefetch = options.Count
+	}
+	receiver, _, err := s.getEndpointReceiver(ctx, from, options.DeadLetter, prefetch)
+	if err != nil {
+		return err
+	}
+
+	logger.LogHighlight("Moving the messages of %v to %v in service bus %v", log.Info, from.String(), to.String(), s.Namespace.Name)
+	return relayMessages(ctx, receiver, sender, options.Count, func(msg *servicebus.Message) (*servicebus.Message, error) {
+		moved, err := movedMessage(msg, options.KeepID)
+		if err != nil {
+			return nil, err
+		}
+		return options.Transform.Apply(moved)
+	}, progress)
+}
+
+// moveMatchingMessages receives the messages from the head of the source moving the ones matching the expression, the
+// others are held with their locks renewed so they are not received again and abandoned at the end, which increases
+// their delivery count. Only the messages that were received are abandoned, the move stops at the count or once the
+// source has no more messages
+func (s *ServiceBusCli) moveMatchingMessages(ctx context.Context, from *Endpoint, to *Endpoint, options MoveOptions, progress ProgressFunc) error {
+	sender, err := s.GetEndpointSender(to)
+	if err != nil {
+		return err
+	}
+	defer sender.Close(context.Background())
+
+	receiver, entity, err := s.getEndpointReceiver(ctx, from, options.DeadLetter, drainPrefetchCount)
+	if err != nil {
+		return err
+	}
+	defer receiver.Close(context.Background())
+
+	locks := newLockRenewer(entity)
+	held := make(map[int64]*servicebus.Message)
+	defer func() {
+		settleCtx, cancel := context.WithTimeout(context.Background(), SettleTimeout)
+		defer cancel()
+		// the held messages are still locked so only the prefetched ones are received again
+		abandonPrefetched(settleCtx, receiver, drainPrefetchCount)
+		locks.abandonHeld()
+		if len(held) > 0 {
+			logger.LogHighlight("Abandoned %v messages of %v that did not match, their delivery count went up", log.Info, fmt.Sprint(len(held)), from.String())
+		}
+	}()
+	renewCtx, stopRenewing := context.WithCancel(ctx)
+	defer stopRenewing()
+	go locks.run(renewCtx)
+
+	moved, consecutiveFailures := 0, 0
+	var sendErr error
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		if !options.Where.Match(msg) {
+			// a held message delivered again because its lock was lost replaces the old one
+			number := sequenceNumber(msg)
+			locks.release(held[number])
+			held[number] = msg
+			locks.hold(msg)
+			return nil
+		}
+
+		prepared, err := movedMessage(msg, options.KeepID)
+		if err == nil {
+			prepared, err = options.Transform.Apply(prepared)
+		}
+		if err == nil {
+			err = SendMessageBatch(msgCtx, sender, prepared)
+		}
+		if err != nil {
+			sendErr = err
+			consecutiveFailures++
+			progress(0, 1)
+			return msg.Abandon(msgCtx)
+		}
+
+		moved++
+		consecutiveFailures = 0
+		progress(1, 0)
+		return msg.Complete(msgCtx)
+	}
+
+	logger.LogHighlight("Moving the matching messages of %v to %v in service bus %v", log.Info, from.String(), to.String(), s.Namespace.Name)
+	for options.Count <= 0 || moved < options.Count {
+		idleCtx, cancel := context.WithTimeout(ctx, drainIdleTimeout)
+		err := receiveOne(idleCtx, receiver, handler)
+		idleErr := idleCtx.Err()
+		cancel()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if idleErr == context.DeadlineExceeded {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// abandoned messages are received again straight away so we need to give up at some point
+		if consecutiveFailures >= maxConsecutiveFailures {
+			return sendErr
+		}
+	}
+	return nil
+}
+
+// getEndpointReceiver gets a peek lock receiver for the queue or subscription of the endpoint or their dead letter
+// queue with the entity that renews the locks of its messages, every prefetched message is locked so the prefetch
+// should not go past the messages that are needed
+func (s *ServiceBusCli) getEndpointReceiver(ctx context.Context, endpoint *Endpoint, deadLetter bool, prefetch int) (servicebus.ReceiveOner, lockRenewable, error) {
+	options := []servicebus.ReceiverOption{
+		servicebus.ReceiverWithReceiveMode(servicebus.PeekLockMode),
+		servicebus.ReceiverWithPrefetchCount(uint32(prefetch)),
+	}
+
+	suffix := ""
+	if deadLetter {
+		suffix = deadLetterQueuePath
+	}
+
+	if endpoint.Queue != "" {
+		if queue, err := s.GetQueue(endpoint.Queue); queue == nil || err != nil {
+			logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, endpoint.Queue, s.Namespace.Name)
+			return nil, nil, errors.New("Could not find queue " + endpoint.Queue + " in service bus " + s.Namespace.Name)
+		}
+		queue, err := s.Namespace.NewQueue(endpoint.Queue + suffix)
+		if err != nil {
+			return nil, nil, err
+		}
+		receiver, err := queue.NewReceiver(ctx, options...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return receiver, queue, nil
+	}
+
+	subscription, err := s.getSubscriptionClient(endpoint.Topic, endpoint.Subscription)
+	if err != nil {
+		return nil, nil, err
+	}
+	entity, err := subscription.Topic.NewSubscription(endpoint.Subscription + suffix)
+	if err != nil {
+		return nil, nil, err
+	}
+	receiver, err := entity.NewReceiver(ctx, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return receiver, entity, nil
+}
+
+// copyMessages peeks the messages matching the expression sending copies of them in batches
+func copyMessages(ctx context.Context, entity peeker, sender MessageSender, options MoveOptions, progress ProgressFunc) error {
+	batch := make([]*servicebus.Message, 0)
+	flush := func() error {
+		if err := SendMessageBatch(ctx, sender, batch...); err != nil {
+			progress(0, len(batch))
+			return err
+		}
+		progress(len(batch), 0)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := peekMatching(ctx, entity, SearchOptions{Where: options.Where, MaxResults: options.Count}, func(msg *servicebus.Message) error {
+		copied, err := movedMessage(msg, options.KeepID)
+		if err == nil {
+			copied, err = options.Transform.Apply(copied)
+		}
+		if err != nil {
+			return err
+		}
+		batch = append(batch, copied)
+		if len(batch) >= copyBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	return err
+}
+
+// movedMessage copies a message that is moved or copied with a new id, unless the id is kept, so the duplicate
+// detection of the destination does not drop a message that is sent back to it
+func movedMessage(msg *servicebus.Message, keepID bool) (*servicebus.Message, error) {
+	if keepID {
+		return copyMessage(msg), nil
+	}
+	return newResubmittedMessage(msg)
+}
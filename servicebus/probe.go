@@ -0,0 +1,375 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/v3/uuid"
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+)
+
+const (
+	// ProbeIDProperty and ProbeSequenceProperty are the user properties that identify probe messages
+	ProbeIDProperty       = "ServiceBusCli.ProbeId"
+	ProbeSequenceProperty = "ServiceBusCli.ProbeSequence"
+	// probeLatencyWindow is the number of recent messages the latency percentiles are calculated from
+	probeLatencyWindow = 10000
+)
+
+var (
+	// probeSources are the entities the running probes receive from, two probes on the same entity would receive
+	// each other's messages
+	probeSources      = make(map[string]bool)
+	probeSourcesMutex sync.Mutex
+)
+
+// ProbeReportFunc Receives the state of a probe every time a message is sent
+type ProbeReportFunc func(report entities.ProbeReport)
+
+// ProbeSourceInUse Returns true if a running probe already receives from the entity the probe would receive from
+func ProbeSourceInUse(request entities.ProbeRequest) bool {
+	probeSourcesMutex.Lock()
+	defer probeSourcesMutex.Unlock()
+
+	return probeSources[strings.ToLower(request.Source())]
+}
+
+// RunProbe Sends timestamped probe messages and receives them back measuring the end to end delivery latency,
+// the messages can be received from a different entity to check forwarding chains. Only one probe can receive from
+// an entity at a time. Only the messages of this probe are completed, any other message is left locked until its
+// lock expires, which increases its delivery count, so the receiving entity should only be used by the probe
+func (s *ServiceBusCli) RunProbe(ctx context.Context, request entities.ProbeRequest, report ProbeReportFunc) (*entities.ProbeReport, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	source := strings.ToLower(request.Source())
+	probeSourcesMutex.Lock()
+	if probeSources[source] {
+		probeSourcesMutex.Unlock()
+		return nil, errors.New("a probe is already receiving from " + request.Source() + ", probes on the same entity receive each other's messages")
+	}
+	probeSources[source] = true
+	probeSourcesMutex.Unlock()
+	defer func() {
+		probeSourcesMutex.Lock()
+		delete(probeSources, source)
+		probeSourcesMutex.Unlock()
+	}()
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	var sender MessageSender
+	if request.Queue != "" {
+		sender, err = s.GetQueueSender(request.Queue)
+	} else {
+		sender, err = s.GetTopicSender(request.Topic)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer sender.Close(context.Background())
+	// probe messages are not validated, their body is not a message of the entity
+	if checked, isChecked := sender.(*schemaSender); isChecked {
+		sender = checked.MessageSender
+	}
+
+	receiver, err := s.getProbeReceiver(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer receiver.Close(context.Background())
+
+	tracker := newProbeTracker(id.String(), request)
+	logger.LogHighlight("Probing from %v to %v with probe id %v", log.Info, request.Target(), request.Source(), tracker.id)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	receiveErr := make(chan error, 1)
+	go func() {
+		receiveErr <- receiveProbeMessages(ctx, receiver, tracker)
+	}()
+
+	ticker := time.NewTicker(request.Interval())
+	defer ticker.Stop()
+
+	var runErr error
+	consecutiveFailures := 0
+probe:
+	for {
+		if request.TotalMessages <= 0 || tracker.sentCount() < request.TotalMessages {
+			if err := tracker.send(ctx, sender); err != nil && ctx.Err() == nil {
+				logger.Error(err.Error())
+				consecutiveFailures++
+				if consecutiveFailures >= maxConsecutiveFailures {
+					runErr = err
+					break
+				}
+			} else {
+				consecutiveFailures = 0
+			}
+		} else if tracker.pendingCount() == 0 {
+			break
+		}
+
+		tracker.expire()
+		if report != nil {
+			report(tracker.report())
+		}
+
+		select {
+		case <-ctx.Done():
+			break probe
+		case err := <-receiveErr:
+			runErr = err
+			break probe
+		case <-ticker.C:
+		}
+	}
+
+	cancel()
+	tracker.expire()
+	result := tracker.report()
+	return &result, runErr
+}
+
+// getProbeReceiver opens a peek lock receiver on the entity the probe messages are received from
+func (s *ServiceBusCli) getProbeReceiver(ctx context.Context, request entities.ProbeRequest) (servicebus.ReceiveOner, error) {
+	queueName := request.ReceiveQueue
+	if queueName == "" && request.ReceiveTopic == "" && request.Queue != "" {
+		queueName = request.Queue
+	}
+
+	if queueName != "" {
+		queue, err := s.GetQueue(queueName)
+		if queue == nil || err != nil {
+			logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, queueName, s.Namespace.Name)
+			return nil, errors.New("Could not find queue " + queueName + " in service bus " + s.Namespace.Name)
+		}
+		return queue.NewReceiver(ctx)
+	}
+
+	topicName := request.ReceiveTopic
+	if topicName == "" {
+		topicName = request.Topic
+	}
+	subscription, err := s.getSubscriptionClient(topicName, request.Subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription.NewReceiver(ctx)
+}
+
+// receiveProbeMessages receives messages until the context is cancelled. The messages that are not from this probe
+// are left unsettled, abandoning them would deliver them again straight away and they would reach the maximum
+// delivery count of the entity in a few seconds, this way they are only delivered again once their lock expires
+func receiveProbeMessages(ctx context.Context, receiver servicebus.ReceiveOner, tracker *probeTracker) error {
+	defer trackListener()()
+
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		if probeID, isProbe := msg.UserProperties[ProbeIDProperty]; !isProbe || fmt.Sprint(probeID) != tracker.id {
+			logger.LogHighlight("Message %v is not from this probe, it is left locked until its lock expires", log.Warning, msg.ID)
+			return nil
+		}
+
+		tracker.receive(msg)
+		return msg.Complete(msgCtx)
+	}
+
+	for {
+		err := receiveOne(ctx, receiver, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type probeMessage struct {
+	sequence int64
+	sentAt   time.Time
+}
+
+// probeTracker keeps the state of the messages sent by a probe
+type probeTracker struct {
+	id      string
+	request entities.ProbeRequest
+
+	mu           sync.Mutex
+	sequence     int64
+	lastSequence int64
+	pending      map[string]probeMessage
+	lost         map[string]probeMessage
+	latencies    []time.Duration
+	last         time.Duration
+	result       entities.ProbeReport
+}
+
+func newProbeTracker(id string, request entities.ProbeRequest) *probeTracker {
+	now := time.Now()
+	return &probeTracker{
+		id:        id,
+		request:   request,
+		pending:   make(map[string]probeMessage),
+		lost:      make(map[string]probeMessage),
+		latencies: make([]time.Duration, 0),
+		result: entities.ProbeReport{
+			ProbeID:    id,
+			Target:     request.Target(),
+			Source:     request.Source(),
+			SloInMilli: request.SloInMilli,
+			StartedAt:  now,
+			UpdatedAt:  now,
+		},
+	}
+}
+
+// send sends the next probe message, the correlation id is unique for every message
+func (t *probeTracker) send(ctx context.Context, sender MessageSender) error {
+	correlationID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.sequence++
+	sequence := t.sequence
+	t.mu.Unlock()
+
+	sentAt := time.Now()
+	message := entities.MessageRequest{
+		Label:         "ServiceBusCli.Probe",
+		CorrelationID: correlationID.String(),
+		Data: map[string]interface{}{
+			"probeId":  t.id,
+			"sequence": sequence,
+			"sentAt":   sentAt.UTC().Format(time.RFC3339Nano),
+		},
+		UserProperties: map[string]interface{}{
+			ProbeIDProperty:       t.id,
+			ProbeSequenceProperty: sequence,
+		},
+	}
+	sbMessage, err := message.ToServiceBus()
+	if err != nil {
+		return err
+	}
+
+	// registering it before sending as it can be received before the send returns
+	t.mu.Lock()
+	t.pending[message.CorrelationID] = probeMessage{sequence: sequence, sentAt: sentAt}
+	t.result.Sent++
+	t.mu.Unlock()
+
+	if err := SendMessageBatch(ctx, sender, sbMessage); err != nil {
+		t.mu.Lock()
+		delete(t.pending, message.CorrelationID)
+		t.result.Sent--
+		t.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (t *probeTracker) receive(msg *servicebus.Message) {
+	receivedAt := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sent, isPending := t.pending[msg.CorrelationID]
+	if isPending {
+		delete(t.pending, msg.CorrelationID)
+	} else if sent, isPending = t.lost[msg.CorrelationID]; isPending {
+		delete(t.lost, msg.CorrelationID)
+		t.result.Lost--
+		t.result.Late++
+	} else {
+		t.result.Duplicates++
+		return
+	}
+
+	latency := receivedAt.Sub(sent.sentAt)
+	t.result.Received++
+	t.last = latency
+	t.latencies = append(t.latencies, latency)
+	if len(t.latencies) > probeLatencyWindow {
+		t.latencies = t.latencies[len(t.latencies)-probeLatencyWindow:]
+	}
+	if t.request.SloInMilli > 0 && latency > time.Duration(t.request.SloInMilli)*time.Millisecond {
+		t.result.OverSlo++
+	}
+
+	if sent.sequence < t.lastSequence {
+		t.result.OutOfOrder++
+	} else {
+		t.lastSequence = sent.sequence
+	}
+}
+
+// expire marks the pending messages older than the timeout as lost
+func (t *probeTracker) expire() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	timeout := t.request.Timeout()
+	for correlationID, message := range t.pending {
+		if now.Sub(message.sentAt) > timeout {
+			delete(t.pending, correlationID)
+			t.lost[correlationID] = message
+			t.result.Lost++
+		}
+	}
+
+	// lost messages are only kept for a while to count them as late if they still arrive
+	for correlationID, message := range t.lost {
+		if now.Sub(message.sentAt) > 10*timeout {
+			delete(t.lost, correlationID)
+		}
+	}
+}
+
+func (t *probeTracker) sentCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.result.Sent
+}
+
+func (t *probeTracker) pendingCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.pending)
+}
+
+func (t *probeTracker) report() entities.ProbeReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := t.result
+	result.Pending = len(t.pending)
+	result.LastInMilli = float64(t.last) / float64(time.Millisecond)
+	result.UpdatedAt = time.Now()
+
+	samples := make([]time.Duration, len(t.latencies))
+	copy(samples, t.latencies)
+	result.Latency = entities.NewLatencyReport(samples)
+
+	return result
+}
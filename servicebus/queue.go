@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"sync"
 	"time"
 
@@ -43,6 +42,7 @@ func (s *ServiceBusCli) GetQueue(queueName string) (*servicebus.Queue, error) {
 	}
 
 	qe, err := s.QueueManager.Get(ctx, queueName)
+	observeOperation(OperationManage, err)
 
 	if err != nil {
 		fmt.Println(err)
@@ -65,7 +65,9 @@ func (s *ServiceBusCli) GetQueueDetails(queueName string) (*servicebus.QueueEnti
 		s.GetQueueManager()
 	}
 
-	return s.QueueManager.Get(ctx, queueName)
+	queue, err := s.QueueManager.Get(ctx, queueName)
+	observeOperation(OperationManage, err)
+	return queue, err
 }
 
 // ListQueues Lists all the Queues in a Service Bus
@@ -80,7 +82,9 @@ func (s *ServiceBusCli) ListQueues() ([]*servicebus.QueueEntity, error) {
 		return nil, commonError
 	}
 
-	return qm.List(ctx)
+	queues, err := qm.List(ctx)
+	observeOperation(OperationManage, err)
+	return queues, err
 }
 
 // CreateQueue Creates a queue in the service bus namespace
@@ -165,6 +169,7 @@ func (s *ServiceBusCli) CreateQueue(queue entities.QueueRequest) error {
 	}
 
 	_, err := qm.Put(ctx, queue.Name, opts...)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
@@ -189,6 +194,7 @@ func (s *ServiceBusCli) DeleteQueue(queueName string) error {
 	qm := s.GetQueueManager()
 
 	err := qm.Delete(ctx, queueName)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
@@ -230,11 +236,16 @@ func (s *ServiceBusCli) SendQueueMessage(queueName string, message entities.Mess
 		return err
 	}
 
+	if err := s.checkSchemas(queueName, "", sbMessage); err != nil {
+		return err
+	}
+
 	err = queue.Send(ctx, sbMessage)
+	observeOperation(OperationSend, err)
 
 	if err != nil {
 		logger.Error(err.Error())
-		os.Exit(1)
+		return err
 	}
 
 	logger.LogHighlight("Service bus queue message was sent successfully to %v queue in service bus %v", log.Info, queueName, s.Namespace.Name)
@@ -283,11 +294,16 @@ func (s *ServiceBusCli) SendBulkQueueMessage(queueName string, messages ...entit
 		return err
 	}
 
+	if err := s.checkSchemas(queueName, "", sbMessages...); err != nil {
+		return err
+	}
+
 	err = queue.SendBatch(ctx, servicebus.NewMessageBatchIterator(262144, sbMessages...))
+	observeOperation(OperationSend, err)
 
 	if err != nil {
 		logger.Error(err.Error())
-		os.Exit(1)
+		return err
 	}
 
 	logger.LogHighlight("Service bus bulk queue messages were sent successfully to %v queue in service bus %v", log.Info, queueName, s.Namespace.Name)
@@ -314,11 +330,16 @@ func (s *ServiceBusCli) SendQueueServiceBusMessage(queueName string, sbMessage *
 		return commonError
 	}
 
+	if err := s.checkSchemas(queueName, "", sbMessage); err != nil {
+		return err
+	}
+
 	err = queue.Send(ctx, sbMessage)
+	observeOperation(OperationSend, err)
 
 	if err != nil {
 		logger.Error(err.Error())
-		os.Exit(1)
+		return err
 	}
 
 	logger.LogHighlight("Service bus queue message was sent successfully to %v queue in service bus %v", log.Info, queueName, s.Namespace.Name)
@@ -331,18 +352,24 @@ func (s *ServiceBusCli) SendQueueServiceBusMessage(queueName string, sbMessage *
 func (s *ServiceBusCli) SubscribeToQueue(queueName string) error {
 	var commonError error
 
+	printMessage := func(msg *servicebus.Message) {
+		s.Renderer.Print(s.transformForPrint(msg), func() {
+			logger.LogHighlight("%v Received message %v on queue %v with label %v", log.Info, msg.SystemProperties.EnqueuedTime.String(), msg.ID, queueName, msg.Label)
+		})
+		warnSchema(msg, s.CheckQueueMessageSchema(queueName, msg))
+	}
+
 	var concurrentHandler servicebus.HandlerFunc = func(ctx context.Context, msg *servicebus.Message) error {
-		logger.LogHighlight("%v Received message %v on queue %v with label %v", log.Info, msg.SystemProperties.EnqueuedTime.String(), msg.ID, queueName, msg.Label)
-		logger.Info("User Properties:")
-		jsonString, _ := json.MarshalIndent(msg.UserProperties, "", "  ")
-		fmt.Println(string(jsonString))
-		logger.Info("Message Body:")
-		fmt.Println(string(msg.Data))
-
-		if !s.Peek {
-			return msg.Complete(ctx)
+		if !s.Limits.take() {
+			// received and deleted messages are already gone, there is no lock to give back
+			if s.Receive.ReceiveAndDelete {
+				return nil
+			}
+			return msg.Abandon(ctx)
 		}
-		return nil
+		defer s.Limits.done()
+
+		return s.inspect(ctx, msg, func() { printMessage(msg) })
 	}
 
 	logger.LogHighlight("Subscribing to queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
@@ -363,8 +390,18 @@ func (s *ServiceBusCli) SubscribeToQueue(queueName string) error {
 	}
 	s.ActiveQueue = queue
 
+	// peeking browses the messages instead of receiving them, so they are never locked
+	if s.Peek {
+		logger.LogHighlight("Starting to browse messages in queue %v for service bus %v", log.Info, queueName, s.Namespace.Name)
+		if err := s.browseUntilClosed(ctx, queue, s.CloseQueueListener, printMessage); err != nil {
+			logger.Error(err.Error())
+			return err
+		}
+		return s.CloseQueueSubscription()
+	}
+
 	logger.LogHighlight("Starting to receive messages queue %v for service bus %v", log.Info, queueName, s.Namespace.Name)
-	receiver, err := queue.NewReceiver(ctx)
+	receiver, err := queue.NewReceiver(ctx, s.Receive.receiverOptions()...)
 
 	if err != nil {
 		commonError := errors.New("Could not create channel for queue " + queueName + " in " + s.Namespace.Name + " bus, subscription was not found")
@@ -372,8 +409,12 @@ func (s *ServiceBusCli) SubscribeToQueue(queueName string) error {
 		return commonError
 	}
 
-	listenerHandler := receiver.Listen(ctx, concurrentHandler)
+	s.locks = newLockRenewer(queue)
+	go s.locks.run(ctx)
+
+	listenerHandler := receiver.Listen(ctx, s.Receive.concurrent(concurrentHandler))
 	s.ActiveQueueListenerHandle = listenerHandler
+	defer trackListener()()
 	defer listenerHandler.Close(ctx)
 
 	if <-s.CloseQueueListener {
@@ -387,21 +428,25 @@ func (s *ServiceBusCli) CloseQueueSubscription() error {
 	logger.LogHighlight("Closing the subscription for %v queue in service bus %v", log.Info, s.ActiveQueue.Name, s.Namespace.Name)
 	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
 	defer cancel()
-	s.ActiveQueueListenerHandle.Close(ctx)
+	// held messages are abandoned through the receiver, so before it is closed
+	s.locks.abandonHeld()
+	if s.ActiveQueueListenerHandle != nil {
+		s.ActiveQueueListenerHandle.Close(ctx)
+	}
 	s.ActiveQueue = nil
 	s.ActiveQueueListenerHandle = nil
 	s.CloseQueueListener <- false
 	return nil
 }
 
-// GetSubscriptionActiveMessages Gets messages from a subscription
-func (s *ServiceBusCli) GetQueueActiveMessages(queueName string, qty int, peek bool) ([]servicebus.Message, error) {
+// GetQueueActiveMessages Gets messages from a queue, received messages are prefetched and completed in a batch
+func (s *ServiceBusCli) GetQueueActiveMessages(queueName string, qty int, peek bool, options ReceiveOptions) ([]servicebus.Message, error) {
 	var commonError error
 	messages := make([]servicebus.Message, 0)
 
-	// We will have a maximum of fetch of 100 messages per query
-	if qty > 100 {
-		qty = 100
+	// Peeks have a maximum of 100 messages per query, received batches are only limited by the count of the entity
+	if peek && qty > maxPeekQuantity {
+		qty = maxPeekQuantity
 	}
 
 	logger.LogHighlight("Getting message for queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
@@ -417,6 +462,7 @@ func (s *ServiceBusCli) GetQueueActiveMessages(queueName string, qty int, peek b
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 	queueEntity, commonError := qm.Get(ctx, queueName)
+	observeOperation(OperationManage, commonError)
 	if commonError != nil {
 		return messages, commonError
 	}
@@ -430,9 +476,9 @@ func (s *ServiceBusCli) GetQueueActiveMessages(queueName string, qty int, peek b
 	// If we set the message count to 0 then we will read a batch of the messages that exists
 	if qty == 0 {
 		qty = messageCount
-		// we will need to check again that the message count is not bigger than 100 and will set the limit again if so
-		if qty > 100 {
-			qty = 100
+		// we will need to check again that a peek is not bigger than 100 and will set the limit again if so
+		if peek && qty > maxPeekQuantity {
+			qty = maxPeekQuantity
 		}
 	}
 
@@ -441,71 +487,37 @@ func (s *ServiceBusCli) GetQueueActiveMessages(queueName string, qty int, peek b
 		qty = messageCount
 	}
 
-	var waitForMessages sync.WaitGroup
-	waitForMessages.Add(qty)
-
-	// Creating a message handler function to deal with our messages
-	var messageHandler servicebus.HandlerFunc = func(c context.Context, m *servicebus.Message) error {
-		mCtx, mCancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
-		defer mCancel()
-		m.Complete(mCtx)
-
-		messages = append(messages, *m)
-		// notify the wait group that the message is dealt with
-		waitForMessages.Done()
-		return nil
-	}
-
-	// Creating the receiver for the messages in the subscription
-	messageReceiver, commonError := queue.NewReceiver(ctx)
-
-	if commonError != nil {
-		return nil, commonError
+	// received messages come from a single receiver asking for the whole batch at once
+	if !peek {
+		receiver, err := queue.NewReceiver(ctx, options.batchReceiverOptions(qty)...)
+		if err != nil {
+			return nil, err
+		}
+		defer queue.Close(context.Background())
+		defer receiver.Close(context.Background())
+		return receiveBatch(ctx, receiver, qty, options)
 	}
 
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	// Background task to receive all of the messages we need
-	go func() {
-		if peek {
-			t, err := queue.Peek(ctx)
-			if err != nil {
-				fmt.Println(err.Error())
-			}
-			for i := 0; i < qty; i++ {
-				m, err := t.Next(ctx)
-				if err != nil {
-					fmt.Println(err.Error())
-				}
-				messages = append(messages, *m)
-				waitForMessages.Done()
-			}
-		} else {
-			for i := 0; i < qty; i++ {
-				if err := queue.ReceiveOne(ctx, messageHandler); err != nil {
-					fmt.Println(err.Error())
-				}
-			}
-		}
-	}()
-
-	waitForMessages.Wait()
+	messages, err := peekBatch(ctx, queue, qty)
 
-	// We are finished and we should now close the receiver before leaving
+	// We are finished and we should now close the queue before leaving
 	_ = queue.Close(ctx)
-	_ = messageReceiver.Close(ctx)
 
-	return messages, nil
+	return messages, err
 }
 
-func (s *ServiceBusCli) GetQueueDeadLetterMessages(queueName string, qty int, peek bool) ([]servicebus.Message, error) {
+// GetQueueDeadLetterMessages Gets the dead letters of a queue, received messages are prefetched and completed in a
+// batch while peeked messages are locked and abandoned
+func (s *ServiceBusCli) GetQueueDeadLetterMessages(queueName string, qty int, peek bool, options ReceiveOptions) ([]servicebus.Message, error) {
 	var commonError error
 	messages := make([]servicebus.Message, 0)
 
-	// We will have a maximum of fetch of 100 messages per query
-	if qty > 100 {
-		qty = 100
+	// Peeks have a maximum of 100 messages per query, received batches are only limited by the count of the entity
+	if peek && qty > maxPeekQuantity {
+		qty = maxPeekQuantity
 	}
 
 	logger.LogHighlight("Getting dead letter messages for queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
@@ -526,7 +538,11 @@ func (s *ServiceBusCli) GetQueueDeadLetterMessages(queueName string, qty int, pe
 	qm := s.GetQueueManager()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
-	queueEntity, _ := qm.Get(ctx, queueName)
+	queueEntity, err := qm.Get(ctx, queueName)
+	observeOperation(OperationManage, err)
+	if err != nil {
+		return messages, err
+	}
 
 	if *queueEntity.CountDetails.DeadLetterMessageCount <= 0 {
 		return messages, nil
@@ -537,9 +553,9 @@ func (s *ServiceBusCli) GetQueueDeadLetterMessages(queueName string, qty int, pe
 	// If we set the message count to 0 then we will read a batch of the messages that exists
 	if qty == 0 {
 		qty = messageCount
-		// we will need to check again that the message count is not bigger than 100 and will set the limit again if so
-		if qty > 100 {
-			qty = 100
+		// we will need to check again that a peek is not bigger than 100 and will set the limit again if so
+		if peek && qty > maxPeekQuantity {
+			qty = maxPeekQuantity
 		}
 	}
 
@@ -548,56 +564,33 @@ func (s *ServiceBusCli) GetQueueDeadLetterMessages(queueName string, qty int, pe
 		qty = messageCount
 	}
 
-	var waitForMessages sync.WaitGroup
-	waitForMessages.Add(qty)
-
-	// Creating a message handler function to deal with our messages
-	var messageHandler servicebus.HandlerFunc = func(c context.Context, m *servicebus.Message) error {
-		mCtx, mCancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
-		defer mCancel()
-
-		if peek {
-			m.Abandon(ctx)
-		} else {
-			m.Complete(mCtx)
+	// received messages come from a single receiver asking for the whole batch at once
+	if !peek {
+		receiver, err := queue.NewDeadLetterReceiver(ctx, options.batchReceiverOptions(qty)...)
+		if err != nil {
+			return nil, err
 		}
-
-		messages = append(messages, *m)
-		// notify the wait group that the message is dealt with
-		waitForMessages.Done()
-		return nil
+		defer queue.Close(context.Background())
+		defer receiver.Close(context.Background())
+		return receiveBatch(ctx, receiver, qty, options)
 	}
 
 	// Creating the receiver for the messages in the subscription
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	var deadLetterReceiver servicebus.ReceiveOner
-	if peek {
-		deadLetterReceiver, commonError = queue.NewDeadLetterReceiver(ctx, servicebus.ReceiverWithReceiveMode(servicebus.PeekLockMode))
-
-	} else {
-		deadLetterReceiver, commonError = queue.NewDeadLetterReceiver(ctx)
-	}
+	deadLetterReceiver, commonError := queue.NewDeadLetterReceiver(ctx, servicebus.ReceiverWithReceiveMode(servicebus.PeekLockMode))
 
 	if commonError != nil {
 		return nil, commonError
 	}
 
-	// Background task to receive all of the messages we need
-	go func() {
-		for i := 0; i < qty; i++ {
-			if err := deadLetterReceiver.ReceiveOne(ctx, messageHandler); err != nil {
-				fmt.Println(err.Error())
-			}
-		}
-	}()
-
-	waitForMessages.Wait()
+	// peeked dead letters are locked and abandoned
+	messages, commonError = receiveAndAbandon(ctx, deadLetterReceiver, qty)
 
 	// We are finished and we should now close the receiver before leaving
 	_ = queue.Close(ctx)
 	_ = deadLetterReceiver.Close(ctx)
 
-	return messages, nil
+	return messages, commonError
 }
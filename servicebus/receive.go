@@ -0,0 +1,193 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+)
+
+// Receive modes of the receivers
+const (
+	// ReceiveModePeekLock locks the messages until they are completed or abandoned
+	ReceiveModePeekLock = "peeklock"
+	// ReceiveModeReceiveAndDelete removes the messages as soon as they are received
+	ReceiveModeReceiveAndDelete = "receiveanddelete"
+)
+
+const (
+	// defaultBatchConcurrency is how many messages of a batch are completed at the same time without a concurrency
+	defaultBatchConcurrency = 16
+	// maxPeekQuantity is how many messages a peek returns at most, received batches are only limited by the count
+	// of the entity
+	maxPeekQuantity = 100
+)
+
+// ReceiveOptions Settings of the receivers listening to or getting the messages of a queue or subscription
+type ReceiveOptions struct {
+	// PrefetchCount is how many messages the receiver asks for ahead of the handlers, zero keeps the default
+	PrefetchCount int
+	// MaxConcurrency is how many messages are handled at the same time, zero keeps the default
+	MaxConcurrency int
+	// ReceiveAndDelete removes the messages when they are received instead of locking them until they are
+	// completed, prefetched messages that were not handled yet are lost when the receiver stops
+	ReceiveAndDelete bool
+}
+
+// ParseReceiveMode Parses a receive mode, it returns true for receive and delete and false for peek lock, which
+// is also the mode of an empty value
+func ParseReceiveMode(mode string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ReceiveModePeekLock:
+		return false, nil
+	case ReceiveModeReceiveAndDelete:
+		return true, nil
+	}
+
+	return false, fmt.Errorf("invalid receive mode %v, use %v or %v", mode, ReceiveModePeekLock, ReceiveModeReceiveAndDelete)
+}
+
+// Validate Checks the prefetch count and concurrency are not negative
+func (o ReceiveOptions) Validate() error {
+	if o.PrefetchCount < 0 {
+		return errors.New("the prefetch count can not be negative")
+	}
+	if o.MaxConcurrency < 0 {
+		return errors.New("the concurrency can not be negative")
+	}
+	return nil
+}
+
+// receiverOptions gets the options of the receivers of the listeners
+func (o ReceiveOptions) receiverOptions() []servicebus.ReceiverOption {
+	mode := servicebus.PeekLockMode
+	if o.ReceiveAndDelete {
+		mode = servicebus.ReceiveAndDeleteMode
+	}
+
+	options := []servicebus.ReceiverOption{servicebus.ReceiverWithReceiveMode(mode)}
+	if o.PrefetchCount > 0 {
+		options = append(options, servicebus.ReceiverWithPrefetchCount(uint32(o.PrefetchCount)))
+	}
+	return options
+}
+
+// batchReceiverOptions gets the options of a receiver getting a batch of messages, the whole batch is prefetched
+// unless there is a smaller prefetch count, so the receiver does not ask for more messages than the batch
+func (o ReceiveOptions) batchReceiverOptions(qty int) []servicebus.ReceiverOption {
+	mode := servicebus.PeekLockMode
+	if o.ReceiveAndDelete {
+		mode = servicebus.ReceiveAndDeleteMode
+	}
+
+	return []servicebus.ReceiverOption{
+		servicebus.ReceiverWithReceiveMode(mode),
+		servicebus.ReceiverWithPrefetchCount(uint32(o.batchPrefetch(qty))),
+	}
+}
+
+// batchPrefetch is the prefetch count of a receiver getting a batch of messages
+func (o ReceiveOptions) batchPrefetch(qty int) int {
+	if o.PrefetchCount > 0 && o.PrefetchCount < qty {
+		return o.PrefetchCount
+	}
+	return qty
+}
+
+// concurrent runs the handler of a listener for up to MaxConcurrency messages at the same time, listeners handle one
+// message at a time otherwise. Errors of concurrent handlers are logged as they can not stop the listener anymore
+func (o ReceiveOptions) concurrent(handler servicebus.HandlerFunc) servicebus.HandlerFunc {
+	if o.MaxConcurrency <= 1 {
+		return handler
+	}
+
+	slots := make(chan bool, o.MaxConcurrency)
+	return func(ctx context.Context, msg *servicebus.Message) error {
+		select {
+		case slots <- true:
+		case <-ctx.Done():
+			return nil
+		}
+
+		go func() {
+			defer func() { <-slots }()
+			if err := handler(ctx, msg); err != nil {
+				logger.LogHighlight("Could not handle message %v, %v", log.Error, msg.ID, err.Error())
+			}
+		}()
+		return nil
+	}
+}
+
+// receiveBatch receives up to qty messages from a single receiver, so they are prefetched together instead of
+// opening a link per message, completing up to MaxConcurrency of them at the same time. Messages that could not be
+// completed are logged and still returned, they will be received again once their lock expires.
+//
+// The receiver asks for more messages once half of the prefetched ones were handled, so it can get messages past
+// the batch. In peek lock they are abandoned, received and deleted ones are already gone from the entity so the
+// ones delivered before the receiver closes are returned too instead of being lost
+func receiveBatch(ctx context.Context, receiver servicebus.ReceiveOner, qty int, options ReceiveOptions) ([]servicebus.Message, error) {
+	concurrency := options.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	var completions sync.WaitGroup
+	slots := make(chan bool, concurrency)
+	messages := make([]servicebus.Message, 0)
+	var handler servicebus.HandlerFunc = func(msgCtx context.Context, msg *servicebus.Message) error {
+		messages = append(messages, *msg)
+		if options.ReceiveAndDelete {
+			return nil
+		}
+
+		slots <- true
+		completions.Add(1)
+		go func() {
+			defer completions.Done()
+			defer func() { <-slots }()
+
+			settleCtx, cancel := context.WithTimeout(context.Background(), SettleTimeout)
+			defer cancel()
+			if err := msg.Complete(settleCtx); err != nil {
+				logger.LogHighlight("Could not complete message %v, %v", log.Error, msg.ID, err.Error())
+			}
+		}()
+		return nil
+	}
+
+	defer completions.Wait()
+	for len(messages) < qty {
+		idleCtx, cancel := context.WithTimeout(ctx, drainIdleTimeout)
+		err := receiveOne(idleCtx, receiver, handler)
+		idle := idleCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+		cancel()
+
+		// the count of the entity can be ahead of what can be received, so waiting for too long means we are done
+		if idle {
+			break
+		}
+		if err != nil {
+			return messages, err
+		}
+	}
+
+	if !options.ReceiveAndDelete {
+		abandonPrefetched(ctx, receiver, options.batchPrefetch(qty))
+		return messages, nil
+	}
+
+	for extra := 0; extra < options.batchPrefetch(qty); extra++ {
+		drainCtx, cancel := context.WithTimeout(ctx, prefetchDrainTimeout)
+		err := receiveOne(drainCtx, receiver, handler)
+		cancel()
+		if err != nil {
+			break
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,86 @@
+package servicebus
+
+import (
+	"errors"
+	"fmt"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/schema"
+	"github.com/cjlapao/servicebuscli-go/templating"
+)
+
+// CheckQueueMessageSchema Validates a message of a queue against its schema in the registry, it returns a
+// *schema.Error if the body does not match it
+func (s *ServiceBusCli) CheckQueueMessageSchema(queueName string, msg *servicebus.Message) error {
+	if err := s.Schemas.Check(queueName, "", msg); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckTopicMessageSchema Validates a message of a topic or one of its subscriptions against its schema in the
+// registry, it returns a *schema.Error if the body does not match it
+func (s *ServiceBusCli) CheckTopicMessageSchema(topicName string, msg *servicebus.Message) error {
+	if err := s.Schemas.Check("", topicName, msg); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkSchemas validates the messages before they are sent, the first one not matching its schema stops the send
+func (s *ServiceBusCli) checkSchemas(queueName string, topicName string, messages ...*servicebus.Message) error {
+	for index, msg := range messages {
+		if err := s.Schemas.Check(queueName, topicName, msg); err != nil {
+			if len(messages) > 1 {
+				logger.LogHighlight("Message %v was not sent, it does not match the schema %v of %v", log.Error, fmt.Sprint(index+1), err.File, err.Target)
+			} else {
+				logger.LogHighlight("Message was not sent, it does not match the schema %v of %v", log.Error, err.File, err.Target)
+			}
+			for _, validationError := range err.Errors {
+				logger.Error(validationError.Field + " " + validationError.Message)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckTemplateSchema Renders the first message of a template and validates it against the schema of the queue or
+// topic, so jobs sending messages that do not match it can be rejected before they start
+func (s *ServiceBusCli) CheckTemplateSchema(queueName string, topicName string, message entities.MessageRequest, rows []map[string]interface{}) error {
+	template, err := templating.New(message, rows)
+	if err != nil {
+		return err
+	}
+	rendered, err := template.Render(0)
+	if err != nil {
+		return err
+	}
+	sbMessage, err := rendered.ToServiceBus()
+	if err != nil {
+		return err
+	}
+
+	if err := s.Schemas.Check(queueName, topicName, sbMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+// isSchemaError checks if a send failed because a message does not match its schema
+func isSchemaError(err error) bool {
+	var schemaError *schema.Error
+	return errors.As(err, &schemaError)
+}
+
+// warnSchema flags a received message that does not match its schema, the message is still processed
+func warnSchema(msg *servicebus.Message, err error) {
+	if err == nil {
+		return
+	}
+
+	logger.LogHighlight("Message %v does not match its schema, %v", log.Warning, msg.ID, err.Error())
+}
@@ -0,0 +1,125 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/filter"
+)
+
+const (
+	// searchPageSize is the number of messages peeked per request while searching
+	searchPageSize = 250
+	// searchProgressInterval is how many messages are searched between progress logs
+	searchProgressInterval = 10000
+	// deadLetterQueuePath is added to the path of a queue or subscription to get its dead letter queue
+	deadLetterQueuePath = "/$DeadLetterQueue"
+)
+
+// SearchOptions Options of a search through the messages of a queue or subscription
+type SearchOptions struct {
+	// Where is the expression the messages need to match, every message matches an empty expression
+	Where *filter.Expression
+	// DeadLetter searches the dead letter queue instead of the active messages
+	DeadLetter bool
+	// MaxResults stops the search once this many messages match, zero searches the whole backlog
+	MaxResults int
+}
+
+// peeker is the queue or subscription being searched
+type peeker interface {
+	Peek(ctx context.Context, options ...servicebus.PeekOption) (servicebus.MessageIterator, error)
+	Close(ctx context.Context) error
+}
+
+// SearchQueueMessages Peeks through the whole backlog of a queue returning the messages matching the expression,
+// the messages are not locked or removed
+func (s *ServiceBusCli) SearchQueueMessages(ctx context.Context, queueName string, options SearchOptions) ([]servicebus.Message, error) {
+	return s.SearchEndpointMessages(ctx, &Endpoint{Queue: queueName}, options)
+}
+
+// SearchSubscriptionMessages Peeks through the whole backlog of a topic subscription returning the messages matching
+// the expression, the messages are not locked or removed
+func (s *ServiceBusCli) SearchSubscriptionMessages(ctx context.Context, topicName string, subscriptionName string, options SearchOptions) ([]servicebus.Message, error) {
+	return s.SearchEndpointMessages(ctx, &Endpoint{Topic: topicName, Subscription: subscriptionName}, options)
+}
+
+// SearchEndpointMessages Peeks through the whole backlog of the queue or subscription of the endpoint returning the
+// messages matching the expression, the messages are not locked or removed
+func (s *ServiceBusCli) SearchEndpointMessages(ctx context.Context, endpoint *Endpoint, options SearchOptions) ([]servicebus.Message, error) {
+	entity, err := s.getEndpointPeeker(endpoint, options.DeadLetter)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.LogHighlight("Searching the messages of %v in service bus %v", log.Info, endpoint.String(), s.Namespace.Name)
+	messages := make([]servicebus.Message, 0)
+	err = peekMatching(ctx, entity, options, func(msg *servicebus.Message) error {
+		messages = append(messages, *msg)
+		return nil
+	})
+	return messages, err
+}
+
+// getEndpointPeeker gets the queue or subscription of the endpoint, or their dead letter queue, to peek its messages
+func (s *ServiceBusCli) getEndpointPeeker(endpoint *Endpoint, deadLetter bool) (peeker, error) {
+	if !endpoint.CanReceive() {
+		return nil, errors.New("can not receive messages from " + endpoint.String() + ", topics need a subscription")
+	}
+
+	suffix := ""
+	if deadLetter {
+		suffix = deadLetterQueuePath
+	}
+	if endpoint.Queue != "" {
+		return s.Namespace.NewQueue(endpoint.Queue + suffix)
+	}
+
+	topic := s.GetTopic(endpoint.Topic)
+	if topic == nil {
+		return nil, errors.New("Could not find topic " + endpoint.Topic + " in service bus " + s.Namespace.Name)
+	}
+	return topic.NewSubscription(endpoint.Subscription + suffix)
+}
+
+// peekMatching peeks the messages page by page from the oldest calling handle with the ones matching the expression
+// until there are no more, the context is cancelled, handle fails or enough of them match
+func peekMatching(ctx context.Context, entity peeker, options SearchOptions, handle func(msg *servicebus.Message) error) error {
+	defer entity.Close(context.Background())
+
+	matched := 0
+	searched := 0
+	iterator, err := entity.Peek(ctx, servicebus.PeekWithPageSize(searchPageSize))
+	observeOperation(OperationReceive, err)
+	if err != nil {
+		return err
+	}
+
+	for options.MaxResults <= 0 || matched < options.MaxResults {
+		msg, err := iterator.Next(ctx)
+		if _, noMessages := err.(servicebus.ErrNoMessages); noMessages {
+			break
+		}
+		if err != nil {
+			observeOperation(OperationReceive, err)
+			return err
+		}
+
+		searched++
+		if searched%searchProgressInterval == 0 {
+			logger.LogHighlight("Searched %v messages, %v matched", log.Info, fmt.Sprint(searched), fmt.Sprint(matched))
+		}
+		if options.Where.Match(msg) {
+			matched++
+			if err := handle(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	logger.LogHighlight("Searched %v messages, %v matched", log.Info, fmt.Sprint(searched), fmt.Sprint(matched))
+	return nil
+}
@@ -0,0 +1,71 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+)
+
+// MaxBatchSizeInBytes Maximum size of a batch of messages sent in a single operation
+const MaxBatchSizeInBytes = 262144
+
+// MessageSender Entity that messages can be sent to, both queues and topics implement it
+type MessageSender interface {
+	SendBatch(ctx context.Context, iterator servicebus.BatchIterator) error
+	Close(ctx context.Context) error
+}
+
+// GetQueueSender Gets a sender for a queue, the connection is kept open until the sender is closed
+func (s *ServiceBusCli) GetQueueSender(queueName string) (MessageSender, error) {
+	queue, err := s.GetQueue(queueName)
+	if queue == nil || err != nil {
+		logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, queueName, s.Namespace.Name)
+		return nil, errors.New("Could not find queue " + queueName + " in service bus " + s.Namespace.Name)
+	}
+
+	return s.checkedSender(queue, queueName, ""), nil
+}
+
+// GetTopicSender Gets a sender for a topic, the connection is kept open until the sender is closed
+func (s *ServiceBusCli) GetTopicSender(topicName string) (MessageSender, error) {
+	topic := s.GetTopic(topicName)
+	if topic == nil {
+		logger.LogHighlight("Could not find topic %v in service bus %v", log.Error, topicName, s.Namespace.Name)
+		return nil, errors.New("Could not find topic " + topicName + " in service bus " + s.Namespace.Name)
+	}
+
+	return s.checkedSender(topic, "", topicName), nil
+}
+
+// schemaSender is a queue or topic sender whose messages are validated against the schema of the entity before
+// SendMessageBatch sends them
+type schemaSender struct {
+	MessageSender
+	cli       *ServiceBusCli
+	queueName string
+	topicName string
+}
+
+// checkedSender wraps the sender of a queue or topic so every path sending through it validates the messages
+func (s *ServiceBusCli) checkedSender(sender MessageSender, queueName string, topicName string) MessageSender {
+	return &schemaSender{MessageSender: sender, cli: s, queueName: queueName, topicName: topicName}
+}
+
+// SendMessageBatch Sends messages to a sender splitting them in batches of the maximum allowed size, the messages
+// sent to a queue or topic sender are validated against its schema first and none is sent if one does not match
+func SendMessageBatch(ctx context.Context, sender MessageSender, messages ...*servicebus.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if checked, isChecked := sender.(*schemaSender); isChecked {
+		if err := checked.cli.checkSchemas(checked.queueName, checked.topicName, messages...); err != nil {
+			return err
+		}
+	}
+
+	err := sender.SendBatch(ctx, servicebus.NewMessageBatchIterator(MaxBatchSizeInBytes, messages...))
+	observeOperation(OperationSend, err)
+	return err
+}
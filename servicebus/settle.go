@@ -0,0 +1,263 @@
+package servicebus
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+)
+
+// SettleTimeout is how long completing, abandoning, deferring or dead lettering a single message can take
+const SettleTimeout = 30 * time.Second
+
+// Settle modes of the messages received by a subscribe command
+const (
+	// SettleComplete completes the messages once they were printed
+	SettleComplete = "complete"
+	// SettleAbandon abandons the messages so they can be received again, it increases their delivery count
+	SettleAbandon = "abandon"
+	// SettleDefer defers the messages, they can only be received again by their sequence number
+	SettleDefer = "defer"
+	// SettleDeadLetter moves the messages to the dead letter queue
+	SettleDeadLetter = "deadletter"
+	// SettleInteractive asks how every message is settled
+	SettleInteractive = "interactive"
+	// SettleSkip leaves an interactive message locked until the subscription stops and abandons it then
+	SettleSkip = "skip"
+)
+
+const (
+	// lockRenewCheckInterval is how often the locks of the held messages are checked
+	lockRenewCheckInterval = time.Second
+	// defaultLockRenewPeriod is the renewal period of the messages without a lock expiry
+	defaultLockRenewPeriod = 10 * time.Second
+	// subscribeDeadLetterReason is the reason of the messages dead lettered by a subscribe command
+	subscribeDeadLetterReason = "DeadLetteredBySubscriber"
+)
+
+// promptMutex keeps the messages of an interactive subscribe command printed and settled one at a time, even when
+// they come from several entities or concurrent handlers
+var promptMutex sync.Mutex
+
+// stdin reads the answers of the interactive settle mode
+var stdin = bufio.NewReader(os.Stdin)
+
+// ParseSettleMode Checks a settle mode, an empty mode completes the messages
+func ParseSettleMode(mode string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "":
+		return SettleComplete, nil
+	case SettleComplete, SettleAbandon, SettleDefer, SettleDeadLetter, SettleInteractive:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("invalid settle mode %v, use %v, %v, %v, %v or %v", mode, SettleComplete, SettleAbandon, SettleDefer, SettleDeadLetter, SettleInteractive)
+}
+
+// lockRenewable is a queue or subscription that can renew the locks of its messages
+type lockRenewable interface {
+	RenewLocks(ctx context.Context, messages ...*servicebus.Message) error
+}
+
+// heldLock is when the lock of a held message is renewed next
+type heldLock struct {
+	period  time.Duration
+	renewAt time.Time
+}
+
+// lockRenewer keeps the messages being inspected locked, renewing their locks halfway through so they are not
+// delivered again while they are held
+type lockRenewer struct {
+	entity lockRenewable
+	mutex  sync.Mutex
+	held   map[*servicebus.Message]heldLock
+}
+
+// newLockRenewer creates a lock renewer for the messages of a queue or subscription
+func newLockRenewer(entity lockRenewable) *lockRenewer {
+	return &lockRenewer{
+		entity: entity,
+		held:   make(map[*servicebus.Message]heldLock),
+	}
+}
+
+// hold renews the lock of a message until it is released, every half of its lock duration
+func (l *lockRenewer) hold(msg *servicebus.Message) {
+	if l == nil {
+		return
+	}
+
+	period := defaultLockRenewPeriod
+	if msg.SystemProperties != nil && msg.SystemProperties.LockedUntil != nil {
+		if remaining := time.Until(*msg.SystemProperties.LockedUntil); remaining > 2*lockRenewCheckInterval {
+			period = remaining / 2
+		}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.held[msg] = heldLock{period: period, renewAt: time.Now().Add(period)}
+}
+
+// release stops renewing the lock of a message, usually because it is about to be settled
+func (l *lockRenewer) release(msg *servicebus.Message) {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.held, msg)
+}
+
+// abandonHeld abandons the messages still held so they can be received again, it needs the receiver to be open
+func (l *lockRenewer) abandonHeld() {
+	if l == nil {
+		return
+	}
+
+	l.mutex.Lock()
+	messages := make([]*servicebus.Message, 0)
+	for msg := range l.held {
+		messages = append(messages, msg)
+	}
+	l.held = make(map[*servicebus.Message]heldLock)
+	l.mutex.Unlock()
+
+	if len(messages) == 0 {
+		return
+	}
+
+	settleCtx, cancel := context.WithTimeout(context.Background(), SettleTimeout)
+	defer cancel()
+	for _, msg := range messages {
+		if err := msg.Abandon(settleCtx); err != nil {
+			logger.Error("Could not abandon the message " + msg.ID + ", " + err.Error())
+		}
+	}
+	logger.LogHighlight("Abandoned %v held messages", log.Info, fmt.Sprint(len(messages)))
+}
+
+// run renews the locks that are due until the context is cancelled
+func (l *lockRenewer) run(ctx context.Context) {
+	ticker := time.NewTicker(lockRenewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.renewDue(ctx)
+		}
+	}
+}
+
+// renewDue renews the locks of the held messages that reached their renewal time in a single request
+func (l *lockRenewer) renewDue(ctx context.Context) {
+	now := time.Now()
+	due := make([]*servicebus.Message, 0)
+	l.mutex.Lock()
+	for msg, lock := range l.held {
+		if !now.Before(lock.renewAt) {
+			due = append(due, msg)
+			l.held[msg] = heldLock{period: lock.period, renewAt: now.Add(lock.period)}
+		}
+	}
+	l.mutex.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	renewCtx, cancel := context.WithTimeout(ctx, SettleTimeout)
+	defer cancel()
+	err := l.entity.RenewLocks(renewCtx, due...)
+	observeOperation(OperationReceive, err)
+	if err != nil {
+		logger.LogHighlight("Could not renew the locks of %v messages, %v", log.Error, fmt.Sprint(len(due)), err.Error())
+	}
+}
+
+// inspect prints a message received by a subscription and settles it. Received and deleted messages are already
+// settled and skipped messages are held locked until the subscription stops, so they are not delivered again while
+// it runs
+func (s *ServiceBusCli) inspect(ctx context.Context, msg *servicebus.Message, print func()) error {
+	if s.Settle == SettleInteractive {
+		promptMutex.Lock()
+		defer promptMutex.Unlock()
+	}
+
+	print()
+	if s.Receive.ReceiveAndDelete {
+		return nil
+	}
+
+	s.locks.hold(msg)
+	mode := s.Settle
+	if mode == SettleInteractive {
+		mode = promptSettleMode()
+	}
+	if mode == SettleSkip {
+		return nil
+	}
+
+	s.locks.release(msg)
+	return settleMessage(ctx, msg, mode)
+}
+
+// settleMessage settles a message in a settle mode, messages are completed by default
+func settleMessage(ctx context.Context, msg *servicebus.Message, mode string) error {
+	settleCtx, cancel := context.WithTimeout(ctx, SettleTimeout)
+	defer cancel()
+
+	var err error
+	switch mode {
+	case SettleAbandon:
+		err = msg.Abandon(settleCtx)
+	case SettleDefer:
+		_, err = applyMessageAction(ctx, msg, MessageOperation{Action: MessageActionDefer}, nil)
+		if err == nil {
+			logger.LogHighlight("Deferred message %v, it can be received again by its sequence number %v", log.Info, msg.ID, fmt.Sprint(sequenceNumber(msg)))
+		}
+	case SettleDeadLetter:
+		_, err = applyMessageAction(ctx, msg, MessageOperation{Action: MessageActionDeadLetter, Reason: subscribeDeadLetterReason}, nil)
+	default:
+		err = msg.Complete(settleCtx)
+	}
+	return err
+}
+
+// promptSettleMode asks how a message is settled until there is a valid answer, the message is skipped when there
+// is no answer or the input is closed
+func promptSettleMode() string {
+	for {
+		fmt.Print("[c]omplete, [a]bandon, [d]eadletter, de[f]er or [s]kip? ")
+		answer, err := stdin.ReadString('\n')
+		if err != nil && (err != io.EOF || answer == "") {
+			fmt.Println()
+			return SettleSkip
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "c", SettleComplete:
+			return SettleComplete
+		case "a", SettleAbandon:
+			return SettleAbandon
+		case "d", SettleDeadLetter:
+			return SettleDeadLetter
+		case "f", SettleDefer:
+			return SettleDefer
+		case "", "s", SettleSkip:
+			return SettleSkip
+		}
+	}
+}
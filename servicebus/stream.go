@@ -0,0 +1,103 @@
+package servicebus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+)
+
+// StreamQueueMessages Listens to a queue calling the handler for every message until the context is cancelled
+// the handler is responsible for settling the messages
+func (s *ServiceBusCli) StreamQueueMessages(ctx context.Context, queueName string, handler servicebus.HandlerFunc) error {
+	var commonError error
+	logger.LogHighlight("Streaming messages from queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
+	if queueName == "" {
+		commonError = errors.New("queue cannot be null")
+		logger.Error(commonError.Error())
+		return commonError
+	}
+
+	queue, err := s.GetQueue(queueName)
+	if queue == nil || err != nil {
+		commonError = errors.New("Could not find queue " + queueName + " in service bus " + s.Namespace.Name)
+		logger.LogHighlight("Could not find queue %v in service bus %v", log.Error, queueName, s.Namespace.Name)
+		return commonError
+	}
+
+	receiver, err := queue.NewReceiver(ctx)
+	if err != nil {
+		commonError = errors.New("Could not create channel for queue " + queueName + " in service bus " + s.Namespace.Name)
+		logger.LogHighlight("Could not create channel for queue %v in service bus %v", log.Error, queueName, s.Namespace.Name)
+		return commonError
+	}
+
+	err = waitForListener(ctx, receiver, handler)
+	logger.LogHighlight("Stopped streaming messages from queue %v in service bus %v", log.Info, queueName, s.Namespace.Name)
+	return err
+}
+
+// StreamSubscriptionMessages Listens to a topic subscription calling the handler for every message until the context is cancelled
+// the handler is responsible for settling the messages
+func (s *ServiceBusCli) StreamSubscriptionMessages(ctx context.Context, topicName string, subscriptionName string, handler servicebus.HandlerFunc) error {
+	var commonError error
+	logger.LogHighlight("Streaming messages from subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+	if topicName == "" {
+		commonError = errors.New("topic cannot be null")
+		logger.Error(commonError.Error())
+		return commonError
+	}
+
+	topic := s.GetTopic(topicName)
+	if topic == nil {
+		commonError = errors.New("Could not find topic " + topicName + " in service bus " + s.Namespace.Name)
+		logger.LogHighlight("Could not find topic %v in service bus %v", log.Error, topicName, s.Namespace.Name)
+		return commonError
+	}
+
+	_, err := topic.NewSubscriptionManager().Get(ctx, subscriptionName)
+	observeOperation(OperationManage, err)
+	if err != nil {
+		commonError = errors.New("Subscription " + subscriptionName + " was not found on topic " + topicName + " in service bus " + s.Namespace.Name)
+		logger.LogHighlight("Subscription %v was not found on %v in service bus %v", log.Error, subscriptionName, topicName, s.Namespace.Name)
+		return commonError
+	}
+
+	subscription, err := topic.NewSubscription(subscriptionName)
+	if err != nil {
+		return err
+	}
+
+	receiver, err := subscription.NewReceiver(ctx)
+	if err != nil {
+		commonError = errors.New("Could not create channel for subscription " + subscriptionName + " on topic " + topicName + " in service bus " + s.Namespace.Name)
+		logger.LogHighlight("Could not create channel for subscription %v on topic %v in service bus %v", log.Error, subscriptionName, topicName, s.Namespace.Name)
+		return commonError
+	}
+
+	err = waitForListener(ctx, receiver, handler)
+	logger.LogHighlight("Stopped streaming messages from subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+	return err
+}
+
+// waitForListener starts listening on the receiver and blocks until either the context is cancelled
+// or the listener stops by itself, the receiver is always closed before returning
+func waitForListener(ctx context.Context, receiver *servicebus.Receiver, handler servicebus.HandlerFunc) error {
+	listenerHandle := receiver.Listen(ctx, handler)
+	defer trackListener()()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case <-listenerHandle.Done():
+		err = listenerHandle.Err()
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
+	defer cancel()
+	_ = listenerHandle.Close(closeCtx)
+
+	return err
+}
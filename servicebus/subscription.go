@@ -2,11 +2,8 @@ package servicebus
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	servicebus "github.com/Azure/azure-service-bus-go"
@@ -27,7 +24,9 @@ func (s *ServiceBusCli) GetSubscription(topicName string, subscriptionName strin
 	}
 
 	sm := topic.NewSubscriptionManager()
-	return sm.Get(ctx, subscriptionName)
+	subscription, err := sm.Get(ctx, subscriptionName)
+	observeOperation(OperationManage, err)
+	return subscription, err
 }
 
 // ListSubscriptions Lists all the topics in a service bus
@@ -43,7 +42,9 @@ func (s *ServiceBusCli) ListSubscriptions(topicName string) ([]*servicebus.Subsc
 	}
 
 	sm := topic.NewSubscriptionManager()
-	return sm.List(ctx)
+	subscriptions, err := sm.List(ctx)
+	observeOperation(OperationManage, err)
+	return subscriptions, err
 }
 
 // CreateSubscription Creates a subscription to a topic in the service bus
@@ -126,6 +127,7 @@ func (s *ServiceBusCli) CreateSubscription(subscription entities.SubscriptionReq
 	}
 
 	_, err := sm.Put(ctx, subscription.Name, opts...)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error("There was an error creating subscription")
 		logger.Error(err.Error())
@@ -153,7 +155,9 @@ func (s *ServiceBusCli) GetSubscriptionRules(topicName string, subscriptionName
 	topic := s.GetTopic(topicName)
 	sm := topic.NewSubscriptionManager()
 
-	return sm.ListRules(ctx, subscriptionName)
+	rules, err := sm.ListRules(ctx, subscriptionName)
+	observeOperation(OperationManage, err)
+	return rules, err
 }
 
 func (s *ServiceBusCli) GetSubscriptionRule(topicName string, subscriptionName string, ruleName string) (*servicebus.RuleEntity, error) {
@@ -187,6 +191,7 @@ func (s *ServiceBusCli) DeleteSubscriptionRule(topicName string, subscriptionNam
 	}
 
 	err = sm.DeleteRule(ctx, subscriptionName, ruleName)
+	observeOperation(OperationManage, err)
 
 	if err != nil {
 		return nil, err
@@ -210,12 +215,14 @@ func (s *ServiceBusCli) CreateSubscriptionRule(subscription entities.Subscriptio
 		if rule.SQLAction != "" {
 			sqlAction.Expression = rule.SQLAction
 			_, err := sm.PutRuleWithAction(ctx, subscription.Name, rule.Name, sqlFilter, sqlAction)
+			observeOperation(OperationManage, err)
 			if err != nil {
 				logger.LogHighlight("Could not create subscription rule %v in subscription %v on topic %v in service bus %v", log.Error, rule.Name, subscription.Name, subscription.TopicName, s.Namespace.Name)
 				return err
 			}
 		} else {
 			_, err := sm.PutRule(ctx, subscription.Name, rule.Name, sqlFilter)
+			observeOperation(OperationManage, err)
 			if err != nil {
 				logger.LogHighlight("Could not create subscription rule %v in subscription %v on topic %v in service bus %v", log.Error, rule.Name, subscription.Name, subscription.TopicName, s.Namespace.Name)
 				return err
@@ -254,6 +261,7 @@ func (s *ServiceBusCli) DeleteSubscription(topicName string, subscriptionName st
 	}
 	sm := topic.NewSubscriptionManager()
 	err := sm.Delete(ctx, subscriptionName)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
@@ -266,18 +274,24 @@ func (s *ServiceBusCli) DeleteSubscription(topicName string, subscriptionName st
 func (s *ServiceBusCli) SubscribeToTopic(topicName string, subscriptionName string) error {
 	var commonError error
 
+	printMessage := func(msg *servicebus.Message) {
+		s.Renderer.Print(s.transformForPrint(msg), func() {
+			logger.LogHighlight("%v Received message %v from topic %v on subscription %v with label %v", log.Info, msg.SystemProperties.EnqueuedTime.String(), msg.ID, topicName, subscriptionName, msg.Label)
+		})
+		warnSchema(msg, s.CheckTopicMessageSchema(topicName, msg))
+	}
+
 	var concurrentHandler servicebus.HandlerFunc = func(ctx context.Context, msg *servicebus.Message) error {
-		logger.LogHighlight("%v Received message %v from topic %v on subscription %v with label %v", log.Info, msg.SystemProperties.EnqueuedTime.String(), msg.ID, topicName, subscriptionName, msg.Label)
-		logger.Info("User Properties:")
-		jsonString, _ := json.MarshalIndent(msg.UserProperties, "", "  ")
-		fmt.Println(string(jsonString))
-		logger.Info("Message Body:")
-		fmt.Println(string(msg.Data))
-
-		if !s.Peek {
-			return msg.Complete(ctx)
+		if !s.Limits.take() {
+			// received and deleted messages are already gone, there is no lock to give back
+			if s.Receive.ReceiveAndDelete {
+				return nil
+			}
+			return msg.Abandon(ctx)
 		}
-		return nil
+		defer s.Limits.done()
+
+		return s.inspect(ctx, msg, func() { printMessage(msg) })
 	}
 
 	logger.LogHighlight("Subscribing to %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
@@ -301,6 +315,7 @@ func (s *ServiceBusCli) SubscribeToTopic(topicName string, subscriptionName stri
 	foundSubscription := false
 	sm := topic.NewSubscriptionManager()
 	subscriptions, subscriptionsErr := sm.List(ctx)
+	observeOperation(OperationManage, subscriptionsErr)
 	if subscriptionsErr != nil {
 		logger.LogHighlight("There was an error getting the list of subscriptions on %v in service bus %v", log.Warning, topicName, s.Namespace.Name)
 	}
@@ -339,8 +354,18 @@ func (s *ServiceBusCli) SubscribeToTopic(topicName string, subscriptionName stri
 		return commonError
 	}
 
+	// peeking browses the messages instead of receiving them, so they are never locked
+	if s.Peek {
+		logger.LogHighlight("Starting to browse messages in %v on topic %v for service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
+		if err := s.browseUntilClosed(ctx, subscription, s.CloseTopicListener, printMessage); err != nil {
+			logger.Error(err.Error())
+			return err
+		}
+		return s.CloseTopicSubscription()
+	}
+
 	logger.LogHighlight("Starting to receive messages in %v on topic %v for service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
-	receiver, err := subscription.NewReceiver(ctx)
+	receiver, err := subscription.NewReceiver(ctx, s.Receive.receiverOptions()...)
 
 	if err != nil {
 		commonError := errors.New("Could not create channel for subscription " + subscriptionName + " on " + topicName + " in " + s.Namespace.Name + " bus, subscription was not found")
@@ -348,8 +373,12 @@ func (s *ServiceBusCli) SubscribeToTopic(topicName string, subscriptionName stri
 		return commonError
 	}
 
-	listenerHandler := receiver.Listen(ctx, concurrentHandler)
+	s.locks = newLockRenewer(subscription)
+	go s.locks.run(ctx)
+
+	listenerHandler := receiver.Listen(ctx, s.Receive.concurrent(concurrentHandler))
 	s.ActiveTopicListenerHandle = listenerHandler
+	defer trackListener()()
 	defer listenerHandler.Close(ctx)
 
 	if <-s.CloseTopicListener {
@@ -363,7 +392,11 @@ func (s *ServiceBusCli) CloseTopicSubscription() error {
 	logger.LogHighlight("Closing the subscription for %v on topic %v in service bus %v", log.Info, s.ActiveSubscription.Name, s.ActiveTopic.Name, s.Namespace.Name)
 	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Second)
 	defer cancel()
-	s.ActiveTopicListenerHandle.Close(ctx)
+	// held messages are abandoned through the receiver, so before it is closed
+	s.locks.abandonHeld()
+	if s.ActiveTopicListenerHandle != nil {
+		s.ActiveTopicListenerHandle.Close(ctx)
+	}
 	if s.DeleteWiretap && s.ActiveSubscription.Name == "wiretap" {
 		s.DeleteSubscription(s.ActiveTopic.Name, "wiretap")
 	}
@@ -374,14 +407,15 @@ func (s *ServiceBusCli) CloseTopicSubscription() error {
 	return nil
 }
 
-// GetSubscriptionActiveMessages Gets messages from a subscription
-func (s *ServiceBusCli) GetSubscriptionActiveMessages(topicName string, subscriptionName string, qty int, peek bool) ([]servicebus.Message, error) {
+// GetSubscriptionActiveMessages Gets messages from a subscription, received messages are prefetched and completed in
+// a batch
+func (s *ServiceBusCli) GetSubscriptionActiveMessages(topicName string, subscriptionName string, qty int, peek bool, options ReceiveOptions) ([]servicebus.Message, error) {
 	var commonError error
 	messages := make([]servicebus.Message, 0)
 
-	// We will have a maximum of fetch of 100 messages per query
-	if qty > 100 {
-		qty = 100
+	// Peeks have a maximum of 100 messages per query, received batches are only limited by the count of the entity
+	if peek && qty > maxPeekQuantity {
+		qty = maxPeekQuantity
 	}
 
 	logger.LogHighlight("Getting message for subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
@@ -403,6 +437,7 @@ func (s *ServiceBusCli) GetSubscriptionActiveMessages(topicName string, subscrip
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 	subscription, commonError := sm.Get(ctx, subscriptionName)
+	observeOperation(OperationManage, commonError)
 	if commonError != nil {
 		return messages, commonError
 	}
@@ -416,9 +451,9 @@ func (s *ServiceBusCli) GetSubscriptionActiveMessages(topicName string, subscrip
 	// If we set the message count to 0 then we will read a batch of the messages that exists
 	if qty == 0 {
 		qty = messageCount
-		// we will need to check again that the message count is not bigger than 100 and will set the limit again if so
-		if qty > 100 {
-			qty = 100
+		// we will need to check again that a peek is not bigger than 100 and will set the limit again if so
+		if peek && qty > maxPeekQuantity {
+			qty = maxPeekQuantity
 		}
 	}
 
@@ -427,21 +462,6 @@ func (s *ServiceBusCli) GetSubscriptionActiveMessages(topicName string, subscrip
 		qty = messageCount
 	}
 
-	var waitForMessages sync.WaitGroup
-	waitForMessages.Add(qty)
-
-	// Creating a message handler function to deal with our messages
-	var messageHandler servicebus.HandlerFunc = func(c context.Context, m *servicebus.Message) error {
-		mCtx, mCancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
-		defer mCancel()
-		m.Complete(mCtx)
-
-		messages = append(messages, *m)
-		// notify the wait group that the message is dealt with
-		waitForMessages.Done()
-		return nil
-	}
-
 	// Creating the receiver for the messages in the subscription
 	messageReceiver, commonError := topic.NewSubscription(subscriptionName)
 
@@ -452,46 +472,36 @@ func (s *ServiceBusCli) GetSubscriptionActiveMessages(topicName string, subscrip
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	// Background task to receive all of the messages we need
-	go func() {
-		if peek {
-			t, err := messageReceiver.Peek(ctx)
-			if err != nil {
-				fmt.Println(err.Error())
-			}
-			for i := 0; i < qty; i++ {
-				m, err := t.Next(ctx)
-				if err != nil {
-					fmt.Println(err.Error())
-				}
-				messages = append(messages, *m)
-				waitForMessages.Done()
-			}
-		} else {
-			for i := 0; i < qty; i++ {
-				if err := messageReceiver.ReceiveOne(ctx, messageHandler); err != nil {
-					fmt.Println(err.Error())
-				}
-			}
+	// received messages come from a single receiver asking for the whole batch at once
+	if !peek {
+		receiver, err := messageReceiver.NewReceiver(ctx, options.batchReceiverOptions(qty)...)
+		if err != nil {
+			return nil, err
 		}
-	}()
+		defer topic.Close(context.Background())
+		defer messageReceiver.Close(context.Background())
+		defer receiver.Close(context.Background())
+		return receiveBatch(ctx, receiver, qty, options)
+	}
 
-	waitForMessages.Wait()
+	messages, commonError = peekBatch(ctx, messageReceiver, qty)
 
 	// We are finished and we should now close the receiver before leaving
 	_ = topic.Close(ctx)
 	_ = messageReceiver.Close(ctx)
 
-	return messages, nil
+	return messages, commonError
 }
 
-func (s *ServiceBusCli) GetSubscriptionDeadLetterMessages(topicName string, subscriptionName string, qty int, peek bool) ([]servicebus.Message, error) {
+// GetSubscriptionDeadLetterMessages Gets the dead letters of a subscription, received messages are prefetched and
+// completed in a batch while peeked messages are locked and abandoned
+func (s *ServiceBusCli) GetSubscriptionDeadLetterMessages(topicName string, subscriptionName string, qty int, peek bool, options ReceiveOptions) ([]servicebus.Message, error) {
 	var commonError error
 	messages := make([]servicebus.Message, 0)
 
-	// We will have a maximum of fetch of 100 messages per query
-	if qty > 100 {
-		qty = 100
+	// Peeks have a maximum of 100 messages per query, received batches are only limited by the count of the entity
+	if peek && qty > maxPeekQuantity {
+		qty = maxPeekQuantity
 	}
 
 	logger.LogHighlight("Getting dead letter messages for subscription %v on topic %v in service bus %v", log.Info, subscriptionName, topicName, s.Namespace.Name)
@@ -513,6 +523,7 @@ func (s *ServiceBusCli) GetSubscriptionDeadLetterMessages(topicName string, subs
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 	subscription, commonError := sm.Get(ctx, subscriptionName)
+	observeOperation(OperationManage, commonError)
 	if commonError != nil {
 		logger.LogHighlight("Could not find subscription %v on topic %v in service bus %v", log.Error, subscriptionName, topicName, s.Namespace.Name)
 		return messages, commonError
@@ -527,9 +538,9 @@ func (s *ServiceBusCli) GetSubscriptionDeadLetterMessages(topicName string, subs
 	// If we set the message count to 0 then we will read a batch of the messages that exists
 	if qty == 0 {
 		qty = messageCount
-		// we will need to check again that the message count is not bigger than 100 and will set the limit again if so
-		if qty > 100 {
-			qty = 100
+		// we will need to check again that a peek is not bigger than 100 and will set the limit again if so
+		if peek && qty > maxPeekQuantity {
+			qty = maxPeekQuantity
 		}
 	}
 
@@ -538,26 +549,6 @@ func (s *ServiceBusCli) GetSubscriptionDeadLetterMessages(topicName string, subs
 		qty = messageCount
 	}
 
-	var waitForMessages sync.WaitGroup
-	waitForMessages.Add(qty)
-
-	// Creating a message handler function to deal with our messages
-	var messageHandler servicebus.HandlerFunc = func(c context.Context, m *servicebus.Message) error {
-		mCtx, mCancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
-		defer mCancel()
-
-		if peek {
-			m.Abandon(ctx)
-		} else {
-			m.Complete(mCtx)
-		}
-
-		messages = append(messages, *m)
-		// notify the wait group that the message is dealt with
-		waitForMessages.Done()
-		return nil
-	}
-
 	// Creating the receiver for the messages in the subscription
 	messageReceiver, commonError := topic.NewSubscription(subscriptionName)
 
@@ -568,33 +559,31 @@ func (s *ServiceBusCli) GetSubscriptionDeadLetterMessages(topicName string, subs
 	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Minute)
 	defer cancel()
 
-	var deadLetterReceiver servicebus.ReceiveOner
-	if peek {
-		deadLetterReceiver, commonError = messageReceiver.NewDeadLetterReceiver(ctx, servicebus.ReceiverWithReceiveMode(servicebus.PeekLockMode))
-
-	} else {
-		deadLetterReceiver, commonError = messageReceiver.NewDeadLetterReceiver(ctx)
+	// received messages come from a single receiver asking for the whole batch at once
+	if !peek {
+		receiver, err := messageReceiver.NewDeadLetterReceiver(ctx, options.batchReceiverOptions(qty)...)
+		if err != nil {
+			return nil, err
+		}
+		defer topic.Close(context.Background())
+		defer messageReceiver.Close(context.Background())
+		defer receiver.Close(context.Background())
+		return receiveBatch(ctx, receiver, qty, options)
 	}
 
+	deadLetterReceiver, commonError := messageReceiver.NewDeadLetterReceiver(ctx, servicebus.ReceiverWithReceiveMode(servicebus.PeekLockMode))
+
 	if commonError != nil {
 		return nil, commonError
 	}
 
-	// Background task to receive all of the messages we need
-	go func() {
-		for i := 0; i < qty; i++ {
-			if err := deadLetterReceiver.ReceiveOne(ctx, messageHandler); err != nil {
-				fmt.Println(err.Error())
-			}
-		}
-	}()
-
-	waitForMessages.Wait()
+	// peeked dead letters are locked and abandoned
+	messages, commonError = receiveAndAbandon(ctx, deadLetterReceiver, qty)
 
 	// We are finished and we should now close the receiver before leaving
 	_ = topic.Close(ctx)
 	_ = messageReceiver.Close(ctx)
 	_ = deadLetterReceiver.Close(ctx)
 
-	return messages, nil
+	return messages, commonError
 }
@@ -3,7 +3,6 @@ package servicebus
 import (
 	"context"
 	"errors"
-	"os"
 	"sync"
 	"time"
 
@@ -40,6 +39,7 @@ func (s *ServiceBusCli) GetTopic(name string) *servicebus.Topic {
 	}
 
 	te, err := s.TopicManager.Get(ctx, name)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return nil
@@ -64,6 +64,7 @@ func (s *ServiceBusCli) GetTopicDetails(name string) *servicebus.TopicEntity {
 	}
 
 	te, err := s.TopicManager.Get(ctx, name)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return nil
@@ -85,7 +86,9 @@ func (s *ServiceBusCli) ListTopics() ([]*servicebus.TopicEntity, error) {
 		}
 	}
 
-	return s.TopicManager.List(ctx)
+	topics, err := s.TopicManager.List(ctx)
+	observeOperation(OperationManage, err)
+	return topics, err
 }
 
 // SendTopicMessage sends a message to a specific topic
@@ -115,7 +118,12 @@ func (s *ServiceBusCli) SendTopicMessage(topicName string, message entities.Mess
 		return err
 	}
 
+	if err := s.checkSchemas("", topicName, sbMessage); err != nil {
+		return err
+	}
+
 	err = topic.Send(ctx, sbMessage)
+	observeOperation(OperationSend, err)
 
 	if err != nil {
 		logger.Error(err.Error())
@@ -163,7 +171,12 @@ func (s *ServiceBusCli) SendBulkTopicMessage(topicName string, messages ...entit
 		sbMessages = append(sbMessages, sbMessage)
 	}
 
+	if err := s.checkSchemas("", topicName, sbMessages...); err != nil {
+		return err
+	}
+
 	err := topic.SendBatch(ctx, servicebus.NewMessageBatchIterator(262144, sbMessages...))
+	observeOperation(OperationSend, err)
 
 	if err != nil {
 		logger.Error(err.Error())
@@ -194,11 +207,16 @@ func (s *ServiceBusCli) SendTopicServiceBusMessage(topicName string, sbMessage *
 		return commonError
 	}
 
+	if err := s.checkSchemas("", topicName, sbMessage); err != nil {
+		return err
+	}
+
 	err := topic.Send(ctx, sbMessage)
+	observeOperation(OperationSend, err)
 
 	if err != nil {
 		logger.Error(err.Error())
-		os.Exit(1)
+		return err
 	}
 
 	logger.LogHighlight("Service bus topic message was sent successfully to %v topic in service bus %v", log.Info, topicName, s.Namespace.Name)
@@ -221,6 +239,7 @@ func (s *ServiceBusCli) CreateTopic(topicName string, opts ...servicebus.TopicMa
 	logger.LogHighlight("Creating topic %v in service bus %v", log.Info, topicName, s.Namespace.Name)
 	tm := s.GetTopicManager()
 	topic, err := tm.Put(ctx, topicName, opts...)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return nil, err
@@ -244,6 +263,7 @@ func (s *ServiceBusCli) DeleteTopic(topicName string) error {
 	tm := s.GetTopicManager()
 
 	err := tm.Delete(ctx, topicName)
+	observeOperation(OperationManage, err)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
@@ -0,0 +1,17 @@
+package servicebus
+
+import (
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+)
+
+// transformForPrint applies the transform pipeline of the cli to a received message, when the pipeline fails the
+// error is logged and the message is printed as it was received
+func (s *ServiceBusCli) transformForPrint(msg *servicebus.Message) *servicebus.Message {
+	transformed, err := s.Transform.Apply(msg)
+	if err != nil {
+		logger.LogHighlight("Could not transform message %v, %v", log.Warning, msg.ID, err.Error())
+		return msg
+	}
+	return transformed
+}
@@ -0,0 +1,467 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"github.com/cjlapao/servicebuscli-go/transform"
+)
+
+// defaultPeekCount is the number of messages peeked if no count is given
+const defaultPeekCount = 10
+
+// command is a shell command, complete returns the values the next argument can have
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, s *Shell, args []string) error
+	complete    func(s *Shell, args []string) []string
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "help", usage: "help [command]", description: "Shows the commands or how to use one of them", run: runHelp, complete: completeCommands},
+		{name: "use", usage: "use <queue|topic|subscription> <name> | use .. | use /", description: "Changes the entity the commands run against, .. goes up one level and / back to the namespace", run: runUse, complete: completeUse},
+		{name: "ls", usage: "ls", description: "Lists the queues and topics of the namespace, the subscriptions of the topic in use or the counts of the entity in use", run: runList},
+		{name: "info", usage: "info", description: "Shows the details of the entity in use", run: runInfo},
+		{name: "peek", usage: "peek [count] [dead-letter]", description: "Peeks the messages of the queue or subscription in use without removing them, defaults to 10 messages", run: runPeek, complete: completeWords("dead-letter")},
+		{name: "send", usage: "send <@file.json|json> [label]", description: "Sends a message to the queue or topic in use, the body is json or a message object read from a file", run: runSend},
+		{name: "rules", usage: "rules", description: "Lists the rules of the subscription in use", run: runRules},
+		{name: "purge", usage: "purge [dead-letter]", description: "Removes all the messages of the queue or subscription in use", run: runPurge, complete: completeWords("dead-letter")},
+		{name: "resubmit", usage: "resubmit [max] [--transform=file.yaml]", description: "Sends the dead letters of the queue or subscription in use back to it, transformed by the pipeline of the yaml file", run: runResubmit},
+		{name: "refresh", usage: "refresh", description: "Reloads the entity names used by the tab completion", run: runRefresh},
+		{name: "exit", usage: "exit", description: "Leaves the shell, ctrl+d also leaves it"},
+	}
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name && commands[i].run != nil {
+			return &commands[i]
+		}
+	}
+
+	return nil
+}
+
+func runHelp(ctx context.Context, s *Shell, args []string) error {
+	for _, cmd := range commands {
+		if len(args) > 0 && cmd.name != strings.ToLower(args[0]) {
+			continue
+		}
+		logger.LogHighlight("  %v  %v", log.Info, fmt.Sprintf("%-55v", cmd.usage), cmd.description)
+	}
+
+	return nil
+}
+
+func runUse(ctx context.Context, s *Shell, args []string) error {
+	if len(args) == 1 {
+		switch args[0] {
+		case "/":
+			s.queue, s.topic, s.subscription = "", "", ""
+			return nil
+		case "..":
+			if s.subscription != "" {
+				s.subscription = ""
+			} else {
+				s.queue, s.topic = "", ""
+			}
+			return nil
+		}
+	}
+	if len(args) != 2 {
+		return errors.New("usage: use <queue|topic|subscription> <name>")
+	}
+
+	name := args[1]
+	switch strings.ToLower(args[0]) {
+	case "queue":
+		if _, err := s.cli.GetQueueDetails(name); err != nil {
+			return fmt.Errorf("could not find queue %v, %v", name, err.Error())
+		}
+		s.queue, s.topic, s.subscription = name, "", ""
+	case "topic":
+		if s.cli.GetTopicDetails(name) == nil {
+			return fmt.Errorf("could not find topic %v", name)
+		}
+		s.queue, s.topic, s.subscription = "", name, ""
+	case "subscription":
+		topic := s.topic
+		if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+			topic, name = parts[0], parts[1]
+		}
+		if topic == "" {
+			return errors.New("use a topic first or name the subscription as topic/subscription")
+		}
+		if _, err := s.cli.GetSubscription(topic, name); err != nil {
+			return fmt.Errorf("could not find subscription %v on topic %v, %v", name, topic, err.Error())
+		}
+		s.queue, s.topic, s.subscription = "", topic, name
+	default:
+		return fmt.Errorf("can not use %v, it can be a queue, topic or subscription", args[0])
+	}
+
+	return nil
+}
+
+func runList(ctx context.Context, s *Shell, args []string) error {
+	switch {
+	case s.queue != "":
+		queue, err := s.cli.GetQueueDetails(s.queue)
+		if err != nil {
+			return err
+		}
+		logger.LogHighlight("Queue: %v (%v)", log.Info, queue.Name, counts(queue.CountDetails))
+	case s.subscription != "":
+		subscription, err := s.cli.GetSubscription(s.topic, s.subscription)
+		if err != nil {
+			return err
+		}
+		logger.LogHighlight("Subscription: %v (%v)", log.Info, subscription.Name, counts(subscription.CountDetails))
+	case s.topic != "":
+		subscriptions, err := s.cli.ListSubscriptions(s.topic)
+		if err != nil {
+			return err
+		}
+		if len(subscriptions) == 0 {
+			logger.LogHighlight("No subscriptions found in topic %v", log.Info, s.topic)
+		}
+		subscriptionNames := make([]string, 0)
+		for _, subscription := range subscriptions {
+			subscriptionNames = append(subscriptionNames, subscription.Name)
+			logger.LogHighlight("Subscription: %v (%v)", log.Info, subscription.Name, counts(subscription.CountDetails))
+		}
+		s.getNames().setSubscriptions(s.topic, subscriptionNames)
+	default:
+		queues, err := s.cli.ListQueues()
+		if err != nil {
+			return err
+		}
+		topics, err := s.cli.ListTopics()
+		if err != nil {
+			return err
+		}
+		if len(queues) == 0 && len(topics) == 0 {
+			logger.LogHighlight("No queues or topics found in service bus %v", log.Info, s.cli.Namespace.Name)
+		}
+
+		queueNames := make([]string, 0)
+		for _, queue := range queues {
+			queueNames = append(queueNames, queue.Name)
+			logger.LogHighlight("Queue: %v (%v)", log.Info, queue.Name, counts(queue.CountDetails))
+		}
+		topicNames := make([]string, 0)
+		for _, topic := range topics {
+			topicNames = append(topicNames, topic.Name)
+			logger.LogHighlight("Topic: %v (%v)", log.Info, topic.Name, counts(topic.CountDetails))
+		}
+		s.getNames().setNamespace(queueNames, topicNames)
+	}
+
+	return nil
+}
+
+func runInfo(ctx context.Context, s *Shell, args []string) error {
+	var response interface{}
+	switch {
+	case s.queue != "":
+		queue, err := s.cli.GetQueueDetails(s.queue)
+		if err != nil {
+			return err
+		}
+		queueResponse := entities.QueueResponse{}
+		queueResponse.FromServiceBus(queue)
+		response = queueResponse
+	case s.subscription != "":
+		subscription, err := s.cli.GetSubscription(s.topic, s.subscription)
+		if err != nil {
+			return err
+		}
+		subscriptionResponse := entities.SubscriptionResponse{}
+		subscriptionResponse.FromServiceBus(subscription)
+		response = subscriptionResponse
+	case s.topic != "":
+		topic := s.cli.GetTopicDetails(s.topic)
+		if topic == nil {
+			return fmt.Errorf("could not find topic %v", s.topic)
+		}
+		topicResponse := entities.TopicResponseEntity{}
+		topicResponse.FromServiceBus(topic)
+		response = topicResponse
+	default:
+		return errors.New("there is no entity in use, use a queue, topic or subscription first")
+	}
+
+	content, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(content))
+
+	return nil
+}
+
+func runPeek(ctx context.Context, s *Shell, args []string) error {
+	count := defaultPeekCount
+	deadLetter := false
+	for _, arg := range args {
+		if arg == "dead-letter" {
+			deadLetter = true
+			continue
+		}
+		value, err := strconv.Atoi(arg)
+		if err != nil || value <= 0 {
+			return fmt.Errorf("invalid count %v, it needs to be a number bigger than 0", arg)
+		}
+		count = value
+	}
+
+	var messages []servicebus.Message
+	var err error
+	switch {
+	case s.queue != "" && deadLetter:
+		messages, err = s.cli.GetQueueDeadLetterMessages(s.queue, count, true, sbcli.ReceiveOptions{})
+	case s.queue != "":
+		messages, err = s.cli.GetQueueActiveMessages(s.queue, count, true, sbcli.ReceiveOptions{})
+	case s.subscription != "" && deadLetter:
+		messages, err = s.cli.GetSubscriptionDeadLetterMessages(s.topic, s.subscription, count, true, sbcli.ReceiveOptions{})
+	case s.subscription != "":
+		messages, err = s.cli.GetSubscriptionActiveMessages(s.topic, s.subscription, count, true, sbcli.ReceiveOptions{})
+	default:
+		return errors.New("only queues and subscriptions have messages to peek, use one first")
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		logger.LogHighlight("There are no messages in %v", log.Info, s.describe())
+	}
+	for i := range messages {
+		printMessage(&messages[i])
+		if s.queue != "" {
+			err = s.cli.CheckQueueMessageSchema(s.queue, &messages[i])
+		} else {
+			err = s.cli.CheckTopicMessageSchema(s.topic, &messages[i])
+		}
+		if err != nil {
+			logger.LogHighlight("Message %v does not match its schema, %v", log.Warning, messages[i].ID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func runSend(ctx context.Context, s *Shell, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("usage: send <@file.json|json> [label]")
+	}
+
+	message := entities.MessageRequest{}
+	if strings.HasPrefix(args[0], "@") {
+		if err := message.FromFile(strings.TrimPrefix(args[0], "@")); err != nil {
+			return err
+		}
+	} else {
+		message.Label = "ServiceBus.Tools"
+		if err := json.Unmarshal([]byte(args[0]), &message.Data); err != nil {
+			return fmt.Errorf("the message body is not a json object, %v", err.Error())
+		}
+	}
+	if len(args) == 2 {
+		message.Label = args[1]
+	}
+
+	switch {
+	case s.queue != "":
+		return s.cli.SendQueueMessage(s.queue, message)
+	case s.topic != "" && s.subscription == "":
+		return s.cli.SendTopicMessage(s.topic, message)
+	default:
+		return errors.New("messages can only be sent to a queue or a topic, use one first")
+	}
+}
+
+func runRules(ctx context.Context, s *Shell, args []string) error {
+	if s.subscription == "" {
+		return errors.New("only subscriptions have rules, use one first")
+	}
+
+	rules, err := s.cli.GetSubscriptionRules(s.topic, s.subscription)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		logger.LogHighlight("There are no rules in %v", log.Info, s.describe())
+	}
+	for _, rule := range rules {
+		response := entities.RuleResponse{}
+		response.FromServiceBus(rule)
+		content, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return err
+		}
+		logger.LogHighlight("Rule: %v", log.Info, rule.Name)
+		fmt.Println(string(content))
+	}
+
+	return nil
+}
+
+func runPurge(ctx context.Context, s *Shell, args []string) error {
+	deadLetter := len(args) > 0 && args[0] == "dead-letter"
+	if s.queue == "" && s.subscription == "" {
+		return errors.New("only queues and subscriptions can be purged, use one first")
+	}
+
+	what := "messages"
+	if deadLetter {
+		what = "dead letters"
+	}
+	if !s.confirm("Remove all the " + what + " of " + s.describe() + "?") {
+		return nil
+	}
+
+	var removed int64
+	progress := func(succeeded int, failed int) {
+		atomic.AddInt64(&removed, int64(succeeded))
+	}
+
+	var err error
+	if s.queue != "" {
+		err = s.cli.PurgeQueue(ctx, s.queue, deadLetter, progress)
+	} else {
+		err = s.cli.PurgeSubscription(ctx, s.topic, s.subscription, deadLetter, progress)
+	}
+	logger.LogHighlight("Removed %v %v from %v", log.Info, strconv.FormatInt(atomic.LoadInt64(&removed), 10), what, s.describe())
+
+	return err
+}
+
+func runResubmit(ctx context.Context, s *Shell, args []string) error {
+	options := sbcli.ResubmitOptions{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--transform=") {
+			pipeline, err := transform.Load(strings.TrimPrefix(arg, "--transform="))
+			if err != nil {
+				return errors.New("could not load the transform pipeline, " + err.Error())
+			}
+			options.Transform = pipeline
+			continue
+		}
+
+		value, err := strconv.Atoi(arg)
+		if err != nil || value <= 0 {
+			return fmt.Errorf("invalid max %v, it needs to be a number bigger than 0", arg)
+		}
+		options.Max = value
+	}
+	if s.queue == "" && s.subscription == "" {
+		return errors.New("only queues and subscriptions have dead letters, use one first")
+	}
+	question := "Resubmit the dead letters of " + s.describe() + "?"
+	if s.queue == "" {
+		question = "Resubmit the dead letters of " + s.describe() + " to topic " + s.topic + "? Every subscription with matching rules receives them"
+	}
+	if !s.confirm(question) {
+		return nil
+	}
+
+	var resubmitted, failed int64
+	progress := func(succeeded int, failures int) {
+		atomic.AddInt64(&resubmitted, int64(succeeded))
+		atomic.AddInt64(&failed, int64(failures))
+	}
+
+	var err error
+	if s.queue != "" {
+		err = s.cli.ResubmitQueueDeadLetters(ctx, s.queue, options, progress)
+	} else {
+		// confirming the command allows the fan out
+		options.FanOut = true
+		err = s.cli.ResubmitSubscriptionDeadLetters(ctx, s.topic, s.subscription, options, progress)
+	}
+	logger.LogHighlight("Resubmitted %v dead letters of %v, %v failed", log.Info, strconv.FormatInt(atomic.LoadInt64(&resubmitted), 10), s.describe(), strconv.FormatInt(atomic.LoadInt64(&failed), 10))
+
+	return err
+}
+
+func runRefresh(ctx context.Context, s *Shell, args []string) error {
+	s.names = nil
+	s.getNames()
+
+	return nil
+}
+
+// confirm asks for a yes in the terminal, commands read from a script do not ask
+func (s *Shell) confirm(question string) bool {
+	if s.terminal == nil {
+		return true
+	}
+
+	answer, err := s.readLine(int(os.Stdin.Fd()), question+" (y/n) ")
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// describe gets the description of the entity in use
+func (s *Shell) describe() string {
+	switch {
+	case s.queue != "":
+		return "queue " + s.queue
+	case s.subscription != "":
+		return "subscription " + s.subscription + " on topic " + s.topic
+	case s.topic != "":
+		return "topic " + s.topic
+	default:
+		return "service bus " + s.cli.Namespace.Name
+	}
+}
+
+func counts(details *servicebus.CountDetails) string {
+	if details == nil {
+		return "no counts"
+	}
+
+	return fmt.Sprintf("messages: %v, dead letters: %v, scheduled: %v", count(details.ActiveMessageCount), count(details.DeadLetterMessageCount), count(details.ScheduledMessageCount))
+}
+
+func count(value *int32) int32 {
+	if value == nil {
+		return 0
+	}
+
+	return *value
+}
+
+// printMessage prints a message the same way the subscribe commands do
+func printMessage(msg *servicebus.Message) {
+	enqueuedTime := ""
+	if msg.SystemProperties != nil && msg.SystemProperties.EnqueuedTime != nil {
+		enqueuedTime = msg.SystemProperties.EnqueuedTime.String()
+	}
+	logger.LogHighlight("%v Message %v with label %v", log.Info, enqueuedTime, msg.ID, msg.Label)
+	logger.Info("User Properties:")
+	jsonString, _ := json.MarshalIndent(msg.UserProperties, "", "  ")
+	fmt.Println(string(jsonString))
+	logger.Info("Message Body:")
+	fmt.Println(string(msg.Data))
+}
@@ -0,0 +1,198 @@
+package shell
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cjlapao/common-go/log"
+)
+
+// keyTab is the key that completes the word under the cursor
+const keyTab = '\t'
+
+// names are the entity names used by the tab completion, they are loaded once and refreshed by the ls and
+// refresh commands so completing does not call the service bus every time
+type names struct {
+	loaded        bool
+	queues        []string
+	topics        []string
+	subscriptions map[string][]string
+}
+
+func (n *names) setNamespace(queues []string, topics []string) {
+	sort.Strings(queues)
+	sort.Strings(topics)
+	n.queues = queues
+	n.topics = topics
+	n.loaded = true
+}
+
+func (n *names) setSubscriptions(topic string, subscriptions []string) {
+	sort.Strings(subscriptions)
+	n.subscriptions[topic] = subscriptions
+}
+
+// getNames loads the queue and topic names the first time they are needed, the logger is silenced as it
+// would write over the line being edited
+func (s *Shell) getNames() *names {
+	if s.names == nil {
+		s.names = &names{
+			subscriptions: make(map[string][]string),
+		}
+	}
+	if s.names.loaded {
+		return s.names
+	}
+
+	restore := silence()
+	defer restore()
+
+	queueNames := make([]string, 0)
+	if queues, err := s.cli.ListQueues(); err == nil {
+		for _, queue := range queues {
+			queueNames = append(queueNames, queue.Name)
+		}
+	}
+	topicNames := make([]string, 0)
+	if topics, err := s.cli.ListTopics(); err == nil {
+		for _, topic := range topics {
+			topicNames = append(topicNames, topic.Name)
+		}
+	}
+	s.names.setNamespace(queueNames, topicNames)
+
+	return s.names
+}
+
+// getSubscriptions loads the subscription names of a topic the first time they are needed
+func (s *Shell) getSubscriptions(topic string) []string {
+	n := s.getNames()
+	if subscriptions, exists := n.subscriptions[topic]; exists {
+		return subscriptions
+	}
+
+	restore := silence()
+	defer restore()
+
+	subscriptionNames := make([]string, 0)
+	if subscriptions, err := s.cli.ListSubscriptions(topic); err == nil {
+		for _, subscription := range subscriptions {
+			subscriptionNames = append(subscriptionNames, subscription.Name)
+		}
+	}
+	n.setSubscriptions(topic, subscriptionNames)
+
+	return subscriptionNames
+}
+
+func silence() func() {
+	loggers := logger.Loggers
+	logger.Loggers = []log.Log{}
+
+	return func() {
+		logger.Loggers = loggers
+	}
+}
+
+// complete is called by the terminal for every key, on tab it completes the word before the cursor with
+// the longest prefix of the values it can have, listing them if there is more than one
+func (s *Shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != keyTab {
+		return "", 0, false
+	}
+
+	before := line[:pos]
+	args := strings.Fields(before)
+	if len(args) == 0 || strings.HasSuffix(before, " ") {
+		args = append(args, "")
+	}
+	word := args[len(args)-1]
+
+	var candidates []string
+	if len(args) == 1 {
+		candidates = completeCommands(s, nil)
+	} else if cmd := findCommand(strings.ToLower(args[0])); cmd != nil && cmd.complete != nil {
+		candidates = cmd.complete(s, args[1:len(args)-1])
+	}
+
+	matches := make([]string, 0)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return line, pos, true
+	}
+
+	completed := matches[0]
+	if len(matches) == 1 {
+		completed += " "
+	} else {
+		completed = commonPrefix(matches)
+		if completed == word {
+			s.terminal.Write([]byte(strings.Join(matches, "  ") + "\n"))
+		}
+	}
+
+	newLine := before[:len(before)-len(word)] + completed + line[pos:]
+	return newLine, pos - len(word) + len(completed), true
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
+
+func completeCommands(s *Shell, args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+
+	result := make([]string, 0)
+	for _, cmd := range commands {
+		result = append(result, cmd.name)
+	}
+
+	return result
+}
+
+func completeUse(s *Shell, args []string) []string {
+	switch len(args) {
+	case 0:
+		return []string{"queue", "topic", "subscription", "..", "/"}
+	case 1:
+		switch strings.ToLower(args[0]) {
+		case "queue":
+			return s.getNames().queues
+		case "topic":
+			return s.getNames().topics
+		case "subscription":
+			if s.topic != "" {
+				return s.getSubscriptions(s.topic)
+			}
+			// subscriptions of other topics are completed as topic/subscription
+			result := make([]string, 0)
+			for _, topic := range s.getNames().topics {
+				for _, subscription := range s.getSubscriptions(topic) {
+					result = append(result, topic+"/"+subscription)
+				}
+			}
+			return result
+		}
+	}
+
+	return nil
+}
+
+func completeWords(words ...string) func(s *Shell, args []string) []string {
+	return func(s *Shell, args []string) []string {
+		return words
+	}
+}
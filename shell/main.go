@@ -0,0 +1,200 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/cjlapao/common-go/log"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"golang.org/x/term"
+)
+
+var logger = log.Get()
+
+// Shell Interactive session on one service bus client, the commands run against the queue, topic or
+// subscription in use so they do not need to repeat the entity names
+type Shell struct {
+	cli *sbcli.ServiceBusCli
+
+	queue        string
+	topic        string
+	subscription string
+
+	terminal *term.Terminal
+	names    *names
+}
+
+// New Creates a shell for the namespace of the client
+func New(cli *sbcli.ServiceBusCli) *Shell {
+	return &Shell{
+		cli: cli,
+	}
+}
+
+// Run Reads and runs commands until the user exits or the context is cancelled, commands are read from the
+// input without a prompt when it is not a terminal so the shell can run a script
+func (s *Shell) Run(ctx context.Context) error {
+	if s.cli == nil || s.cli.Namespace == nil {
+		return errors.New("there is no service bus namespace, check the connection string")
+	}
+
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for ctx.Err() == nil && scanner.Scan() {
+			if !s.Execute(ctx, scanner.Text()) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	s.terminal = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, s.prompt())
+	s.terminal.AutoCompleteCallback = s.complete
+
+	logger.LogHighlight("Connected to service bus %v, type %v to see the commands and %v to leave", log.Info, s.cli.Namespace.Name, "help", "exit")
+	for ctx.Err() == nil {
+		line, err := s.readLine(stdin, s.prompt())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !s.Execute(ctx, line) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// readLine puts the terminal in raw mode only while the line is edited, the commands print with the
+// terminal as it was
+func (s *Shell) readLine(stdin int, prompt string) (string, error) {
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(stdin, state)
+
+	if width, height, err := term.GetSize(stdin); err == nil {
+		s.terminal.SetSize(width, height)
+	}
+	s.terminal.SetPrompt(prompt)
+
+	return s.terminal.ReadLine()
+}
+
+// Execute Runs a command line, returns false if the shell should exit, ctrl+c cancels the command without
+// leaving the shell
+func (s *Shell) Execute(ctx context.Context, line string) bool {
+	args, err := splitArgs(line)
+	if err != nil {
+		logger.Error(err.Error())
+		return true
+	}
+	if len(args) == 0 || strings.HasPrefix(args[0], "#") {
+		return true
+	}
+
+	name := strings.ToLower(args[0])
+	if name == "exit" || name == "quit" {
+		return false
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		logger.LogHighlight("Unknown command %v, type %v to see the commands", log.Error, args[0], "help")
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	defer signal.Stop(signalChan)
+	go func() {
+		select {
+		case <-signalChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := cmd.run(ctx, s, args[1:]); err != nil {
+		logger.Error(err.Error())
+	}
+
+	return true
+}
+
+// prompt shows the namespace and the entity in use
+func (s *Shell) prompt() string {
+	path := s.cli.Namespace.Name
+	switch {
+	case s.queue != "":
+		path += "/queues/" + s.queue
+	case s.subscription != "":
+		path += "/topics/" + s.topic + "/subscriptions/" + s.subscription
+	case s.topic != "":
+		path += "/topics/" + s.topic
+	}
+
+	return path + "> "
+}
+
+// splitArgs splits a command line by spaces, keeping quoted values together so json bodies can be sent
+func splitArgs(line string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("the command has an unclosed quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
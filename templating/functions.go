@@ -0,0 +1,136 @@
+package templating
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Azure/azure-amqp-common-go/v3/uuid"
+)
+
+const randomStringCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+var firstNames = []string{
+	"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth",
+	"David", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Carlos", "Karen",
+	"Daniel", "Nancy", "Matthew", "Lisa", "Anthony", "Sofia", "Mark", "Ana", "Paul", "Emma",
+}
+
+var lastNames = []string{
+	"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez",
+	"Hernandez", "Lopez", "Gonzalez", "Wilson", "Anderson", "Thomas", "Taylor", "Moore", "Jackson", "Martin",
+	"Lee", "Perez", "Thompson", "White", "Harris", "Sanchez", "Clark", "Ramirez", "Lewis", "Silva",
+}
+
+var emailDomains = []string{
+	"example.com", "example.org", "example.net", "mail.example.com", "test.example.com",
+}
+
+// the global random source is not seeded in the go version of the module so we keep our own
+var (
+	randomMutex  sync.Mutex
+	randomSource = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// functions available to the templates
+var functions = template.FuncMap{
+	"uuid":         newUUID,
+	"now":          now,
+	"unix":         func() int64 { return time.Now().Unix() },
+	"unixMilli":    func() int64 { return time.Now().UnixNano() / int64(time.Millisecond) },
+	"randomInt":    randomInt,
+	"randomString": randomString,
+	"pick":         pick,
+	"firstName":    func() string { return pickString(firstNames) },
+	"lastName":     func() string { return pickString(lastNames) },
+	"name":         func() string { return pickString(firstNames) + " " + pickString(lastNames) },
+	"email":        email,
+}
+
+// FormatFuncs Returns the functions of the templates that format messages and notifications, json marshals a
+// value without escaping html so it can be embedded in a json document
+func FormatFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			var buffer bytes.Buffer
+			encoder := json.NewEncoder(&buffer)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(value); err != nil {
+				return "", err
+			}
+
+			return strings.TrimRight(buffer.String(), "\n"), nil
+		},
+	}
+}
+
+func newUUID() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	return id.String(), nil
+}
+
+// now gets the current time in RFC3339 or in the go layout if one is passed, {{now "2006-01-02"}}
+func now(layout ...string) string {
+	if len(layout) > 0 {
+		return time.Now().UTC().Format(layout[0])
+	}
+
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+// randomInt gets a random number between min and max, both included
+func randomInt(min int, max int) int {
+	if max < min {
+		min, max = max, min
+	}
+
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+	return min + randomSource.Intn(max-min+1)
+}
+
+// randomString gets a random alphanumeric string with the length or with a length between the two values,
+// {{randomString 8}} or {{randomString 5 10}}
+func randomString(length ...int) string {
+	size := 10
+	switch len(length) {
+	case 1:
+		size = length[0]
+	case 2:
+		size = randomInt(length[0], length[1])
+	}
+
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+	var builder strings.Builder
+	for i := 0; i < size; i++ {
+		builder.WriteByte(randomStringCharacters[randomSource.Intn(len(randomStringCharacters))])
+	}
+
+	return builder.String()
+}
+
+// pick gets one of the values at random, {{pick "red" "green" "blue"}}
+func pick(values ...interface{}) interface{} {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[randomInt(0, len(values)-1)]
+}
+
+func pickString(values []string) string {
+	return values[randomInt(0, len(values)-1)]
+}
+
+func email() string {
+	return strings.ToLower(pickString(firstNames)+"."+pickString(lastNames)) + "@" + pickString(emailDomains)
+}
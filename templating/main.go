@@ -0,0 +1,249 @@
+package templating
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/cjlapao/servicebuscli-go/entities"
+)
+
+// Template is a message request with templated fields that renders a different message for every index
+//
+// The label, correlation id, content type, user property values and any string in the data can use go
+// templates, for example:
+//
+//	{"orderId": "{{uuid}}", "sequence": "{{.Index}}", "customer": "{{name}}", "tenant": "{{.Row.tenant}}"}
+//
+// .Index is the position of the message starting at zero and .Row is the row of the data file for the
+// message, the rows are reused from the start when there are more messages than rows. The columns of a row are
+// strings, json values that are not strings are written as json and missing columns are empty. A value that is only
+// a template expression keeps its type when it renders to a number or a boolean, so "{{randomInt 1 10}}"
+// becomes a json number. Strings without templates are sent as they are.
+type Template struct {
+	message   entities.MessageRequest
+	rows      []map[string]string
+	templates map[string]*template.Template
+}
+
+// Context Values available to the templates of a message
+type Context struct {
+	Index int
+	Row   map[string]string
+}
+
+// New Parses the templates in a message request, rows can be nil if there is no data file
+func New(message entities.MessageRequest, rows []map[string]interface{}) (*Template, error) {
+	result := Template{
+		message:   message,
+		rows:      make([]map[string]string, 0, len(rows)),
+		templates: make(map[string]*template.Template),
+	}
+
+	for _, row := range rows {
+		columns, err := rowColumns(row)
+		if err != nil {
+			return nil, err
+		}
+		result.rows = append(result.rows, columns)
+	}
+
+	values := []string{message.Label, message.CorrelationID, message.ContentType}
+	for _, value := range message.UserProperties {
+		values = append(values, collectStrings(value)...)
+	}
+	for _, value := range message.Data {
+		values = append(values, collectStrings(value)...)
+	}
+
+	for _, value := range values {
+		if err := result.parse(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
+
+// IsTemplate Returns true if the message uses templates or rows, otherwise every message rendered is the same
+func (t *Template) IsTemplate() bool {
+	return len(t.templates) > 0 || len(t.rows) > 0
+}
+
+// Render Renders the message for an index
+func (t *Template) Render(index int) (*entities.MessageRequest, error) {
+	ctx := Context{
+		Index: index,
+		Row:   map[string]string{},
+	}
+	if len(t.rows) > 0 {
+		ctx.Row = t.rows[index%len(t.rows)]
+	}
+
+	result := entities.MessageRequest{}
+	var err error
+	if result.Label, err = t.renderString(t.message.Label, ctx); err != nil {
+		return nil, err
+	}
+	if result.CorrelationID, err = t.renderString(t.message.CorrelationID, ctx); err != nil {
+		return nil, err
+	}
+	if result.ContentType, err = t.renderString(t.message.ContentType, ctx); err != nil {
+		return nil, err
+	}
+
+	if t.message.UserProperties != nil {
+		result.UserProperties = make(map[string]interface{})
+		for key, value := range t.message.UserProperties {
+			if result.UserProperties[key], err = t.renderValue(value, ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if t.message.Data != nil {
+		result.Data = make(map[string]interface{})
+		for key, value := range t.message.Data {
+			if result.Data[key], err = t.renderValue(value, ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &result, nil
+}
+
+func (t *Template) parse(value string) error {
+	if !strings.Contains(value, "{{") {
+		return nil
+	}
+	if _, exists := t.templates[value]; exists {
+		return nil
+	}
+
+	parsed, err := template.New(value).Option("missingkey=zero").Funcs(functions).Parse(value)
+	if err != nil {
+		return err
+	}
+
+	t.templates[value] = parsed
+	return nil
+}
+
+func (t *Template) renderString(value string, ctx Context) (string, error) {
+	parsed, exists := t.templates[value]
+	if !exists {
+		return value, nil
+	}
+
+	var buffer bytes.Buffer
+	if err := parsed.Execute(&buffer, ctx); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// rowColumns converts the values of a row to the strings the templates get, so a missing column renders as an
+// empty string with missingkey=zero instead of <no value>
+func rowColumns(row map[string]interface{}) (map[string]string, error) {
+	columns := make(map[string]string)
+	for key, value := range row {
+		switch typed := value.(type) {
+		case nil:
+			columns[key] = ""
+		case string:
+			columns[key] = typed
+		default:
+			content, err := json.Marshal(typed)
+			if err != nil {
+				return nil, err
+			}
+			columns[key] = string(content)
+		}
+	}
+
+	return columns, nil
+}
+
+// renderValue renders the strings in a json value, a copy is returned so the template is not changed
+func (t *Template) renderValue(value interface{}, ctx Context) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		rendered, err := t.renderString(typed, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if rendered != typed && isSingleExpression(typed) {
+			return typedValue(rendered), nil
+		}
+		return rendered, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range typed {
+			rendered, err := t.renderValue(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, item := range typed {
+			rendered, err := t.renderValue(item, ctx)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	}
+
+	return value, nil
+}
+
+// collectStrings gets all the strings in a json value
+func collectStrings(value interface{}) []string {
+	result := make([]string, 0)
+	switch typed := value.(type) {
+	case string:
+		result = append(result, typed)
+	case map[string]interface{}:
+		for _, item := range typed {
+			result = append(result, collectStrings(item)...)
+		}
+	case []interface{}:
+		for _, item := range typed {
+			result = append(result, collectStrings(item)...)
+		}
+	}
+
+	return result
+}
+
+// isSingleExpression returns true if the value is only one template expression
+func isSingleExpression(value string) bool {
+	trimmed := strings.TrimSpace(value)
+	return strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "{{") == 1
+}
+
+// typedValue converts a rendered value to a json number or boolean if it is one
+func typedValue(value string) interface{} {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "true" || trimmed == "false" {
+		return trimmed == "true"
+	}
+	// only valid json numbers, so values like 007 stay strings
+	var number float64
+	if err := json.Unmarshal([]byte(trimmed), &number); err == nil {
+		if integer, err := strconv.ParseInt(trimmed, 10, 64); err == nil {
+			return integer
+		}
+		return number
+	}
+
+	return value
+}
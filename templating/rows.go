@@ -0,0 +1,90 @@
+package templating
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadRows Reads the rows of a data file, csv files need a header row with the column names and any other
+// file is read as newline delimited json with an object per line
+func LoadRows(filePath string) ([]map[string]interface{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(filePath), ".csv") {
+		return readCsvRows(file)
+	}
+
+	return readNdjsonRows(file)
+}
+
+func readCsvRows(reader io.Reader) ([]map[string]interface{}, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("the csv file is empty, it needs a header row with the column names")
+		}
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{})
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+func readNdjsonRows(reader io.Reader) ([]map[string]interface{}, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	result := make([]map[string]interface{}, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + " is not a json object, " + err.Error())
+		}
+		result = append(result, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,251 @@
+package top
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+// peekCount is the number of messages shown when peeking an entity
+const peekCount = 10
+
+// action is something done to the selected entity, it either returns the lines shown in the detail view or
+// only a status message
+type action struct {
+	description string
+	destructive bool
+	run         func(ctx context.Context) (lines []string, status string, err error)
+}
+
+// handleAction starts the action of the key on the entity, destructive actions need to be confirmed first
+func (t *Top) handleAction(key string, entity Entity) {
+	var selected *action
+	switch key {
+	case keyEnter, "p":
+		selected = t.peekAction(entity, false)
+	case "d":
+		selected = t.peekAction(entity, true)
+	case "l":
+		selected = t.rulesAction(entity)
+	case "x":
+		selected = t.purgeAction(entity, false)
+	case "X":
+		selected = t.purgeAction(entity, true)
+	case "R":
+		selected = t.resubmitAction(entity)
+	default:
+		return
+	}
+
+	if selected == nil {
+		return
+	}
+	if selected.destructive {
+		t.confirm = selected
+		return
+	}
+	t.start(*selected)
+}
+
+// start runs the action in the background, only one action runs at a time
+func (t *Top) start(selected action) {
+	if t.busy {
+		t.status = "Wait for the current action to finish"
+		return
+	}
+
+	t.busy = true
+	t.status = strings.ToUpper(selected.description[:1]) + selected.description[1:] + "..."
+	go func() {
+		lines, status, err := selected.run(context.Background())
+		t.updates <- func() {
+			t.busy = false
+			if err != nil {
+				t.status = "Could not " + selected.description + ", " + err.Error()
+				return
+			}
+
+			t.status = status
+			if lines != nil {
+				t.view = viewDetail
+				t.detailTitle = selected.description
+				t.detailLines = lines
+				t.detailOffset = 0
+			}
+			// the counts changed so there is no point waiting for the next refresh
+			t.refresh()
+		}
+	}()
+}
+
+func (t *Top) peekAction(entity Entity, deadLetter bool) *action {
+	if entity.Type == EntityTopic {
+		t.status = "Topics have no messages to peek, select one of its subscriptions"
+		return nil
+	}
+
+	description := "peek the messages of " + describe(entity)
+	if deadLetter {
+		description = "peek the dead letters of " + describe(entity)
+	}
+
+	return &action{
+		description: description,
+		run: func(ctx context.Context) ([]string, string, error) {
+			var messages []servicebus.Message
+			var err error
+			switch {
+			case entity.Type == EntityQueue && deadLetter:
+				messages, err = t.cli.GetQueueDeadLetterMessages(entity.Name, peekCount, true, sbcli.ReceiveOptions{})
+			case entity.Type == EntityQueue:
+				messages, err = t.cli.GetQueueActiveMessages(entity.Name, peekCount, true, sbcli.ReceiveOptions{})
+			case deadLetter:
+				messages, err = t.cli.GetSubscriptionDeadLetterMessages(entity.Topic, entity.Subscription, peekCount, true, sbcli.ReceiveOptions{})
+			default:
+				messages, err = t.cli.GetSubscriptionActiveMessages(entity.Topic, entity.Subscription, peekCount, true, sbcli.ReceiveOptions{})
+			}
+			if err != nil {
+				return nil, "", err
+			}
+
+			lines := make([]string, 0)
+			for i := range messages {
+				lines = append(lines, messageLines(&messages[i])...)
+				lines = append(lines, "")
+			}
+			if len(lines) == 0 {
+				lines = append(lines, "There are no messages")
+			}
+
+			return lines, "Peeked " + strconv.Itoa(len(messages)) + " messages of " + describe(entity), nil
+		},
+	}
+}
+
+func (t *Top) rulesAction(entity Entity) *action {
+	if entity.Type != EntitySubscription {
+		t.status = "Only subscriptions have rules"
+		return nil
+	}
+
+	return &action{
+		description: "list the rules of " + describe(entity),
+		run: func(ctx context.Context) ([]string, string, error) {
+			rules, err := t.cli.GetSubscriptionRules(entity.Topic, entity.Subscription)
+			if err != nil {
+				return nil, "", err
+			}
+
+			lines := make([]string, 0)
+			for _, rule := range rules {
+				response := entities.RuleResponse{}
+				response.FromServiceBus(rule)
+				lines = append(lines, jsonLines(response)...)
+				lines = append(lines, "")
+			}
+
+			return lines, "Found " + strconv.Itoa(len(rules)) + " rules in " + describe(entity), nil
+		},
+	}
+}
+
+func (t *Top) purgeAction(entity Entity, deadLetter bool) *action {
+	if entity.Type == EntityTopic {
+		t.status = "Topics can not be purged, select one of its subscriptions"
+		return nil
+	}
+
+	description := "purge the messages of " + describe(entity)
+	if deadLetter {
+		description = "purge the dead letters of " + describe(entity)
+	}
+
+	return &action{
+		description: description,
+		destructive: true,
+		run: func(ctx context.Context) ([]string, string, error) {
+			var removed int64
+			progress := func(succeeded int, failed int) {
+				atomic.AddInt64(&removed, int64(succeeded))
+			}
+
+			var err error
+			if entity.Type == EntityQueue {
+				err = t.cli.PurgeQueue(ctx, entity.Name, deadLetter, progress)
+			} else {
+				err = t.cli.PurgeSubscription(ctx, entity.Topic, entity.Subscription, deadLetter, progress)
+			}
+
+			return nil, fmt.Sprintf("Removed %v messages from %v", atomic.LoadInt64(&removed), describe(entity)), err
+		},
+	}
+}
+
+func (t *Top) resubmitAction(entity Entity) *action {
+	if entity.Type == EntityTopic {
+		t.status = "Topics have no dead letters, select one of its subscriptions"
+		return nil
+	}
+
+	description := "resubmit the dead letters of " + describe(entity)
+	if entity.Type != EntityQueue {
+		description += " to topic " + entity.Topic + ", every subscription with matching rules receives them"
+	}
+
+	return &action{
+		description: description,
+		destructive: true,
+		run: func(ctx context.Context) ([]string, string, error) {
+			var resubmitted, failed int64
+			progress := func(succeeded int, failures int) {
+				atomic.AddInt64(&resubmitted, int64(succeeded))
+				atomic.AddInt64(&failed, int64(failures))
+			}
+
+			var err error
+			if entity.Type == EntityQueue {
+				err = t.cli.ResubmitQueueDeadLetters(ctx, entity.Name, sbcli.ResubmitOptions{}, progress)
+			} else {
+				// confirming the action allows the fan out
+				err = t.cli.ResubmitSubscriptionDeadLetters(ctx, entity.Topic, entity.Subscription, sbcli.ResubmitOptions{FanOut: true}, progress)
+			}
+
+			return nil, fmt.Sprintf("Resubmitted %v dead letters of %v, %v failed", atomic.LoadInt64(&resubmitted), describe(entity), atomic.LoadInt64(&failed)), err
+		},
+	}
+}
+
+// messageLines formats a message as indented json, messages that are not json show the body as it is
+func messageLines(msg *servicebus.Message) []string {
+	response := entities.MessageResponse{}
+	if err := response.FromServiceBus(msg); err != nil {
+		return append([]string{"Message " + msg.ID + " " + msg.Label}, strings.Split(string(msg.Data), "\n")...)
+	}
+
+	return jsonLines(response)
+}
+
+func jsonLines(value interface{}) []string {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	return strings.Split(string(content), "\n")
+}
+
+func describe(entity Entity) string {
+	switch entity.Type {
+	case EntitySubscription:
+		return "subscription " + entity.Subscription + " on topic " + entity.Topic
+	default:
+		return entity.Type + " " + entity.Name
+	}
+}
@@ -0,0 +1,167 @@
+package top
+
+import (
+	"sort"
+	"strings"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+// Types of the entities in the dashboard
+const (
+	EntityQueue        = "queue"
+	EntityTopic        = "topic"
+	EntitySubscription = "subscription"
+)
+
+// Columns the entities can be sorted by
+const (
+	SortByName = iota
+	SortByType
+	SortByActive
+	SortByDeadLetter
+	SortByScheduled
+)
+
+var sortNames = []string{"name", "type", "active", "dead letters", "scheduled"}
+
+// Entity Queue, topic or subscription with its counts and how much they changed since the previous refresh
+type Entity struct {
+	Type         string
+	Name         string
+	Topic        string
+	Subscription string
+
+	Active     int64
+	DeadLetter int64
+	Scheduled  int64
+
+	ActiveDelta     int64
+	DeadLetterDelta int64
+	ScheduledDelta  int64
+}
+
+// Key Unique name of the entity, queues and topics can have the same name so the type is part of it
+func (e *Entity) Key() string {
+	return e.Type + ":" + e.Name
+}
+
+// listEntities gets the counts of every queue, topic and subscription in the namespace
+func listEntities(cli *sbcli.ServiceBusCli) ([]Entity, error) {
+	result := make([]Entity, 0)
+
+	queues, err := cli.ListQueues()
+	if err != nil {
+		return nil, err
+	}
+	for _, queue := range queues {
+		result = append(result, newEntity(EntityQueue, queue.Name, "", "", queue.CountDetails))
+	}
+
+	topics, err := cli.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		result = append(result, newEntity(EntityTopic, topic.Name, topic.Name, "", topic.CountDetails))
+
+		subscriptions, err := cli.ListSubscriptions(topic.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, subscription := range subscriptions {
+			result = append(result, newEntity(EntitySubscription, topic.Name+"/"+subscription.Name, topic.Name, subscription.Name, subscription.CountDetails))
+		}
+	}
+
+	return result, nil
+}
+
+func newEntity(entityType string, name string, topic string, subscription string, details *servicebus.CountDetails) Entity {
+	entity := Entity{
+		Type:         entityType,
+		Name:         name,
+		Topic:        topic,
+		Subscription: subscription,
+	}
+	if details != nil {
+		entity.Active = count(details.ActiveMessageCount)
+		entity.DeadLetter = count(details.DeadLetterMessageCount)
+		entity.Scheduled = count(details.ScheduledMessageCount)
+	}
+
+	return entity
+}
+
+func count(value *int32) int64 {
+	if value == nil {
+		return 0
+	}
+
+	return int64(*value)
+}
+
+// setDeltas calculates the changes of the counts since the previous refresh, new entities have no changes
+func setDeltas(entities []Entity, previous []Entity) {
+	byKey := make(map[string]Entity)
+	for _, entity := range previous {
+		byKey[entity.Key()] = entity
+	}
+
+	for i := range entities {
+		old, exists := byKey[entities[i].Key()]
+		if !exists {
+			continue
+		}
+		entities[i].ActiveDelta = entities[i].Active - old.Active
+		entities[i].DeadLetterDelta = entities[i].DeadLetter - old.DeadLetter
+		entities[i].ScheduledDelta = entities[i].Scheduled - old.Scheduled
+	}
+}
+
+// filterEntities gets the entities with the filter in their name, ignoring the case
+func filterEntities(entities []Entity, filter string) []Entity {
+	if filter == "" {
+		return entities
+	}
+
+	filter = strings.ToLower(filter)
+	result := make([]Entity, 0)
+	for _, entity := range entities {
+		if strings.Contains(strings.ToLower(entity.Name), filter) {
+			result = append(result, entity)
+		}
+	}
+
+	return result
+}
+
+// sortEntities sorts the entities by the column, counts are sorted from the highest by default and ties
+// are sorted by name so the rows do not jump around between refreshes
+func sortEntities(entities []Entity, column int, reverse bool) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		a, b := entities[i], entities[j]
+		var less, equal bool
+		switch column {
+		case SortByType:
+			less, equal = a.Type < b.Type, a.Type == b.Type
+		case SortByActive:
+			less, equal = a.Active > b.Active, a.Active == b.Active
+		case SortByDeadLetter:
+			less, equal = a.DeadLetter > b.DeadLetter, a.DeadLetter == b.DeadLetter
+		case SortByScheduled:
+			less, equal = a.Scheduled > b.Scheduled, a.Scheduled == b.Scheduled
+		default:
+			less, equal = a.Name < b.Name, a.Name == b.Name
+		}
+
+		if equal {
+			return a.Name < b.Name
+		}
+		if reverse {
+			return !less
+		}
+		return less
+	})
+}
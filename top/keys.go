@@ -0,0 +1,95 @@
+package top
+
+import (
+	"context"
+	"io"
+)
+
+// names of the keys that are not a printable character
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdn"
+	keyHome      = "home"
+	keyEnd       = "end"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyCtrlC     = "ctrl+c"
+)
+
+// escapeSequences are the keys terminals send as escape sequences
+var escapeSequences = map[string]string{
+	"\x1b[A":  keyUp,
+	"\x1b[B":  keyDown,
+	"\x1bOA":  keyUp,
+	"\x1bOB":  keyDown,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+	"\x1b[H":  keyHome,
+	"\x1b[F":  keyEnd,
+	"\x1b[1~": keyHome,
+	"\x1b[4~": keyEnd,
+}
+
+// readKeys reads the key presses from the terminal in raw mode until the context is cancelled
+// or the input is closed
+func readKeys(ctx context.Context, input io.Reader, keys chan<- string) {
+	defer close(keys)
+
+	buffer := make([]byte, 32)
+	for {
+		n, err := input.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		for _, key := range parseKeys(buffer[:n]) {
+			select {
+			case keys <- key:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// parseKeys splits what was read from the terminal in keys, a read can have more than one key when typing fast
+func parseKeys(data []byte) []string {
+	keys := make([]string, 0)
+	for len(data) > 0 {
+		if data[0] == 0x1b && len(data) > 1 {
+			matched := false
+			for sequence, key := range escapeSequences {
+				if len(data) >= len(sequence) && string(data[:len(sequence)]) == sequence {
+					keys = append(keys, key)
+					data = data[len(sequence):]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				// unknown sequence, ignoring the rest of the read
+				return keys
+			}
+			continue
+		}
+
+		switch data[0] {
+		case 0x1b:
+			keys = append(keys, keyEscape)
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		case 0x7f, 0x08:
+			keys = append(keys, keyBackspace)
+		case 0x03:
+			keys = append(keys, keyCtrlC)
+		default:
+			keys = append(keys, string(data[0]))
+		}
+		data = data[1:]
+	}
+
+	return keys
+}
@@ -0,0 +1,321 @@
+package top
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cjlapao/common-go/log"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+	"golang.org/x/term"
+)
+
+// DefaultInterval is how often the dashboard refreshes if no interval is set
+const DefaultInterval = 5 * time.Second
+
+var logger = log.Get()
+
+// views of the dashboard
+const (
+	viewList = iota
+	viewDetail
+)
+
+var helpLines = []string{
+	"up/down or j/k  Move the selection",
+	"pgup/pgdn       Move the selection a page",
+	"home/end or g/G Move the selection to the first or the last entity",
+	"s               Sort by the next column, name, type, active, dead letters or scheduled",
+	"r               Reverse the sorting",
+	"/               Filter the entities by name, enter keeps the filter and esc clears it",
+	"space           Refresh now",
+	"enter or p      Peek the messages of the selected queue or subscription",
+	"d               Peek the dead letters of the selected queue or subscription",
+	"l               List the rules of the selected subscription",
+	"x               Purge the messages of the selected queue or subscription",
+	"X               Purge the dead letters of the selected queue or subscription",
+	"R               Resubmit the dead letters of the selected queue or subscription",
+	"q               Quit, or go back to the list",
+}
+
+// Top Full screen dashboard with the counts of the queues, topics and subscriptions of a namespace, refreshed
+// every interval, the selected entity can be peeked, purged and have its dead letters resubmitted
+type Top struct {
+	Interval time.Duration
+
+	cli    *sbcli.ServiceBusCli
+	screen *os.File
+
+	entities    []Entity
+	visible     []Entity
+	refreshedAt time.Time
+	refreshing  bool
+
+	sortBy      int
+	sortReverse bool
+	filter      string
+	editing     bool
+	selected    int
+	offset      int
+
+	view         int
+	detailTitle  string
+	detailLines  []string
+	detailOffset int
+
+	// confirm is the destructive action waiting for a yes
+	confirm *action
+	busy    bool
+	status  string
+
+	// updates are applied by the event loop so the state is never changed by two goroutines
+	updates chan func()
+}
+
+// New Creates a dashboard for the namespace of the client
+func New(cli *sbcli.ServiceBusCli) *Top {
+	return &Top{
+		Interval: DefaultInterval,
+		cli:      cli,
+		sortBy:   SortByName,
+		updates:  make(chan func(), 16),
+	}
+}
+
+// Run Takes over the terminal until the user quits or the context is cancelled, the logger is silenced while
+// the dashboard is open as it would write over the screen
+func (t *Top) Run(ctx context.Context) error {
+	if t.cli == nil || t.cli.Namespace == nil {
+		return errors.New("there is no service bus namespace, check the connection string")
+	}
+
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("top needs to run in a terminal")
+	}
+	if t.Interval <= 0 {
+		t.Interval = DefaultInterval
+	}
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(stdin, state)
+
+	// some service bus calls print their errors straight to the output
+	t.screen = os.Stdout
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stdout = devNull
+		defer func() {
+			os.Stdout = t.screen
+			devNull.Close()
+		}()
+	}
+	loggers := logger.Loggers
+	logger.Loggers = []log.Log{}
+	defer func() {
+		logger.Loggers = loggers
+	}()
+
+	io.WriteString(t.screen, enterAlternateScreen+hideCursor)
+	defer io.WriteString(t.screen, showCursor+leaveAlternateScreen)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	keys := make(chan string)
+	go readKeys(ctx, os.Stdin, keys)
+
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	t.refresh()
+	for {
+		t.render()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.refresh()
+		case update := <-t.updates:
+			update()
+		case key, ok := <-keys:
+			if !ok || !t.handleKey(key) {
+				return nil
+			}
+		}
+	}
+}
+
+// refresh gets the counts in the background, a refresh is skipped if the previous one did not finish
+func (t *Top) refresh() {
+	if t.refreshing {
+		return
+	}
+	t.refreshing = true
+
+	go func() {
+		entities, err := listEntities(t.cli)
+		t.updates <- func() {
+			t.refreshing = false
+			if err != nil {
+				t.status = "Could not refresh the entities, " + err.Error()
+				return
+			}
+			setDeltas(entities, t.entities)
+			t.entities = entities
+			t.refreshedAt = time.Now()
+			t.updateVisible()
+		}
+	}()
+}
+
+// updateVisible applies the filter and the sorting keeping the selected entity if it is still visible
+func (t *Top) updateVisible() {
+	selectedKey := ""
+	if t.selected < len(t.visible) {
+		selectedKey = t.visible[t.selected].Key()
+	}
+
+	visible := make([]Entity, 0)
+	visible = append(visible, filterEntities(t.entities, t.filter)...)
+	sortEntities(visible, t.sortBy, t.sortReverse)
+	t.visible = visible
+
+	t.selected = 0
+	for i, entity := range t.visible {
+		if entity.Key() == selectedKey {
+			t.selected = i
+			break
+		}
+	}
+}
+
+// selectedEntity gets the entity under the cursor
+func (t *Top) selectedEntity() *Entity {
+	if t.selected < 0 || t.selected >= len(t.visible) {
+		return nil
+	}
+
+	entity := t.visible[t.selected]
+	return &entity
+}
+
+// handleKey changes the state with a key press, returns false to quit
+func (t *Top) handleKey(key string) bool {
+	if key == keyCtrlC {
+		return false
+	}
+
+	if t.editing {
+		switch key {
+		case keyEnter:
+			t.editing = false
+		case keyEscape:
+			t.editing = false
+			t.filter = ""
+		case keyBackspace:
+			if len(t.filter) > 0 {
+				t.filter = t.filter[:len(t.filter)-1]
+			}
+		default:
+			if len(key) == 1 && key[0] >= ' ' && key[0] <= '~' {
+				t.filter += key
+			}
+		}
+		t.updateVisible()
+		return true
+	}
+
+	if t.confirm != nil {
+		if key == "y" || key == "Y" {
+			t.start(*t.confirm)
+		} else {
+			t.status = "Cancelled " + t.confirm.description
+		}
+		t.confirm = nil
+		return true
+	}
+
+	if t.view == viewDetail {
+		switch key {
+		case "q", keyEscape, keyBackspace:
+			t.view = viewList
+		case keyUp, "k":
+			t.detailOffset--
+		case keyDown, "j":
+			t.detailOffset++
+		case keyPageUp:
+			t.detailOffset -= t.pageSize()
+		case keyPageDown:
+			t.detailOffset += t.pageSize()
+		}
+		return true
+	}
+
+	switch key {
+	case "q":
+		return false
+	case keyUp, "k":
+		t.selected--
+	case keyDown, "j":
+		t.selected++
+	case keyPageUp:
+		t.selected -= t.pageSize()
+	case keyPageDown:
+		t.selected += t.pageSize()
+	case keyHome, "g":
+		t.selected = 0
+	case keyEnd, "G":
+		t.selected = len(t.visible) - 1
+	case "s":
+		t.sortBy = (t.sortBy + 1) % len(sortNames)
+		t.updateVisible()
+	case "r":
+		t.sortReverse = !t.sortReverse
+		t.updateVisible()
+	case "/":
+		t.editing = true
+	case " ":
+		t.refresh()
+	case "?":
+		t.view = viewDetail
+		t.detailTitle = "keys"
+		t.detailLines = helpLines
+		t.detailOffset = 0
+	default:
+		if entity := t.selectedEntity(); entity != nil {
+			t.handleAction(key, *entity)
+		}
+	}
+
+	if t.selected >= len(t.visible) {
+		t.selected = len(t.visible) - 1
+	}
+	if t.selected < 0 {
+		t.selected = 0
+	}
+	return true
+}
+
+// pageSize is the number of rows that fit in the screen
+func (t *Top) pageSize() int {
+	_, height := t.size()
+	if height-listChromeLines < 1 {
+		return 1
+	}
+
+	return height - listChromeLines
+}
+
+// size gets the size of the terminal, with a default if it can not be read
+func (t *Top) size() (int, int) {
+	width, height, err := term.GetSize(int(t.screen.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+
+	return width, height
+}
@@ -0,0 +1,209 @@
+package top
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// terminal escape sequences
+const (
+	enterAlternateScreen = "\x1b[?1049h"
+	leaveAlternateScreen = "\x1b[?1049l"
+	hideCursor           = "\x1b[?25l"
+	showCursor           = "\x1b[?25h"
+	clearScreen          = "\x1b[H\x1b[2J"
+	reverse              = "\x1b[7m"
+	bold                 = "\x1b[1m"
+	red                  = "\x1b[31m"
+	green                = "\x1b[32m"
+	yellow               = "\x1b[33m"
+	reset                = "\x1b[0m"
+)
+
+// listChromeLines are the lines of the list view that are not entities, header, sort, columns, help and status
+const listChromeLines = 5
+
+const (
+	typeWidth  = 14
+	countWidth = 9
+	deltaWidth = 7
+)
+
+// render draws the whole screen in one write so it does not flicker
+func (t *Top) render() {
+	width, height := t.size()
+	lines := make([]string, 0, height)
+
+	refreshed := "never"
+	if !t.refreshedAt.IsZero() {
+		refreshed = t.refreshedAt.Format("15:04:05")
+	}
+	header := fmt.Sprintf(" servicebus top | %v | %v entities | refreshed %v every %v", t.cli.Namespace.Name, len(t.visible), refreshed, t.Interval.String())
+	if t.refreshing {
+		header += " | refreshing"
+	}
+	lines = append(lines, reverse+pad(header, width)+reset)
+
+	if t.view == viewDetail {
+		lines = append(lines, t.renderDetail(width, height-3)...)
+		lines = append(lines, pad(" up/down scroll  pgup/pgdn page  esc back", width))
+	} else {
+		order := "descending"
+		if t.sortBy == SortByName || t.sortBy == SortByType {
+			order = "ascending"
+		}
+		if t.sortReverse {
+			if order == "ascending" {
+				order = "descending"
+			} else {
+				order = "ascending"
+			}
+		}
+		filter := t.filter
+		if t.editing {
+			filter += "_"
+		}
+		lines = append(lines, pad(fmt.Sprintf(" sort: %v %v | filter: %v", sortNames[t.sortBy], order, filter), width))
+		lines = append(lines, t.renderList(width, height-listChromeLines)...)
+		lines = append(lines, pad(" p peek  d dead letters  l rules  x/X purge  R resubmit  s sort  / filter  ? help  q quit", width))
+	}
+
+	status := t.status
+	if t.confirm != nil {
+		status = "Are you sure you want to " + t.confirm.description + "? (y/n)"
+	}
+	lines = append(lines, bold+pad(" "+status, width)+reset)
+
+	// raw mode does not return the carriage when moving to a new line
+	t.screen.WriteString(clearScreen + strings.Join(lines, "\r\n"))
+}
+
+func (t *Top) renderList(width int, rows int) []string {
+	nameWidth := width - typeWidth - 3*(countWidth+deltaWidth) - 1
+	if nameWidth < 10 {
+		nameWidth = 10
+	}
+
+	lines := make([]string, 0, rows+1)
+	columns := " " + pad("TYPE", typeWidth-1) + pad("NAME", nameWidth) +
+		padLeft("ACTIVE", countWidth) + pad("", deltaWidth) +
+		padLeft("DEAD", countWidth) + pad("", deltaWidth) +
+		padLeft("SCHEDULED", countWidth) + pad("", deltaWidth)
+	lines = append(lines, bold+pad(columns, width)+reset)
+
+	// keeping the selected entity in the screen
+	if t.selected < t.offset {
+		t.offset = t.selected
+	}
+	if rows > 0 && t.selected >= t.offset+rows {
+		t.offset = t.selected - rows + 1
+	}
+	if t.offset > len(t.visible)-rows {
+		t.offset = len(t.visible) - rows
+	}
+	if t.offset < 0 {
+		t.offset = 0
+	}
+
+	for i := t.offset; i < len(t.visible) && len(lines) <= rows; i++ {
+		entity := t.visible[i]
+		line := " " + pad(entity.Type, typeWidth-1) + pad(entity.Name, nameWidth) +
+			padLeft(strconv.FormatInt(entity.Active, 10), countWidth) + delta(entity.ActiveDelta, yellow) +
+			padLeft(strconv.FormatInt(entity.DeadLetter, 10), countWidth) + delta(entity.DeadLetterDelta, red) +
+			padLeft(strconv.FormatInt(entity.Scheduled, 10), countWidth) + delta(entity.ScheduledDelta, yellow)
+		if i == t.selected {
+			line = reverse + strings.ReplaceAll(line, reset, reset+reverse) + strings.Repeat(" ", max(0, width-visibleLength(line))) + reset
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) <= rows {
+		lines = append(lines, "")
+	}
+
+	return lines
+}
+
+func (t *Top) renderDetail(width int, rows int) []string {
+	if t.detailOffset > len(t.detailLines)-rows {
+		t.detailOffset = len(t.detailLines) - rows
+	}
+	if t.detailOffset < 0 {
+		t.detailOffset = 0
+	}
+
+	lines := []string{bold + pad(" "+strings.ToUpper(t.detailTitle[:1])+t.detailTitle[1:], width) + reset}
+	for i := t.detailOffset; i < len(t.detailLines) && len(lines) < rows; i++ {
+		lines = append(lines, pad(" "+t.detailLines[i], width))
+	}
+	for len(lines) < rows {
+		lines = append(lines, "")
+	}
+
+	return lines
+}
+
+// delta formats the change of a count, growing counts are colored and shrinking counts are green
+func delta(value int64, color string) string {
+	switch {
+	case value > 0:
+		return color + padLeft("+"+strconv.FormatInt(value, 10), deltaWidth) + reset
+	case value < 0:
+		return green + padLeft(strconv.FormatInt(value, 10), deltaWidth) + reset
+	}
+
+	return strings.Repeat(" ", deltaWidth)
+}
+
+// pad cuts or fills the value with spaces to the width
+func pad(value string, width int) string {
+	length := utf8.RuneCountInString(value)
+	if length > width {
+		runes := []rune(value)
+		if width <= 1 {
+			return string(runes[:width])
+		}
+		return string(runes[:width-1]) + "~"
+	}
+
+	return value + strings.Repeat(" ", width-length)
+}
+
+func padLeft(value string, width int) string {
+	length := utf8.RuneCountInString(value)
+	if length >= width {
+		return value
+	}
+
+	return strings.Repeat(" ", width-length) + value
+}
+
+// visibleLength is the length of a line without the escape sequences
+func visibleLength(value string) int {
+	length := 0
+	escaping := false
+	for _, r := range value {
+		switch {
+		case escaping:
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				escaping = false
+			}
+		case r == '\x1b':
+			escaping = true
+		default:
+			length++
+		}
+	}
+
+	return length
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
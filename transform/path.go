@@ -0,0 +1,188 @@
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segment is a field name or an array index of a path
+type segment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// Path Location of a field or array item of a json body, see ParsePath
+type Path []segment
+
+// ParsePath Parses a json path limited to fields and indexes like $.customer.addresses[0].city, jq style paths
+// like .customer.id start at the root as well
+func ParsePath(path string) (Path, error) {
+	switch {
+	case path == ".":
+		path = "$"
+	case strings.HasPrefix(path, ".["):
+		path = "$" + path[1:]
+	case strings.HasPrefix(path, "."):
+		path = "$" + path
+	}
+	return parsePath(path)
+}
+
+// Get Gets the value in the path of a decoded json body, the second value is false when the path does not exist
+func (p Path) Get(node interface{}) (interface{}, bool) {
+	for _, current := range p {
+		switch typed := node.(type) {
+		case map[string]interface{}:
+			child, exists := typed[current.key]
+			if current.isIndex || !exists {
+				return nil, false
+			}
+			node = child
+		case []interface{}:
+			if !current.isIndex || current.index >= len(typed) {
+				return nil, false
+			}
+			node = typed[current.index]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// parsePath parses a json path limited to fields and indexes, for example $.customer.addresses[0].city or
+// $['order id']
+func parsePath(path string) ([]segment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path %v needs to start with $", path)
+	}
+
+	segments := make([]segment, 0)
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("path %v has an empty field name", path)
+			}
+			segments = append(segments, segment{key: key})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			end := strings.Index(rest, "']")
+			if end < 0 {
+				return nil, fmt.Errorf("path %v has an unclosed field name", path)
+			}
+			segments = append(segments, segment{key: rest[2:end]})
+			rest = rest[end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("path %v has an unclosed index", path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("path %v has an invalid index %v", path, rest[1:end])
+			}
+			segments = append(segments, segment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %v is not valid, use fields like $.a.b and indexes like $.a[0]", path)
+		}
+	}
+
+	return segments, nil
+}
+
+// setPath sets the value in the path of a node, missing objects are created and an index one past the end of
+// an array appends to it
+func setPath(node interface{}, path []segment, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	current := path[0]
+	if !current.isIndex {
+		object, isObject := node.(map[string]interface{})
+		if node == nil {
+			object, isObject = make(map[string]interface{}), true
+		}
+		if !isObject {
+			return nil, errors.New(current.key + " can not be set as its parent is not an object")
+		}
+
+		child, err := setPath(object[current.key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		object[current.key] = child
+		return object, nil
+	}
+
+	array, isArray := node.([]interface{})
+	if !isArray {
+		return nil, fmt.Errorf("index %v can not be set as its parent is not an array", current.index)
+	}
+	if current.index > len(array) {
+		return nil, fmt.Errorf("index %v is out of the array of %v items", current.index, len(array))
+	}
+	if current.index == len(array) {
+		array = append(array, nil)
+	}
+
+	child, err := setPath(array[current.index], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	array[current.index] = child
+	return array, nil
+}
+
+// deletePath removes the field or array item in the path of a node, missing paths are left as they are
+func deletePath(node interface{}, path []segment) (interface{}, error) {
+	current := path[0]
+	last := len(path) == 1
+
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		if current.isIndex {
+			return node, nil
+		}
+		child, exists := typed[current.key]
+		if !exists {
+			return node, nil
+		}
+		if last {
+			delete(typed, current.key)
+			return typed, nil
+		}
+		updated, err := deletePath(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		typed[current.key] = updated
+		return typed, nil
+	case []interface{}:
+		if !current.isIndex || current.index >= len(typed) {
+			return node, nil
+		}
+		if last {
+			return append(typed[:current.index], typed[current.index+1:]...), nil
+		}
+		updated, err := deletePath(typed[current.index], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		typed[current.index] = updated
+		return typed, nil
+	}
+
+	return node, nil
+}
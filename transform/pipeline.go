@@ -0,0 +1,258 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/servicebuscli-go/entities"
+	"github.com/cjlapao/servicebuscli-go/filter"
+	"github.com/cjlapao/servicebuscli-go/templating"
+	"gopkg.in/yaml.v2"
+)
+
+// Actions of the transformation steps
+const (
+	// ActionSetProperty sets the user property in name to the value
+	ActionSetProperty = "setProperty"
+	// ActionRemoveProperty removes the user property in name
+	ActionRemoveProperty = "removeProperty"
+	// ActionSetLabel sets the label to the value
+	ActionSetLabel = "setLabel"
+	// ActionSet sets the field of the json body in path to the value, creating the objects in between
+	ActionSet = "set"
+	// ActionDelete removes the field of the json body in path
+	ActionDelete = "delete"
+	// ActionTemplate replaces the body with the result of a go template, the template gets the envelope of the message
+	ActionTemplate = "template"
+)
+
+// Pipeline Chain of transformations applied to the messages before they are printed or sent again, loaded from
+// a yaml file, for example:
+//
+//	steps:
+//	  - action: removeProperty
+//	    name: DeadLetterReason
+//	  - action: setLabel
+//	    value: Order.Created
+//	    where: label = 'OrderCreated'
+//	  - action: set
+//	    path: $.customer.id
+//	    value: 42
+//	  - action: delete
+//	    path: $.debug
+//	  - action: template
+//	    template: '{"order": {{json .Body}}, "source": "replay"}'
+//
+// The steps run in order and every step sees the changes of the previous ones, steps with a where expression
+// only change the messages matching it
+type Pipeline struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Step Transformation of the pipeline, the fields used depend on the action
+type Step struct {
+	Action   string      `yaml:"action"`
+	Name     string      `yaml:"name"`
+	Path     string      `yaml:"path"`
+	Value    interface{} `yaml:"value"`
+	Template string      `yaml:"template"`
+	Where    string      `yaml:"where"`
+
+	path     []segment
+	where    *filter.Expression
+	template *template.Template
+}
+
+// Load Reads and validates a pipeline from a yaml file
+func Load(filePath string) (*Pipeline, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(content)
+}
+
+// Parse Reads and validates a pipeline from yaml
+func Parse(content []byte) (*Pipeline, error) {
+	pipeline := Pipeline{}
+	if err := yaml.UnmarshalStrict(content, &pipeline); err != nil {
+		return nil, err
+	}
+
+	if err := pipeline.Validate(); err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// Validate Checks the steps have what their action needs and compiles their paths, templates and expressions
+func (p *Pipeline) Validate() error {
+	if len(p.Steps) == 0 {
+		return errors.New("the pipeline has no steps")
+	}
+
+	for i := range p.Steps {
+		if err := p.Steps[i].compile(); err != nil {
+			return fmt.Errorf("step %v (%v) %v", i+1, p.Steps[i].Action, err.Error())
+		}
+	}
+	return nil
+}
+
+// Apply Runs the steps on a copy of the message, the received message is not changed so it can still be settled
+func (p *Pipeline) Apply(msg *servicebus.Message) (*servicebus.Message, error) {
+	if p == nil || len(p.Steps) == 0 {
+		return msg, nil
+	}
+
+	result := *msg
+	result.UserProperties = make(map[string]interface{})
+	for key, value := range msg.UserProperties {
+		result.UserProperties[key] = value
+	}
+
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if !step.where.Match(&result) {
+			continue
+		}
+		if err := step.apply(&result); err != nil {
+			return nil, fmt.Errorf("step %v (%v) failed on message %v, %v", i+1, step.Action, msg.ID, err.Error())
+		}
+	}
+	return &result, nil
+}
+
+func (s *Step) compile() error {
+	where, err := filter.Parse(s.Where)
+	if err != nil {
+		return errors.New("has an invalid where expression, " + err.Error())
+	}
+	s.where = where
+	s.Value = jsonValue(s.Value)
+
+	switch s.Action {
+	case ActionSetProperty:
+		if s.Name == "" {
+			return errors.New("needs the name of the property")
+		}
+		switch s.Value.(type) {
+		case string, bool, int, int64, float64:
+		default:
+			return errors.New("needs a text, number or boolean value")
+		}
+	case ActionRemoveProperty:
+		if s.Name == "" {
+			return errors.New("needs the name of the property")
+		}
+	case ActionSetLabel:
+		if _, isText := s.Value.(string); !isText {
+			return errors.New("needs the label in the value")
+		}
+	case ActionSet, ActionDelete:
+		path, err := parsePath(s.Path)
+		if err != nil {
+			return err
+		}
+		if len(path) == 0 {
+			return errors.New("can not change the root of the body, use a template")
+		}
+		s.path = path
+	case ActionTemplate:
+		parsed, err := template.New("body").Funcs(templating.FormatFuncs()).Parse(s.Template)
+		if err != nil {
+			return errors.New("has an invalid template, " + err.Error())
+		}
+		s.template = parsed
+	default:
+		return fmt.Errorf("has an invalid action, use %v, %v, %v, %v, %v or %v", ActionSetProperty, ActionRemoveProperty, ActionSetLabel, ActionSet, ActionDelete, ActionTemplate)
+	}
+
+	return nil
+}
+
+func (s *Step) apply(msg *servicebus.Message) error {
+	switch s.Action {
+	case ActionSetProperty:
+		msg.UserProperties[s.Name] = s.Value
+	case ActionRemoveProperty:
+		delete(msg.UserProperties, s.Name)
+	case ActionSetLabel:
+		msg.Label = s.Value.(string)
+	case ActionSet, ActionDelete:
+		body, err := decodeBody(msg.Data)
+		if err != nil {
+			return err
+		}
+		if s.Action == ActionSet {
+			body, err = setPath(body, s.path, s.Value)
+		} else {
+			body, err = deletePath(body, s.path)
+		}
+		if err != nil {
+			return err
+		}
+		data, err := encodeBody(body)
+		if err != nil {
+			return err
+		}
+		msg.Data = data
+	case ActionTemplate:
+		envelope := entities.MessageEnvelope{}
+		envelope.FromServiceBus(msg)
+		var result bytes.Buffer
+		if err := s.template.Execute(&result, envelope); err != nil {
+			return err
+		}
+		msg.Data = result.Bytes()
+	}
+
+	return nil
+}
+
+// decodeBody decodes a json body keeping the numbers as they are
+func decodeBody(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var body interface{}
+	if err := decoder.Decode(&body); err != nil || decoder.More() {
+		return nil, errors.New("the body is not json")
+	}
+	return body, nil
+}
+
+// encodeBody encodes a json body without escaping the html characters
+func encodeBody(body interface{}) ([]byte, error) {
+	var result bytes.Buffer
+	encoder := json.NewEncoder(&result)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(body); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(result.Bytes(), "\n"), nil
+}
+
+// jsonValue converts the maps decoded from yaml, which can have any key, to json objects
+func jsonValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{})
+		for key, item := range typed {
+			result[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0)
+		for _, item := range typed {
+			result = append(result, jsonValue(item))
+		}
+		return result
+	}
+	return value
+}
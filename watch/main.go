@@ -0,0 +1,258 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/cjlapao/common-go/log"
+	sbcli "github.com/cjlapao/servicebuscli-go/servicebus"
+)
+
+var logger = log.Get()
+
+// Watcher Polls the entities of the rules and notifies the webhooks when a rule fires and when it resolves,
+// a rule only notifies once until its state changes
+type Watcher struct {
+	rules  *Rules
+	cli    *sbcli.ServiceBusCli
+	client *http.Client
+	states map[string]*ruleState
+}
+
+// ruleState is what the watcher remembers of a rule between polls
+type ruleState struct {
+	value    float64
+	sampled  bool
+	sampleAt time.Time
+
+	matchingSince time.Time
+	growingSince  time.Time
+	increasedAt   time.Time
+
+	firing      bool
+	firingSince time.Time
+	resolvedAt  time.Time
+	// webhooks that already got the current state of the rule, only the ones that got the rule firing
+	// are told when it resolves
+	notified map[string]bool
+	firedTo  map[string]bool
+}
+
+// entityCounts are the counts of an entity in a poll
+type entityCounts struct {
+	details *servicebus.CountDetails
+	size    *int64
+}
+
+// NewWatcher Creates a watcher for the rules, they need to be validated
+func NewWatcher(cli *sbcli.ServiceBusCli, rules *Rules) *Watcher {
+	states := make(map[string]*ruleState)
+	for _, rule := range rules.Rules {
+		states[rule.Name] = &ruleState{notified: make(map[string]bool)}
+	}
+
+	return &Watcher{
+		rules:  rules,
+		cli:    cli,
+		client: &http.Client{},
+		states: states,
+	}
+}
+
+// Run Polls the entities straight away and then every interval until the context is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.rules.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll Gets the counts of the entities, evaluates the rules and sends the notifications, notifications that
+// could not be sent are tried again in the next poll
+func (w *Watcher) Poll(ctx context.Context) {
+	counts := make(map[string]*entityCounts)
+	now := time.Now()
+
+	for i := range w.rules.Rules {
+		rule := &w.rules.Rules[i]
+		state := w.states[rule.Name]
+
+		entity := rule.Entity()
+		if _, exists := counts[entity]; !exists {
+			entityCounts, err := w.getCounts(rule)
+			if err != nil {
+				logger.LogHighlight("Could not get the counts of %v for rule %v, %v", log.Error, entity, rule.Name, err.Error())
+			}
+			counts[entity] = entityCounts
+		}
+		if counts[entity] == nil {
+			continue
+		}
+
+		value, ok := counts[entity].value(rule.Metric)
+		if !ok {
+			logger.LogHighlight("The %v metric is not available for %v in rule %v", log.Warning, rule.Metric, entity, rule.Name)
+			continue
+		}
+
+		if state.evaluate(rule, value, now) {
+			if state.firing {
+				logger.LogHighlight("Rule %v is firing, %v of %v is %v", log.Warning, rule.Name, rule.Metric, entity, formatValue(value))
+			} else {
+				logger.LogHighlight("Rule %v is resolved, %v of %v is %v", log.Info, rule.Name, rule.Metric, entity, formatValue(value))
+			}
+		}
+
+		w.notify(ctx, rule, state)
+	}
+}
+
+// getCounts gets the counts of the entity a rule watches
+func (w *Watcher) getCounts(rule *Rule) (*entityCounts, error) {
+	switch {
+	case rule.Queue != "":
+		queue, err := w.cli.GetQueueDetails(rule.Queue)
+		if err != nil {
+			return nil, err
+		}
+		if queue == nil {
+			return nil, errors.New("could not find queue " + rule.Queue)
+		}
+		return &entityCounts{details: queue.CountDetails, size: queue.SizeInBytes}, nil
+	case rule.Subscription != "":
+		subscription, err := w.cli.GetSubscription(rule.Topic, rule.Subscription)
+		if err != nil {
+			return nil, err
+		}
+		return &entityCounts{details: subscription.CountDetails}, nil
+	default:
+		topic := w.cli.GetTopicDetails(rule.Topic)
+		if topic == nil {
+			return nil, errors.New("could not find topic " + rule.Topic)
+		}
+		return &entityCounts{details: topic.CountDetails, size: topic.SizeInBytes}, nil
+	}
+}
+
+// notify sends the current state of the rule to the webhooks that did not get it yet
+func (w *Watcher) notify(ctx context.Context, rule *Rule, state *ruleState) {
+	// a rule that never fired has nothing to resolve
+	if !state.firing && state.resolvedAt.IsZero() {
+		return
+	}
+
+	status := StatusResolved
+	if state.firing {
+		status = StatusFiring
+	}
+	notification := newNotification(status, w.cli.Namespace.Name, rule, state)
+
+	for i := range w.rules.Webhooks {
+		webhook := &w.rules.Webhooks[i]
+		if !rule.usesWebhook(webhook.Name) || state.notified[webhook.Name] {
+			continue
+		}
+		if !state.firing && !state.firedTo[webhook.Name] {
+			continue
+		}
+
+		if err := webhook.send(ctx, w.client, notification); err != nil {
+			logger.LogHighlight("Could not notify webhook %v of rule %v, %v", log.Error, webhook.Name, rule.Name, err.Error())
+			continue
+		}
+		state.notified[webhook.Name] = true
+	}
+}
+
+// evaluate updates the state with a new value of the metric and returns true if the rule changed between
+// firing and resolved
+func (s *ruleState) evaluate(rule *Rule, value float64, now time.Time) bool {
+	matching := false
+	if rule.operator == ConditionGrowing {
+		switch {
+		case !s.sampled || value < s.value:
+			s.growingSince = time.Time{}
+		case value > s.value:
+			if s.growingSince.IsZero() {
+				// it started growing after the previous sample
+				s.growingSince = s.sampleAt
+			}
+			s.increasedAt = now
+		case now.Sub(s.increasedAt) >= rule.For:
+			// a plateau longer than the window ends the growth, shorter ones only pause it
+			s.growingSince = time.Time{}
+		}
+		// it needs to be growing for the whole window and to have gone up within it
+		matching = !s.growingSince.IsZero() && now.Sub(s.growingSince) >= rule.For && now.Sub(s.increasedAt) < rule.For
+	} else if rule.matches(value) {
+		if s.matchingSince.IsZero() {
+			s.matchingSince = now
+		}
+		matching = now.Sub(s.matchingSince) >= rule.For
+	} else {
+		s.matchingSince = time.Time{}
+	}
+
+	s.value = value
+	s.sampled = true
+	s.sampleAt = now
+
+	if matching == s.firing {
+		return false
+	}
+
+	s.firing = matching
+	if matching {
+		s.firingSince = now
+		s.resolvedAt = time.Time{}
+		s.firedTo = nil
+	} else {
+		s.resolvedAt = now
+		s.firedTo = s.notified
+	}
+	s.notified = make(map[string]bool)
+	return true
+}
+
+// value gets the value of a metric, false if the service bus did not return it
+func (c *entityCounts) value(metric string) (float64, bool) {
+	if metric == MetricSize {
+		if c.size == nil {
+			return 0, false
+		}
+		return float64(*c.size), true
+	}
+
+	if c.details == nil {
+		return 0, false
+	}
+
+	var count *int32
+	switch metric {
+	case MetricActive:
+		count = c.details.ActiveMessageCount
+	case MetricDeadLetter:
+		count = c.details.DeadLetterMessageCount
+	case MetricScheduled:
+		count = c.details.ScheduledMessageCount
+	case MetricTransfer:
+		count = c.details.TransferMessageCount
+	case MetricTransferDeadLetter:
+		count = c.details.TransferDeadLetterMessageCount
+	}
+	if count == nil {
+		return 0, false
+	}
+
+	return float64(*count), true
+}
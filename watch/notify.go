@@ -0,0 +1,145 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cjlapao/servicebuscli-go/templating"
+)
+
+// webhookTimeout is how long a webhook has to answer
+const webhookTimeout = 10 * time.Second
+
+// Status of a notification
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Notification State of a rule sent to the webhooks when it fires or resolves
+type Notification struct {
+	Status     string     `json:"status"`
+	Rule       string     `json:"rule"`
+	Namespace  string     `json:"namespace"`
+	Entity     string     `json:"entity"`
+	Metric     string     `json:"metric"`
+	Condition  string     `json:"condition"`
+	Value      float64    `json:"value"`
+	Message    string     `json:"message"`
+	StartsAt   time.Time  `json:"startsAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+func newNotification(status string, namespace string, rule *Rule, state *ruleState) Notification {
+	notification := Notification{
+		Status:    status,
+		Rule:      rule.Name,
+		Namespace: namespace,
+		Entity:    rule.Entity(),
+		Metric:    rule.Metric,
+		Condition: rule.Condition,
+		Value:     state.value,
+		StartsAt:  state.firingSince,
+	}
+	if status == StatusResolved {
+		resolvedAt := state.resolvedAt
+		notification.ResolvedAt = &resolvedAt
+	}
+
+	notification.Message = fmt.Sprintf("[%v] %v: %v of %v in %v is %v, condition %v", strings.ToUpper(status), rule.Name, metricDescriptions[rule.Metric], rule.Entity(), namespace, formatValue(state.value), rule.Condition)
+	if rule.For > 0 {
+		notification.Message += " for " + rule.For.String()
+	}
+	return notification
+}
+
+// body builds the body of the notification in the format of the webhook
+func (w *Webhook) body(notification Notification) ([]byte, error) {
+	switch w.Format {
+	case FormatSlack:
+		return marshal(map[string]interface{}{
+			"text": notification.Message,
+		})
+	case FormatTeams:
+		color := "d63333"
+		if notification.Status == StatusResolved {
+			color = "2eb886"
+		}
+		return marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "http://schema.org/extensions",
+			"themeColor": color,
+			"summary":    notification.Message,
+			"title":      "[" + notification.Status + "] " + notification.Rule,
+			"text":       notification.Message,
+		})
+	}
+
+	if w.Template == "" {
+		return marshal(notification)
+	}
+
+	parsed, err := template.New(w.Name).Funcs(templating.FormatFuncs()).Parse(w.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := parsed.Execute(&buffer, notification); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// send posts the notification to the webhook
+func (w *Webhook) send(ctx context.Context, client *http.Client, notification Notification) error {
+	body, err := w.body(notification)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range w.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook %v answered with status %v", w.Name, response.Status)
+	}
+	return nil
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// marshal encodes json without escaping the html characters as the conditions use < and >
+func marshal(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+}
@@ -0,0 +1,263 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultInterval is how often the entities are polled if the rules file does not set an interval
+const DefaultInterval = 30 * time.Second
+
+// Metrics of an entity a rule can watch
+const (
+	MetricActive             = "active"
+	MetricDeadLetter         = "deadLetter"
+	MetricScheduled          = "scheduled"
+	MetricTransfer           = "transfer"
+	MetricTransferDeadLetter = "transferDeadLetter"
+	MetricSize               = "size"
+)
+
+var metricDescriptions = map[string]string{
+	MetricActive:             "active messages",
+	MetricDeadLetter:         "dead letter messages",
+	MetricScheduled:          "scheduled messages",
+	MetricTransfer:           "transfer messages",
+	MetricTransferDeadLetter: "transfer dead letter messages",
+	MetricSize:               "size in bytes",
+}
+
+// ConditionGrowing fires when the metric keeps growing for the duration of the rule instead of comparing it
+// with a threshold
+const ConditionGrowing = "growing"
+
+// Formats of the webhook bodies
+const (
+	FormatGeneric = "generic"
+	FormatSlack   = "slack"
+	FormatTeams   = "teams"
+)
+
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// Rules Alert rules and the webhooks they notify, loaded from a yaml file, for example:
+//
+//	interval: 30s
+//	webhooks:
+//	  - name: slack
+//	    url: https://hooks.slack.com/services/...
+//	    format: slack
+//	rules:
+//	  - name: billing-dead-letters
+//	    topic: orders
+//	    subscription: billing
+//	    metric: deadLetter
+//	    condition: "> 0"
+//	  - name: queue-growing
+//	    queue: example.queue
+//	    metric: active
+//	    condition: growing
+//	    for: 10m
+type Rules struct {
+	Interval time.Duration `yaml:"interval"`
+	Webhooks []Webhook     `yaml:"webhooks"`
+	Rules    []Rule        `yaml:"rules"`
+}
+
+// Webhook Http endpoint the notifications are posted to, the generic format posts the notification as json
+// or the result of the template if there is one
+type Webhook struct {
+	Name     string            `yaml:"name"`
+	URL      string            `yaml:"url"`
+	Format   string            `yaml:"format"`
+	Template string            `yaml:"template"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// Rule Condition on a metric of a queue, topic or subscription, the condition needs to be true for the
+// duration of the rule before it fires, the rule notifies all the webhooks if none is set
+type Rule struct {
+	Name         string        `yaml:"name"`
+	Queue        string        `yaml:"queue"`
+	Topic        string        `yaml:"topic"`
+	Subscription string        `yaml:"subscription"`
+	Metric       string        `yaml:"metric"`
+	Condition    string        `yaml:"condition"`
+	For          time.Duration `yaml:"for"`
+	Webhooks     []string      `yaml:"webhooks"`
+
+	operator  string
+	threshold float64
+}
+
+// LoadRules Reads and validates the rules from a yaml file
+func LoadRules(filePath string) (*Rules, error) {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := Rules{}
+	if err := yaml.UnmarshalStrict(content, &rules); err != nil {
+		return nil, err
+	}
+
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+// Validate Checks the rules and the webhooks, setting the defaults
+func (r *Rules) Validate() error {
+	if r.Interval <= 0 {
+		r.Interval = DefaultInterval
+	}
+	if len(r.Rules) == 0 {
+		return errors.New("there are no rules to watch")
+	}
+
+	webhooks := make(map[string]bool)
+	for i := range r.Webhooks {
+		webhook := &r.Webhooks[i]
+		if webhook.URL == "" {
+			return fmt.Errorf("webhook %v needs an url", i+1)
+		}
+		if webhook.Name == "" {
+			webhook.Name = webhook.URL
+		}
+		switch webhook.Format {
+		case "":
+			webhook.Format = FormatGeneric
+		case FormatGeneric, FormatSlack, FormatTeams:
+		default:
+			return fmt.Errorf("webhook %v has an invalid format %v, it can be %v, %v or %v", webhook.Name, webhook.Format, FormatGeneric, FormatSlack, FormatTeams)
+		}
+		if webhook.Template != "" && webhook.Format != FormatGeneric {
+			return fmt.Errorf("webhook %v can only use a template with the %v format", webhook.Name, FormatGeneric)
+		}
+		webhooks[webhook.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if rule.Name == "" {
+			return fmt.Errorf("rule %v needs a name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("there is more than one rule named %v", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %v %v", rule.Name, err.Error())
+		}
+		for _, name := range rule.Webhooks {
+			if !webhooks[name] {
+				return fmt.Errorf("rule %v uses the webhook %v that does not exist", rule.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Rule) validate() error {
+	switch {
+	case r.Queue != "" && r.Topic != "":
+		return errors.New("can only watch a queue or a topic")
+	case r.Queue == "" && r.Topic == "":
+		return errors.New("needs a queue or a topic")
+	case r.Queue != "" && r.Subscription != "":
+		return errors.New("can only have a subscription with a topic")
+	}
+
+	switch r.Metric {
+	case MetricActive, MetricDeadLetter, MetricScheduled, MetricTransfer, MetricTransferDeadLetter:
+	case MetricSize:
+		if r.Subscription != "" {
+			return errors.New("can not watch the size of a subscription")
+		}
+	default:
+		return fmt.Errorf("has an invalid metric %v, it can be %v", r.Metric, strings.Join([]string{MetricActive, MetricDeadLetter, MetricScheduled, MetricTransfer, MetricTransferDeadLetter, MetricSize}, ", "))
+	}
+
+	if r.For < 0 {
+		return errors.New("can not have a negative duration")
+	}
+
+	condition := strings.TrimSpace(r.Condition)
+	if condition == ConditionGrowing {
+		if r.For <= 0 {
+			return errors.New("needs a duration to know for how long the metric has to grow")
+		}
+		r.operator = ConditionGrowing
+		return nil
+	}
+
+	for _, operator := range operators {
+		if strings.HasPrefix(condition, operator) {
+			if _, err := fmt.Sscanf(strings.TrimSpace(strings.TrimPrefix(condition, operator)), "%g", &r.threshold); err != nil {
+				return fmt.Errorf("has an invalid threshold in the condition %v", r.Condition)
+			}
+			r.operator = operator
+			return nil
+		}
+	}
+
+	return fmt.Errorf("has an invalid condition %v, it can be %v or an operator like \"> 0\"", r.Condition, ConditionGrowing)
+}
+
+// Entity Gets the description of the entity the rule watches
+func (r *Rule) Entity() string {
+	switch {
+	case r.Queue != "":
+		return "queue " + r.Queue
+	case r.Subscription != "":
+		return "subscription " + r.Subscription + " of topic " + r.Topic
+	default:
+		return "topic " + r.Topic
+	}
+}
+
+// usesWebhook returns true if the rule notifies the webhook
+func (r *Rule) usesWebhook(name string) bool {
+	if len(r.Webhooks) == 0 {
+		return true
+	}
+
+	for _, webhook := range r.Webhooks {
+		if webhook == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches compares the value with the threshold of the rule
+func (r *Rule) matches(value float64) bool {
+	switch r.operator {
+	case ">":
+		return value > r.threshold
+	case ">=":
+		return value >= r.threshold
+	case "<":
+		return value < r.threshold
+	case "<=":
+		return value <= r.threshold
+	case "==":
+		return value == r.threshold
+	case "!=":
+		return value != r.threshold
+	}
+
+	return false
+}